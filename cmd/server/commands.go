@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"marketpulse/internal/config"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/internal/infra/filestore"
+)
+
+// runCommand dispatches CLI subcommands. The server runs when no
+// arguments are given.
+func runCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	switch args[0] {
+	case "state":
+		return runStateCommand(cfg, logger, args[1:])
+	case "backtest":
+		return runBacktestCommand(cfg, logger, args[1:])
+	case "record":
+		return runRecordCommand(cfg, logger, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q (want: state, backtest, record)", args[0])
+	}
+}
+
+// runStateCommand implements `state export` and `state import`.
+//
+//	marketpulse state export [-format json|ndjson] [-o file]
+//	marketpulse state import [-i file]
+func runStateCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: state export|import [flags]")
+	}
+
+	stateRepo, _, _, closeState, err := newStores(cfg, logger)
+	if errors.Is(err, filestore.ErrLocked) {
+		return fmt.Errorf("%w; stop the server or use its /v1/admin/state endpoints", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer closeState()
+
+	symbolPolicy, err := service.NewSymbolPolicy(cfg.SymbolPattern, cfg.SymbolAllowlist, cfg.SymbolDenylist)
+	if err != nil {
+		return err
+	}
+	stateSvc := service.NewStateService(stateRepo, symbolPolicy)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("state export", flag.ExitOnError)
+		format := fs.String("format", service.FormatNDJSON, "output format: json or ndjson")
+		out := fs.String("o", "-", "output file (- for stdout)")
+		fs.Parse(args[1:])
+
+		var w io.Writer = os.Stdout
+		if *out != "-" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		n, err := stateSvc.Export(ctx, w, *format)
+		if err != nil {
+			return err
+		}
+		logger.Info("state exported", zap.Int("symbols", n), zap.String("file", *out))
+		return nil
+
+	case "import":
+		fs := flag.NewFlagSet("state import", flag.ExitOnError)
+		in := fs.String("i", "-", "input file (- for stdin)")
+		fs.Parse(args[1:])
+
+		var r io.Reader = os.Stdin
+		if *in != "-" {
+			f, err := os.Open(*in)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		n, err := stateSvc.Import(ctx, r)
+		if err != nil {
+			return fmt.Errorf("imported %d before error: %w", n, err)
+		}
+		logger.Info("state imported", zap.Int("symbols", n), zap.String("file", *in))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown state command %q (want: export, import)", args[0])
+	}
+}
+
+// runBacktestCommand runs one backtest and writes the result as JSON.
+//
+//	marketpulse backtest -symbol IBM [-i candles.json] [-rsi-low 30] [-rsi-high 70]
+//	    [-stop-loss 2] [-take-profit 4] [-max-hold 24] [-capital 10000] [-o file]
+func runBacktestCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	symbol := fs.String("symbol", "", "symbol to backtest (required)")
+	in := fs.String("i", "", "JSON array of candles to replay instead of fetching history")
+	low := fs.Float64("rsi-low", 30, "enter when RSI falls to this level")
+	high := fs.Float64("rsi-high", 70, "exit when RSI rises to this level")
+	stopLoss := fs.Float64("stop-loss", 0, "stop loss in percent below entry (0 = off)")
+	takeProfit := fs.Float64("take-profit", 0, "take profit in percent above entry (0 = off)")
+	maxHold := fs.Int("max-hold", 0, "exit after this many bars (0 = off)")
+	capital := fs.Float64("capital", 0, "initial capital (default 10000)")
+	out := fs.String("o", "-", "output file (- for stdout)")
+	fs.Parse(args)
+
+	if *symbol == "" {
+		return fmt.Errorf("usage: backtest -symbol SYMBOL [flags]")
+	}
+
+	req := entity.BacktestRequest{
+		Symbol:         *symbol,
+		RSILow:         low,
+		RSIHigh:        high,
+		StopLossPct:    *stopLoss,
+		TakeProfitPct:  *takeProfit,
+		MaxHoldBars:    *maxHold,
+		InitialCapital: *capital,
+	}
+	if *in != "" {
+		b, err := os.ReadFile(*in)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &req.Candles); err != nil {
+			return fmt.Errorf("decode candles: %w", err)
+		}
+	}
+
+	symbolPolicy, err := service.NewSymbolPolicy(cfg.SymbolPattern, cfg.SymbolAllowlist, cfg.SymbolDenylist)
+	if err != nil {
+		return err
+	}
+	feedCli, err := newFeed(cfg, logger)
+	if err != nil {
+		return err
+	}
+	backtestSvc := service.NewBacktestService(feedCli, symbolPolicy)
+
+	res, err := backtestSvc.Run(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		return err
+	}
+
+	logger.Info("backtest complete",
+		zap.String("symbol", res.Symbol),
+		zap.Int("candles", res.Candles),
+		zap.Int("trades", len(res.Trades)),
+		zap.Float64("total_return_pct", res.TotalReturnPct),
+		zap.Float64("win_rate", res.WinRate),
+		zap.Float64("max_drawdown_pct", res.MaxDrawdownPct),
+		zap.Float64("sharpe", res.Sharpe),
+	)
+	return nil
+}
+
+// runRecordCommand fetches the upstream history of symbols into a replay
+// dataset (see FEED_MODE=replay). With -append, candles are merged into
+// an existing file, so running it periodically grows the dataset.
+//
+//	marketpulse record -symbols IBM,AAPL -o dataset.json [-append]
+func runRecordCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	symbols := fs.String("symbols", "", "comma-separated symbols to record (required)")
+	out := fs.String("o", "", "dataset file (required)")
+	appendTo := fs.Bool("append", false, "merge into an existing dataset")
+	fs.Parse(args)
+
+	if *symbols == "" || *out == "" {
+		return fmt.Errorf("usage: record -symbols IBM,AAPL -o dataset.json [-append]")
+	}
+
+	series := map[string][]feed.Candle{}
+	if *appendTo {
+		existing, err := feed.LoadDataset(*out)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if existing != nil {
+			series = existing
+		}
+	}
+
+	feedClient := feed.NewClient(cfg)
+	ctx := context.Background()
+	for _, sym := range strings.Split(*symbols, ",") {
+		sym = strings.ToUpper(strings.TrimSpace(sym))
+		if sym == "" {
+			continue
+		}
+		candles, err := feedClient.FetchIntraday(ctx, sym, time.Time{})
+		if err != nil {
+			return fmt.Errorf("fetch %s: %w", sym, err)
+		}
+		series[sym] = append(series[sym], candles...)
+		logger.Info("recorded", zap.String("symbol", sym), zap.Int("candles", len(candles)))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return feed.WriteDataset(f, series)
+}
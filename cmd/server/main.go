@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,7 +16,10 @@ import (
 	"marketpulse/internal/config"
 	"marketpulse/internal/domain/service"
 	"marketpulse/internal/infra/feed"
+	"marketpulse/internal/infra/filestore"
 	"marketpulse/internal/infra/redis"
+	"marketpulse/internal/infra/webhook"
+	"marketpulse/internal/worker"
 )
 
 func main() {
@@ -24,18 +28,83 @@ func main() {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
-	redisClient := redis.NewClient(cfg)
-	feedClient := feed.NewClient(cfg)
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, logger, os.Args[1:]); err != nil {
+			logger.Fatal("command failed", zap.Error(err))
+		}
+		return
+	}
+
+	feedClient, err := newFeed(cfg, logger)
+	if err != nil {
+		logger.Fatal("feed", zap.Error(err))
+	}
+
+	stateRepo, hashStore, cache, closeState, err := newStores(cfg, logger)
+	if err != nil {
+		logger.Fatal("state store", zap.Error(err))
+	}
+	defer closeState()
+
+	symbolPolicy, err := service.NewSymbolPolicy(cfg.SymbolPattern, cfg.SymbolAllowlist, cfg.SymbolDenylist)
+	if err != nil {
+		logger.Fatal("symbol policy", zap.Error(err))
+	}
+
+	calendar, err := newCalendar(cfg)
+	if err != nil {
+		logger.Fatal("market calendar", zap.Error(err))
+	}
+
+	// Replays run on their virtual clock, and so does the poller
+	clock := service.Clock(time.Now)
+	pollInterval, pollDelay := cfg.PollInterval, cfg.PollDelay
+	if replay, ok := feedClient.(*feed.Replay); ok {
+		clock = replay.Now
+		pollInterval = time.Duration(float64(pollInterval) / cfg.ReplaySpeed)
+		pollDelay = time.Duration(float64(pollDelay) / cfg.ReplaySpeed)
+	}
+
+	intradaySvc := service.NewIntradayService(stateRepo, feedClient, symbolPolicy, calendar)
+	intradaySvc.UseClock(clock)
+	intradayCache := service.NewIntradayCache(intradaySvc, cfg.MicroCacheTTL)
+
+	stateSvc := service.NewStateService(stateRepo, symbolPolicy)
+	directorySvc := service.NewDirectoryService(feedClient, cache, symbolPolicy, cfg.SymbolCacheTTL)
+	quoteSvc := service.NewQuoteService(feedClient, cache, symbolPolicy, cfg.QuoteCacheTTL)
+	quoteSvc.UseClock(clock)
+	screenerSvc := service.NewScreenerService(stateRepo)
+	backtestSvc := service.NewBacktestService(feedClient, symbolPolicy)
+	watchlistSvc := service.NewWatchlistService(hashStore, stateRepo, intradaySvc, symbolPolicy)
+	alertSvc := service.NewAlertService(hashStore, symbolPolicy)
+	alertSvc.UseClock(clock)
+	intradaySvc.OnUpdate(alertSvc.Evaluate)
+	streamHub := service.NewStreamHub(symbolPolicy)
+	streamHub.UseClock(clock)
+	intradaySvc.OnUpdate(streamHub.Publish)
+
+	webhookSvc := service.NewWebhookService(hashStore, webhook.NewSender(cfg.WebhookTimeout, cfg.WebhookAllowPrivate), service.WebhookOptions{
+		Workers:      cfg.WebhookWorkers,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		QueueSize:    cfg.WebhookQueueSize,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+	})
+	alertSvc.OnFire(webhookSvc.Enqueue)
+	webhookSvc.Start(context.Background())
 
-	stateRepo := redis.NewStateRepository(redisClient, cfg.MaxSymbols)
-	intradaySvc := service.NewIntradayService(stateRepo, feedClient)
+	// The poller always runs: streamed symbols are polled while watched
+	// even when POLL_SYMBOLS is empty.
+	poller := worker.NewPoller(intradaySvc, calendar, cfg.PollSymbols, pollInterval, pollDelay, cfg.PollConcurrency, logger)
+	poller.Start(context.Background())
 
-	router := api.NewRouter(cfg, logger, intradaySvc)
+	router := api.NewRouter(cfg, logger, intradaySvc, intradayCache, stateSvc, directorySvc, quoteSvc, screenerSvc, backtestSvc, watchlistSvc, alertSvc, webhookSvc, streamHub, poller)
 
 	srv := &http.Server{
 		Addr:    cfg.HTTPPort,
 		Handler: router,
 	}
+	// Streams never go idle; end them so Shutdown can finish.
+	srv.RegisterOnShutdown(streamHub.Close)
 
 	go func() {
 		logger.Info("server started", zap.String("addr", cfg.HTTPPort))
@@ -58,4 +127,87 @@ func main() {
 	} else {
 		logger.Info("shutdown complete")
 	}
+	poller.Stop()
+	webhookSvc.Stop()
+}
+
+// newFeed returns the upstream client, or a replay of REPLAY_FILE when
+// FEED_MODE=replay. Both also serve symbol reference data and quotes.
+func newFeed(cfg *config.Config, logger *zap.Logger) (feedSource, error) {
+	switch cfg.FeedMode {
+	case "replay":
+		replay, err := feed.NewReplay(cfg.ReplayFile, cfg.ReplaySpeed, cfg.ReplayWarmupBars, cfg.ReplayLoop)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("replaying recorded feed",
+			zap.String("file", cfg.ReplayFile),
+			zap.Float64("speed", cfg.ReplaySpeed),
+			zap.Time("virtual_start", replay.Now()),
+			zap.Bool("loop", cfg.ReplayLoop),
+		)
+		return replay, nil
+	case "live":
+		return feed.NewClient(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown FEED_MODE %q (want: live, replay)", cfg.FeedMode)
+	}
+}
+
+// newCalendar returns the exchange calendar, or nil (always open) for
+// MARKET_CALENDAR=always and replayed feeds, whose clock is virtual.
+func newCalendar(cfg *config.Config) (*service.Calendar, error) {
+	if cfg.FeedMode == "replay" {
+		return nil, nil
+	}
+	switch cfg.MarketCalendar {
+	case "always":
+		return nil, nil
+	case "us":
+		return service.NewCalendar(service.CalendarConfig{
+			Location:     cfg.MarketTimezone,
+			PreOpen:      cfg.MarketPreOpen,
+			Open:         cfg.MarketOpen,
+			Close:        cfg.MarketClose,
+			PostClose:    cfg.MarketPostClose,
+			HolidaysFile: cfg.MarketHolidaysFile,
+			Extended:     cfg.MarketExtendedHours,
+			StaleAfter:   cfg.MarketStaleAfter,
+		})
+	default:
+		return nil, fmt.Errorf("unknown MARKET_CALENDAR %q (want: us, always)", cfg.MarketCalendar)
+	}
+}
+
+// feedSource is an upstream of candles, symbol reference data and
+// quotes.
+type feedSource interface {
+	service.CandleFeed
+	service.SymbolDirectory
+	service.QuoteSource
+}
+
+// newStores builds the configured state backend, the hash store used for
+// per-user documents and the cache for upstream lookups (reference data,
+// quotes), which is in memory without Redis. The returned close func flushes file-backed
+// state and is a no-op for Redis.
+func newStores(cfg *config.Config, logger *zap.Logger) (service.StateRepository, service.HashStore, service.Cache, func(), error) {
+	switch cfg.StateBackend {
+	case "file":
+		fileStore, err := filestore.NewStore(cfg.StateDir, cfg.StateSnapshotInterval)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		logger.Info("using file state store", zap.String("dir", cfg.StateDir))
+		return fileStore, fileStore, service.NewMemoryCache(), func() {
+			if err := fileStore.Close(); err != nil {
+				logger.Error("file state store close", zap.Error(err))
+			}
+		}, nil
+	default:
+		redisClient := redis.NewClient(cfg)
+		retention := redis.Retention{StateTTL: cfg.StateTTL, HistoryTTL: cfg.HistoryTTL}
+		stateRepo := redis.NewStateRepository(redisClient, cfg.MaxSymbols, retention)
+		return stateRepo, redis.NewHashStore(redisClient, retention), redis.NewCache(redisClient), func() {}, nil
+	}
 }
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type AlertHandler struct {
+	alertSvc *service.AlertService
+}
+
+func NewAlertHandler(svc *service.AlertService) *AlertHandler {
+	return &AlertHandler{alertSvc: svc}
+}
+
+func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alertSvc.ListRules(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, rules)
+}
+
+func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	req := entity.AlertRuleRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	rule, err := h.alertSvc.CreateRule(r.Context(), middleware.UserIDFromContext(r.Context()), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, rule)
+}
+
+// UpdateRule toggles a rule with {"enabled": bool}.
+func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Enabled *bool `json:"enabled" validate:"required"`
+	}{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	rule, err := h.alertSvc.SetRuleEnabled(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), *req.Enabled)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, rule)
+}
+
+func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.alertSvc.DeleteRule(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+		renderError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListEvents returns the caller's fired alerts, newest first. Optional
+// filters: symbol, type, from and to (RFC 3339), acknowledged and limit.
+func (h *AlertHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	f, err := parseEventFilter(r)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	events, err := h.alertSvc.Events(r.Context(), middleware.UserIDFromContext(r.Context()), f)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, events)
+}
+
+func (h *AlertHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	ev, err := h.alertSvc.Acknowledge(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, ev)
+}
+
+// Snooze silences a rule with {"duration": "4h"} or {"until": "<RFC 3339>"}.
+func (h *AlertHandler) Snooze(w http.ResponseWriter, r *http.Request) {
+	req := entity.SnoozeRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	var until time.Time
+	switch {
+	case req.Until != nil && req.Duration == "":
+		until = *req.Until
+	case req.Duration != "" && req.Until == nil:
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			renderError(w, r, entity.ErrBadRequest("duration must be a positive duration such as 30m or 4h"))
+			return
+		}
+		until = time.Now().Add(d)
+	default:
+		renderError(w, r, entity.ErrBadRequest("exactly one of duration or until required"))
+		return
+	}
+
+	rule, err := h.alertSvc.Snooze(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), &until)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, rule)
+}
+
+func (h *AlertHandler) Unsnooze(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.alertSvc.Snooze(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), nil)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, rule)
+}
+
+func (h *AlertHandler) ListMutes(w http.ResponseWriter, r *http.Request) {
+	mutes, err := h.alertSvc.Mutes(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, mutes)
+}
+
+func (h *AlertHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	m, err := h.alertSvc.Mute(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "symbol"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, m)
+}
+
+func (h *AlertHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	if err := h.alertSvc.Unmute(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "symbol")); err != nil {
+		renderError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseEventFilter(r *http.Request) (entity.AlertEventFilter, error) {
+	q := r.URL.Query()
+	f := entity.AlertEventFilter{
+		Symbol: strings.ToUpper(strings.TrimSpace(q.Get("symbol"))),
+		Type:   q.Get("type"),
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, entity.ErrBadRequest("from must be an RFC 3339 time")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, entity.ErrBadRequest("to must be an RFC 3339 time")
+		}
+	}
+	if v := q.Get("acknowledged"); v != "" {
+		ack, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, entity.ErrBadRequest("acknowledged must be true or false")
+		}
+		f.Acknowledged = &ack
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			return f, entity.ErrBadRequest("limit must be a non-negative integer")
+		}
+	}
+	return f, nil
+}
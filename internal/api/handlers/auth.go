@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/go-chi/render"
 
@@ -20,22 +21,25 @@ func NewAuthHandler(cfg *config.Config) *AuthHandler {
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	req := entity.LoginRequest{}
-	if err := render.DecodeJSON(r.Body, &req); err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "invalid JSON"})
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
 		return
 	}
 
 	// Demo user
 	if req.Username != "demo" || req.Password != "demo" {
-		render.Status(r, http.StatusUnauthorized)
-		render.JSON(w, r, map[string]string{"error": "invalid credentials"})
+		renderError(w, r, entity.NewError(http.StatusUnauthorized, entity.CodeInvalidCredentials, "invalid credentials"))
 		return
 	}
 
-	token, _ := middleware.GenerateJWT(h.cfg.JWTSecret, "demo_user_1", h.cfg.JWTExpiry)
+	userID := "demo_user_1"
+	role := ""
+	if slices.Contains(h.cfg.AdminUsers, userID) {
+		role = middleware.RoleAdmin
+	}
+	token, _ := middleware.GenerateJWT(h.cfg.JWTSecret, userID, role, h.cfg.JWTExpiry)
 	render.JSON(w, r, entity.LoginResponse{
 		Token:  token,
-		UserID: "demo_user_1",
+		UserID: userID,
 	})
 }
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type BacktestHandler struct {
+	backtestSvc *service.BacktestService
+}
+
+func NewBacktestHandler(svc *service.BacktestService) *BacktestHandler {
+	return &BacktestHandler{backtestSvc: svc}
+}
+
+// Run handles POST /backtest. Without candles in the body, the symbol's
+// history is fetched from upstream.
+func (h *BacktestHandler) Run(w http.ResponseWriter, r *http.Request) {
+	req := entity.BacktestRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	res, err := h.backtestSvc.Run(r.Context(), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, res)
+}
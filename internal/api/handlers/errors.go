@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+)
+
+// renderError writes err in the uniform error envelope; see
+// middleware.RenderError.
+func renderError(w http.ResponseWriter, r *http.Request, err error) {
+	middleware.RenderError(w, r, err)
+}
+
+// decodeJSON decodes the request body into v and enforces its validate
+// tags.
+func decodeJSON(r *http.Request, v any) error {
+	if err := render.DecodeJSON(r.Body, v); err != nil {
+		return entity.NewError(http.StatusBadRequest, entity.CodeInvalidJSON, "invalid JSON")
+	}
+	return middleware.ValidateStruct(v)
+}
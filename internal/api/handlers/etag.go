@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// intradayETag is a weak validator for an intraday response. It is
+// derived from the state version (RSI count and last bar time), the bars
+// returned, the market status and stale flag, and the request path and
+// parameters, so it changes when a new candle is applied, the market
+// opens or closes, the data turns stale or the client asks for something
+// else. It is weak because the
+// compressor serves br, gzip and identity bodies under the same tag.
+func intradayETag(r *http.Request, format string, resp *entity.IntradayResponse) string {
+	q := r.URL.Query()
+	q.Del("access_token")
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%d|%s|%s|%s", resp.Symbol, resp.RSICount, resp.DataAsOf.UnixNano(), r.URL.Path, format, q.Encode())
+	// Not StalenessSec: it ticks every second and would defeat 304s
+	fmt.Fprintf(h, "|%s|%t", resp.MarketStatus, resp.Stale)
+	fmt.Fprintf(h, "|%d", len(resp.Candles))
+	if len(resp.Candles) > 0 {
+		fmt.Fprintf(h, "|%d", resp.Candles[0].Timestamp.UnixNano())
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// setCacheHeaders sets ETag and Cache-Control, and reports whether the
+// request's If-None-Match already matches etag, in which case it has
+// written 304 Not Modified and the caller must not write a body.
+func setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, maxAge time.Duration) bool {
+	w.Header().Set("ETag", etag)
+	if secs := int(maxAge / time.Second); secs > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", secs))
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag or "*".
+// Comparison is weak, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+)
+
+func TestIntradayETag(t *testing.T) {
+	asOf := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	base := entity.IntradayResponse{
+		Symbol:       "IBM",
+		RSICount:     40,
+		DataAsOf:     asOf,
+		MarketStatus: "open",
+		Candles:      []entity.Candle{{Timestamp: asOf}},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/market/intraday/IBM?tail=1", nil)
+	tag := intradayETag(req, "json", &base)
+	if !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("etag %s is not weak", tag)
+	}
+
+	tokenReq := httptest.NewRequest(http.MethodGet, "/v1/market/intraday/IBM?tail=1&access_token=x", nil)
+	if got := intradayETag(tokenReq, "json", &base); got != tag {
+		t.Errorf("access_token changed the etag")
+	}
+
+	changes := map[string]func(*entity.IntradayResponse){
+		"rsi count":     func(r *entity.IntradayResponse) { r.RSICount++ },
+		"data as of":    func(r *entity.IntradayResponse) { r.DataAsOf = asOf.Add(time.Minute) },
+		"market status": func(r *entity.IntradayResponse) { r.MarketStatus = "closed" },
+		"stale":         func(r *entity.IntradayResponse) { r.Stale = true },
+		"candles":       func(r *entity.IntradayResponse) { r.Candles = nil },
+	}
+	for name, change := range changes {
+		resp := base
+		change(&resp)
+		if intradayETag(req, "json", &resp) == tag {
+			t.Errorf("%s: etag unchanged", name)
+		}
+	}
+	// Staleness seconds tick every second; revalidation must still hit
+	aged := base
+	aged.StalenessSec = 120
+	if intradayETag(req, "json", &aged) != tag {
+		t.Error("staleness_sec changed the etag")
+	}
+	if intradayETag(req, "msgpack", &base) == tag {
+		t.Error("format: etag unchanged")
+	}
+}
+
+func TestSetCacheHeaders(t *testing.T) {
+	const tag = `W/"abc"`
+	cases := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"x", W/"abc"`, true},
+		{`*`, true},
+		{`W/"abd"`, false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", c.ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		if got := setCacheHeaders(rec, req, tag, 0); got != c.want {
+			t.Errorf("If-None-Match %q: not modified = %v, want %v", c.ifNoneMatch, got, c.want)
+		}
+		if c.want && rec.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %q: status %d, want 304", c.ifNoneMatch, rec.Code)
+		}
+		if rec.Header().Get("ETag") != tag || rec.Header().Get("Cache-Control") != "private, no-cache" {
+			t.Errorf("headers = %v", rec.Header())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// Response formats of the candle endpoints.
+const (
+	formatJSON    = "json"
+	formatCSV     = "csv"
+	formatNDJSON  = "ndjson"
+	formatMsgpack = "msgpack"
+)
+
+// candleFormats are the formats of endpoints returning one row per bar.
+var candleFormats = []string{formatJSON, formatCSV, formatNDJSON, formatMsgpack}
+
+// mediaFormats maps Accept media types to formats.
+var mediaFormats = map[string]string{
+	"application/json":        formatJSON,
+	"text/csv":                formatCSV,
+	"application/x-ndjson":    formatNDJSON,
+	"application/ndjson":      formatNDJSON,
+	"application/jsonl":       formatNDJSON,
+	"application/msgpack":     formatMsgpack,
+	"application/x-msgpack":   formatMsgpack,
+	"application/vnd.msgpack": formatMsgpack,
+}
+
+// barColumns is the CSV header. NDJSON lines are history bars as in the
+// JSON responses, plus the symbol.
+var barColumns = []string{"symbol", "ts", "open", "high", "low", "close", "volume", "rsi", "change_pct", "rsi_count", "warmup_status"}
+
+// negotiateFormat picks the response format among allowed from ?format=,
+// else from the first allowed Accept media type, else JSON. The response
+// varies with Accept either way.
+func negotiateFormat(w http.ResponseWriter, r *http.Request, allowed ...string) (string, error) {
+	w.Header().Add("Vary", "Accept")
+	if f := r.URL.Query().Get("format"); f != "" {
+		if slices.Contains(allowed, f) {
+			return f, nil
+		}
+		return "", entity.ErrValidation([]entity.FieldError{{Field: "format", Message: "must be one of: " + strings.Join(allowed, ", ")}})
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if f, ok := mediaFormats[strings.ToLower(strings.TrimSpace(mediaType))]; ok && slices.Contains(allowed, f) {
+			return f, nil
+		}
+	}
+	return formatJSON, nil
+}
+
+// respond writes v as JSON or, for formatMsgpack, as MessagePack with the
+// same field names.
+func respond(w http.ResponseWriter, r *http.Request, format string, v any) {
+	if format != formatMsgpack {
+		render.JSON(w, r, v)
+		return
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		renderError(w, r, entity.ErrInternal("encode response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/msgpack")
+	if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+		w.WriteHeader(status)
+	}
+	w.Write(buf.Bytes())
+}
+
+// intradayBars pairs the candles of resp with their indicators.
+func intradayBars(resp *entity.IntradayResponse) []entity.HistoryBar {
+	bars := make([]entity.HistoryBar, len(resp.Candles))
+	for i, c := range resp.Candles {
+		bars[i].Candle = c
+		if i < len(resp.Indicators) {
+			bars[i].BarIndicators = resp.Indicators[i]
+		}
+	}
+	return bars
+}
+
+// historyBars flattens /v2 bars for export.
+func historyBars(bars []entity.Bar) []entity.HistoryBar {
+	out := make([]entity.HistoryBar, len(bars))
+	for i, b := range bars {
+		out[i] = entity.HistoryBar{Candle: b.Candle, BarIndicators: b.Indicators}
+	}
+	return out
+}
+
+// exportBar is one NDJSON line.
+type exportBar struct {
+	Symbol string `json:"symbol"`
+	entity.HistoryBar
+}
+
+// writeBars writes bars as CSV or NDJSON, one row per bar. name is the
+// suggested download file name without extension.
+func writeBars(w http.ResponseWriter, format, name, symbol string, bars []entity.HistoryBar) {
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	case formatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, name, format))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is out, so a failed write can only be logged; the
+	// client sees a truncated body.
+	if format == formatNDJSON {
+		enc := json.NewEncoder(w)
+		for _, b := range bars {
+			if err := enc.Encode(exportBar{Symbol: symbol, HistoryBar: b}); err != nil {
+				log.Printf("export %s: write ndjson: %v", symbol, err)
+				return
+			}
+		}
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(barColumns); err != nil {
+		log.Printf("export %s: write csv: %v", symbol, err)
+		return
+	}
+	for _, b := range bars {
+		if err := cw.Write(barRecord(symbol, b)); err != nil {
+			log.Printf("export %s: write csv: %v", symbol, err)
+			return
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("export %s: write csv: %v", symbol, err)
+	}
+}
+
+// barRecord is one CSV row. Indicator cells are empty for bars the RSI
+// state did not cover yet.
+func barRecord(symbol string, b entity.HistoryBar) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	rec := []string{
+		symbol,
+		b.Timestamp.UTC().Format(time.RFC3339),
+		f(b.Open), f(b.High), f(b.Low), f(b.Close),
+		strconv.FormatInt(b.Volume, 10),
+		"", "",
+		strconv.Itoa(b.RSICount),
+		b.WarmupStatus,
+	}
+	if b.RSICount > 0 {
+		rec[7], rec[8] = f(b.RSI), f(b.ChangePct)
+	}
+	return rec
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// brokenWriter is a response whose connection drops after limit bytes.
+type brokenWriter struct {
+	*httptest.ResponseRecorder
+	limit int
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if w.Body.Len()+len(p) > w.limit {
+		return 0, errors.New("connection reset")
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func exportBars(n int) []entity.HistoryBar {
+	bars := make([]entity.HistoryBar, n)
+	for i := range bars {
+		bars[i].Timestamp = wsT0.Add(time.Duration(i) * time.Minute)
+		bars[i].Close = 100 + float64(i)
+	}
+	return bars
+}
+
+// captureLog redirects the standard logger for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return &buf
+}
+
+func TestWriteBarsLogsWriteErrors(t *testing.T) {
+	cases := []struct {
+		format string
+		bars   int
+	}{
+		{formatNDJSON, 10},
+		// Small enough to sit in the csv buffer until Flush
+		{formatCSV, 2},
+		// Large enough to fail inside Write
+		{formatCSV, 500},
+	}
+	for _, c := range cases {
+		logs := captureLog(t)
+		w := &brokenWriter{ResponseRecorder: httptest.NewRecorder()}
+		writeBars(w, c.format, "IBM", "IBM", exportBars(c.bars))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s/%d: status %d", c.format, c.bars, w.Code)
+		}
+		if !strings.Contains(logs.String(), "export IBM: write "+c.format+": connection reset") {
+			t.Errorf("%s/%d: log %q, want the write error", c.format, c.bars, logs)
+		}
+	}
+}
+
+func TestWriteBars(t *testing.T) {
+	logs := captureLog(t)
+	rec := httptest.NewRecorder()
+	bars := exportBars(2)
+	bars[1].RSICount, bars[1].RSI, bars[1].WarmupStatus = 1, 100, "processing"
+	writeBars(rec, formatCSV, "IBM-2024", "IBM", bars)
+
+	if got := rec.Header().Get("Content-Disposition"); got != `inline; filename="IBM-2024.csv"` {
+		t.Errorf("Content-Disposition %q", got)
+	}
+	want := "symbol,ts,open,high,low,close,volume,rsi,change_pct,rsi_count,warmup_status\n" +
+		"IBM,2024-03-06T14:30:00Z,0,0,0,100,0,,,0,\n" +
+		"IBM,2024-03-06T14:31:00Z,0,0,0,101,0,100,0,1,processing\n"
+	if rec.Body.String() != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", rec.Body, want)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("logged %q on a good write", logs)
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		query, accept string
+		allowed       []string
+		want          string
+		err           bool
+	}{
+		{"", "", candleFormats, formatJSON, false},
+		{"", "text/csv", candleFormats, formatCSV, false},
+		{"", "Application/X-NDJSON; charset=utf-8", candleFormats, formatNDJSON, false},
+		{"", "text/html, application/vnd.msgpack;q=0.9, text/csv", candleFormats, formatMsgpack, false},
+		// Types the endpoint can't serve are skipped, not failed
+		{"", "text/csv, application/msgpack", []string{formatJSON, formatMsgpack}, formatMsgpack, false},
+		{"", "text/csv", []string{formatJSON, formatMsgpack}, formatJSON, false},
+		{"", "image/png, */*", candleFormats, formatJSON, false},
+		// ?format= wins over Accept
+		{"format=ndjson", "application/msgpack", candleFormats, formatNDJSON, false},
+		{"format=csv", "", []string{formatJSON, formatMsgpack}, "", true},
+		{"format=xml", "", candleFormats, "", true},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/v1/market/history/IBM?"+c.query, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		got, err := negotiateFormat(rec, req, c.allowed...)
+		if c.err {
+			var ae entity.APIError
+			if !errors.As(err, &ae) || ae.Code != entity.CodeValidationFailed {
+				t.Errorf("%q/%q: err = %v, want a validation error", c.query, c.accept, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("%q/%q: got %q, %v; want %q", c.query, c.accept, got, err, c.want)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("%q/%q: Vary = %q", c.query, c.accept, rec.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestRespondMsgpack(t *testing.T) {
+	v := entity.BatchIntradayResponse{Results: []entity.BatchResult{{
+		Symbol: "IBM",
+		Status: http.StatusOK,
+		Data:   &entity.IntradayResponse{Symbol: "IBM", RSI: 42.5, RSICount: 20, Candles: []entity.Candle{{Timestamp: wsT0, Close: 101.25}}},
+	}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), render.StatusCtxKey, http.StatusAccepted))
+	rec := httptest.NewRecorder()
+	respond(rec, req, formatMsgpack, v)
+
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	// Decoded without struct tags, keys are what clients see
+	var raw map[string]any
+	if err := msgpack.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	result := raw["results"].([]any)[0].(map[string]any)
+	data := result["data"].(map[string]any)
+	if result["symbol"] != "IBM" || data["rsi_count"] == nil || data["rsi"] != 42.5 {
+		t.Errorf("msgpack keys %v / %v, want the JSON field names", result, data)
+	}
+	if _, ok := result["error"]; ok {
+		t.Error("omitempty field encoded")
+	}
+
+	// And it decodes back into the same value through the json tags
+	var back entity.BatchIntradayResponse
+	dec := msgpack.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(&back); err != nil {
+		t.Fatal(err)
+	}
+	got := back.Results[0].Data
+	if got.RSI != 42.5 || got.RSICount != 20 || len(got.Candles) != 1 || got.Candles[0].Close != 101.25 || !got.Candles[0].Timestamp.Equal(wsT0) {
+		t.Errorf("round trip = %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	respond(rec, httptest.NewRequest(http.MethodGet, "/", nil), formatJSON, v)
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") || !strings.Contains(rec.Body.String(), `"rsi_count":20`) {
+		t.Errorf("json: %q %s", rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
@@ -1,28 +1,52 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
 
 	"marketpulse/internal/domain/entity"
 	"marketpulse/internal/domain/service"
 )
 
 type MarketHandler struct {
-	intradaySvc *service.IntradayService
+	intradaySvc      *service.IntradayService
+	cache            *service.IntradayCache
+	batchMaxSymbols  int
+	batchParallelism int
 }
 
-func NewMarketHandler(svc *service.IntradayService) *MarketHandler {
-	return &MarketHandler{intradaySvc: svc}
+func NewMarketHandler(svc *service.IntradayService, cache *service.IntradayCache, batchMaxSymbols, batchParallelism int) *MarketHandler {
+	return &MarketHandler{
+		intradaySvc:      svc,
+		cache:            cache,
+		batchMaxSymbols:  batchMaxSymbols,
+		batchParallelism: batchParallelism,
+	}
 }
 
 func (h *MarketHandler) Intraday(w http.ResponseWriter, r *http.Request) {
+	h.intraday(w, r, 1)
+}
+
+// IntradayV2 handles GET /v2/market/intraday/{symbol}: bars oldest first
+// with nested indicators, tail keeping the newest bars.
+func (h *MarketHandler) IntradayV2(w http.ResponseWriter, r *http.Request) {
+	h.intraday(w, r, 2)
+}
+
+func (h *MarketHandler) intraday(w http.ResponseWriter, r *http.Request, version int) {
 	if h == nil || h.intradaySvc == nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, map[string]string{"error": "intraday service not wired"})
+		renderError(w, r, entity.ErrInternal("intraday service not wired"))
+		return
+	}
+
+	format, err := negotiateFormat(w, r, candleFormats...)
+	if err != nil {
+		renderError(w, r, err)
 		return
 	}
 
@@ -30,6 +54,143 @@ func (h *MarketHandler) Intraday(w http.ResponseWriter, r *http.Request) {
 		Symbol: chi.URLParam(r, "symbol"),
 	}
 
+	parseIntradayParams(r, &req)
+
+	resp, err := h.cache.GetIntraday(r.Context(), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	// Optional tail trimming at API layer; keeps the newest bars
+	if version < 2 && req.Tail != nil && *req.Tail > 0 && len(resp.Candles) > *req.Tail {
+		resp.Candles = resp.Candles[len(resp.Candles)-*req.Tail:]
+		resp.Indicators = resp.Indicators[len(resp.Indicators)-*req.Tail:]
+	}
+
+	if setCacheHeaders(w, r, intradayETag(r, format, resp), h.cache.TTL()) {
+		return
+	}
+
+	if version < 2 {
+		if format == formatCSV || format == formatNDJSON {
+			writeBars(w, format, resp.Symbol+"-intraday", resp.Symbol, intradayBars(resp))
+			return
+		}
+		respond(w, r, format, resp)
+		return
+	}
+
+	v2 := entity.IntradayV2(resp, req.Tail)
+	if format == formatCSV || format == formatNDJSON {
+		writeBars(w, format, v2.Symbol+"-intraday", v2.Symbol, historyBars(v2.Bars))
+		return
+	}
+	respond(w, r, format, v2)
+}
+
+// History handles GET /market/history/{symbol}: the full upstream history
+// with per-bar indicators, as JSON, CSV or NDJSON.
+func (h *MarketHandler) History(w http.ResponseWriter, r *http.Request) {
+	h.history(w, r, 1)
+}
+
+// HistoryV2 handles GET /v2/market/history/{symbol}, with indicators
+// nested in each bar.
+func (h *MarketHandler) HistoryV2(w http.ResponseWriter, r *http.Request) {
+	h.history(w, r, 2)
+}
+
+func (h *MarketHandler) history(w http.ResponseWriter, r *http.Request, version int) {
+	format, err := negotiateFormat(w, r, candleFormats...)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	params := entity.IntradayRequest{}
+	parseIntradayParams(r, &params)
+	resp, err := h.intradaySvc.GetHistory(r.Context(), chi.URLParam(r, "symbol"), params.Tail)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	if format == formatCSV || format == formatNDJSON {
+		writeBars(w, format, resp.Symbol+"-history", resp.Symbol, resp.Bars)
+		return
+	}
+	if version >= 2 {
+		respond(w, r, format, entity.HistoryV2(resp))
+		return
+	}
+	respond(w, r, format, resp)
+}
+
+// IntradayBatch handles GET /market/intraday?symbols=IBM,AAPL with the
+// same tail/rsi_low/rsi_high parameters applied to every symbol.
+func (h *MarketHandler) IntradayBatch(w http.ResponseWriter, r *http.Request) {
+	defaults := entity.IntradayRequest{}
+	parseIntradayParams(r, &defaults)
+
+	var reqs []entity.IntradayRequest
+	for _, sym := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+		if sym = strings.TrimSpace(sym); sym != "" {
+			req := defaults
+			req.Symbol = sym
+			reqs = append(reqs, req)
+		}
+	}
+	h.runBatch(w, r, reqs)
+}
+
+// IntradayBatchPost handles POST /market/intraday, where each symbol may
+// carry its own tail and thresholds.
+func (h *MarketHandler) IntradayBatchPost(w http.ResponseWriter, r *http.Request) {
+	body := entity.BatchIntradayRequest{}
+	if err := decodeJSON(r, &body); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	reqs := make([]entity.IntradayRequest, 0, len(body.Symbols))
+	for _, req := range body.Symbols {
+		if req.Tail == nil {
+			req.Tail = body.Tail
+		}
+		if req.RSILow == nil {
+			req.RSILow = body.RSILow
+		}
+		if req.RSIHigh == nil {
+			req.RSIHigh = body.RSIHigh
+		}
+		reqs = append(reqs, req)
+	}
+	h.runBatch(w, r, reqs)
+}
+
+func (h *MarketHandler) runBatch(w http.ResponseWriter, r *http.Request, reqs []entity.IntradayRequest) {
+	format, err := negotiateFormat(w, r, formatJSON, formatMsgpack)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if len(reqs) == 0 {
+		renderError(w, r, entity.ErrBadRequest("symbols required"))
+		return
+	}
+	if len(reqs) > h.batchMaxSymbols {
+		renderError(w, r, entity.ErrLimitExceeded(fmt.Sprintf("at most %d symbols per batch", h.batchMaxSymbols)))
+		return
+	}
+
+	results := h.intradaySvc.GetIntradayBatch(r.Context(), reqs, h.batchParallelism)
+	respond(w, r, format, entity.BatchIntradayResponse{Results: results})
+}
+
+// parseIntradayParams reads tail, rsi_low and rsi_high from the query
+// string into req; malformed values are ignored.
+func parseIntradayParams(r *http.Request, req *entity.IntradayRequest) {
 	if tailStr := r.URL.Query().Get("tail"); tailStr != "" {
 		if tail, err := strconv.Atoi(tailStr); err == nil {
 			req.Tail = &tail
@@ -47,22 +208,4 @@ func (h *MarketHandler) Intraday(w http.ResponseWriter, r *http.Request) {
 			req.RSIHigh = &high
 		}
 	}
-    
-	resp, err := h.intradaySvc.GetIntraday(r.Context(), req)
-	if err != nil {
-		status := http.StatusBadGateway
-		if he, ok := err.(entity.HTTPError); ok {
-			status = he.StatusCode
-		}
-		render.Status(r, status)
-		render.JSON(w, r, map[string]string{"error": err.Error()})
-		return
-	}
-
-	// Optional tail trimming at API layer
-	if req.Tail != nil && *req.Tail > 0 && len(resp.Candles) > *req.Tail {
-		resp.Candles = resp.Candles[:*req.Tail]
-	}
-
-	render.JSON(w, r, resp)
 }
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+)
+
+// gaugeFeed records the most fetches it has seen in flight at once.
+type gaugeFeed struct {
+	service.CandleFeed
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *gaugeFeed) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]feed.Candle, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(f.delay)
+	return f.CandleFeed.FetchIntraday(ctx, symbol, since)
+}
+
+// newTestMarket serves symbols matching ^[A-Z]+$, denies EVIL and fails
+// upstream for BOOM.
+func newTestMarket(t *testing.T, fd service.CandleFeed, maxSymbols, parallelism int) *MarketHandler {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy(`^[A-Z]+$`, nil, []string{"EVIL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd == nil {
+		fd = brokenFeed{&stubFeed{n: 30}, "BOOM"}
+	}
+	svc := service.NewIntradayService(newTestStore(t), fd, policy, nil)
+	return NewMarketHandler(svc, service.NewIntradayCache(svc, 0), maxSymbols, parallelism)
+}
+
+func batchResults(t *testing.T, rec *httptest.ResponseRecorder) []entity.BatchResult {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp entity.BatchIntradayResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Results
+}
+
+func TestIntradayBatchPerSymbolErrors(t *testing.T) {
+	h := newTestMarket(t, nil, 10, 4)
+	rec := httptest.NewRecorder()
+	h.IntradayBatch(rec, httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols=ibm,+bad!,EVIL,BOOM,&tail=5", nil))
+	results := batchResults(t, rec)
+
+	want := []struct {
+		symbol string
+		status int
+		code   string
+	}{
+		{"IBM", http.StatusOK, ""},
+		{"bad!", http.StatusBadRequest, entity.CodeInvalidSymbol},
+		{"EVIL", http.StatusForbidden, entity.CodeSymbolNotAllowed},
+		{"BOOM", http.StatusBadGateway, entity.CodeUpstreamError},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d in request order", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Symbol != w.symbol || got.Status != w.status {
+			t.Errorf("result %d: %s %d, want %s %d", i, got.Symbol, got.Status, w.symbol, w.status)
+		}
+		if w.code == "" {
+			if got.Error != nil || got.Data == nil || len(got.Data.Candles) != 5 {
+				t.Errorf("%s: want data with 5 candles, got %+v", w.symbol, got)
+			}
+			continue
+		}
+		if got.Data != nil || got.Error == nil || got.Error.Code != w.code || got.Error.Message == "" {
+			t.Errorf("%s: want a %s error envelope, got %+v", w.symbol, w.code, got)
+		}
+	}
+}
+
+func TestIntradayBatchDuplicates(t *testing.T) {
+	h := newTestMarket(t, nil, 10, 4)
+	rec := httptest.NewRecorder()
+	h.IntradayBatch(rec, httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols=IBM,ibm,IBM", nil))
+	results := batchResults(t, rec)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want one per requested entry", len(results))
+	}
+	for i, r := range results {
+		if r.Symbol != "IBM" || r.Status != http.StatusOK || r.Data == nil {
+			t.Errorf("result %d: %+v", i, r)
+		}
+	}
+	if results[0].Data.RSI != results[2].Data.RSI {
+		t.Errorf("duplicates disagree: rsi %v vs %v", results[0].Data.RSI, results[2].Data.RSI)
+	}
+}
+
+func TestIntradayBatchPostThresholds(t *testing.T) {
+	h := newTestMarket(t, nil, 10, 4)
+	body := `{"tail": 2, "rsi_low": 0, "rsi_high": 1, "symbols": [{"symbol": "IBM"}, {"symbol": "AAPL", "tail": 4, "rsi_high": 100}]}`
+	rec := httptest.NewRecorder()
+	h.IntradayBatchPost(rec, httptest.NewRequest(http.MethodPost, "/v1/market/intraday", strings.NewReader(body)))
+	results := batchResults(t, rec)
+	if len(results) != 2 {
+		t.Fatalf("got %d results", len(results))
+	}
+	ibm, aapl := results[0].Data, results[1].Data
+	if ibm == nil || aapl == nil {
+		t.Fatalf("results %+v", results)
+	}
+	if len(ibm.Candles) != 2 || ibm.Alert != "OVERBOUGHT" {
+		t.Errorf("IBM took %d candles, alert %q; want the batch tail and rsi_high", len(ibm.Candles), ibm.Alert)
+	}
+	if len(aapl.Candles) != 4 || aapl.Alert != "" {
+		t.Errorf("AAPL took %d candles, alert %q; want its own tail and rsi_high", len(aapl.Candles), aapl.Alert)
+	}
+}
+
+func TestIntradayBatchLimits(t *testing.T) {
+	h := newTestMarket(t, nil, 3, 4)
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status int
+		code   string
+	}{
+		{"too many", httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols=A,B,C,D", nil), http.StatusBadRequest, entity.CodeLimitExceeded},
+		{"none", httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols=,+,", nil), http.StatusBadRequest, entity.CodeBadRequest},
+		{"post too many", httptest.NewRequest(http.MethodPost, "/v1/market/intraday",
+			strings.NewReader(`{"symbols":[{"symbol":"A"},{"symbol":"B"},{"symbol":"C"},{"symbol":"D"}]}`)), http.StatusBadRequest, entity.CodeLimitExceeded},
+		{"post empty", httptest.NewRequest(http.MethodPost, "/v1/market/intraday", strings.NewReader(`{"symbols":[]}`)), http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		if c.req.Method == http.MethodPost {
+			h.IntradayBatchPost(rec, c.req)
+		} else {
+			h.IntradayBatch(rec, c.req)
+		}
+		if rec.Code != c.status {
+			t.Errorf("%s: status %d, want %d: %s", c.name, rec.Code, c.status, rec.Body)
+			continue
+		}
+		if e := errorBody(t, rec); e.Code == "" || (c.code != "" && e.Code != c.code) {
+			t.Errorf("%s: code %q, want %q", c.name, e.Code, c.code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.IntradayBatch(rec, httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols=A,B,C", nil))
+	if n := len(batchResults(t, rec)); n != 3 {
+		t.Errorf("batch at the limit returned %d results", n)
+	}
+}
+
+func TestIntradayBatchParallelism(t *testing.T) {
+	fd := &gaugeFeed{CandleFeed: &stubFeed{n: 30}, delay: 20 * time.Millisecond}
+	h := newTestMarket(t, fd, 20, 3)
+
+	syms := make([]string, 9)
+	for i := range syms {
+		syms[i] = fmt.Sprintf("S%c", 'A'+i)
+	}
+	rec := httptest.NewRecorder()
+	h.IntradayBatch(rec, httptest.NewRequest(http.MethodGet, "/v1/market/intraday?symbols="+strings.Join(syms, ","), nil))
+	for _, r := range batchResults(t, rec) {
+		if r.Status != http.StatusOK {
+			t.Errorf("%s: %+v", r.Symbol, r.Error)
+		}
+	}
+	if fd.peak != 3 {
+		t.Errorf("peak of %d fetches in flight, want the batch parallelism of 3", fd.peak)
+	}
+}
+
+// getIntraday serves path through the v1 and v2 single-symbol routes.
+func getIntraday(t *testing.T, h *MarketHandler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := chi.NewRouter()
+	r.Get("/v1/market/intraday/{symbol}", h.Intraday)
+	r.Get("/v2/market/intraday/{symbol}", h.IntradayV2)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", path, rec.Code, rec.Body)
+	}
+	return rec
+}
+
+func TestIntradayVersionShapes(t *testing.T) {
+	h := newTestMarket(t, nil, 10, 4)
+
+	var v1 map[string]json.RawMessage
+	json.Unmarshal(getIntraday(t, h, "/v1/market/intraday/IBM").Body.Bytes(), &v1)
+	for _, key := range []string{"candles", "rsi", "change_pct", "rsi_count", "warmup_status", "is_valid_rsi", "market_status"} {
+		if _, ok := v1[key]; !ok {
+			t.Errorf("v1 lacks %q", key)
+		}
+	}
+	for _, key := range []string{"bars", "indicators"} {
+		if _, ok := v1[key]; ok {
+			t.Errorf("v1 has v2 field %q", key)
+		}
+	}
+
+	var v2 entity.IntradayResponseV2
+	var v2raw map[string]json.RawMessage
+	body := getIntraday(t, h, "/v2/market/intraday/IBM").Body.Bytes()
+	json.Unmarshal(body, &v2)
+	json.Unmarshal(body, &v2raw)
+	for _, key := range []string{"candles", "rsi", "rsi_count"} {
+		if _, ok := v2raw[key]; ok {
+			t.Errorf("v2 has v1 field %q", key)
+		}
+	}
+	if len(v2.Bars) == 0 || v2.Indicators.RSICount == 0 || !v2.Indicators.IsValidRSI || v2.MarketStatus == "" {
+		t.Fatalf("v2 = %s", body)
+	}
+	for i, b := range v2.Bars {
+		if i > 0 && !b.Timestamp.After(v2.Bars[i-1].Timestamp) {
+			t.Errorf("bar %d at %v is not after %v", i, b.Timestamp, v2.Bars[i-1].Timestamp)
+		}
+		if b.Indicators.WarmupStatus == "" {
+			t.Errorf("bar %d has no nested indicators", i)
+		}
+	}
+	if last := v2.Bars[len(v2.Bars)-1]; last.Indicators.RSI != v2.Indicators.RSI || !last.Timestamp.Equal(barAt(29)) {
+		t.Errorf("newest bar %+v, want the symbol's current state at %v", last, barAt(29))
+	}
+}
+
+// v1 used to keep the oldest tail bars of a fetch; it now keeps the
+// newest, on seed and on incremental fetches alike.
+func TestIntradayV1TailKeepsNewest(t *testing.T) {
+	fd := &stubFeed{n: 30}
+	h := newTestMarket(t, fd, 10, 4)
+
+	var resp entity.IntradayResponse
+	json.Unmarshal(getIntraday(t, h, "/v1/market/intraday/IBM?tail=3").Body.Bytes(), &resp)
+	if len(resp.Candles) != 3 || !resp.Candles[0].Timestamp.Equal(barAt(27)) || !resp.Candles[2].Timestamp.Equal(barAt(29)) {
+		t.Errorf("seed: candles %+v, want bars 27 to 29", resp.Candles)
+	}
+
+	fd.mu.Lock()
+	fd.n = 35
+	fd.mu.Unlock()
+	resp = entity.IntradayResponse{}
+	json.Unmarshal(getIntraday(t, h, "/v1/market/intraday/IBM?tail=2").Body.Bytes(), &resp)
+	if len(resp.Candles) != 2 || !resp.Candles[0].Timestamp.Equal(barAt(33)) || !resp.Candles[1].Timestamp.Equal(barAt(34)) {
+		t.Errorf("incremental: candles %+v, want bars 33 and 34 of the 5 fetched", resp.Candles)
+	}
+}
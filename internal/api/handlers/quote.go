@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/service"
+)
+
+type QuoteHandler struct {
+	quoteSvc *service.QuoteService
+}
+
+func NewQuoteHandler(svc *service.QuoteService) *QuoteHandler {
+	return &QuoteHandler{quoteSvc: svc}
+}
+
+// Get handles GET /market/quote/{symbol}. Clients may reuse the quote for
+// as long as the server caches it.
+func (h *QuoteHandler) Get(w http.ResponseWriter, r *http.Request) {
+	q, err := h.quoteSvc.GetQuote(r.Context(), chi.URLParam(r, "symbol"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if secs := int(h.quoteSvc.TTL() / time.Second); secs > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", secs))
+	}
+	render.JSON(w, r, q)
+}
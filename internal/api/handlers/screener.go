@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type ScreenerHandler struct {
+	screenerSvc *service.ScreenerService
+}
+
+func NewScreenerHandler(svc *service.ScreenerService) *ScreenerHandler {
+	return &ScreenerHandler{screenerSvc: svc}
+}
+
+// Screen handles GET /market/screener?filter=rsi<30&filter=warmup_status=stable&sort=-change_pct.
+// filter may repeat or hold a comma-separated list; limit, rsi_low and
+// rsi_high are optional.
+func (h *ScreenerHandler) Screen(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := entity.ScreenerRequest{Sort: q.Get("sort")}
+	for _, v := range q["filter"] {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				req.Filters = append(req.Filters, f)
+			}
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			renderError(w, r, entity.ErrBadRequest("limit must be a non-negative integer"))
+			return
+		}
+		req.Limit = limit
+	}
+	if v := q.Get("rsi_low"); v != "" {
+		if low, err := strconv.ParseFloat(v, 64); err == nil {
+			req.RSILow = &low
+		}
+	}
+	if v := q.Get("rsi_high"); v != "" {
+		if high, err := strconv.ParseFloat(v, 64); err == nil {
+			req.RSIHigh = &high
+		}
+	}
+
+	resp, err := h.screenerSvc.Screen(r.Context(), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, resp)
+}
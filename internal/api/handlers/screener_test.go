@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/pkg/rsi"
+)
+
+func TestScreenerQuery(t *testing.T) {
+	store := newTestStore(t)
+	for sym, st := range map[string]rsi.CompactRSI{
+		"AAA": {Count: 60, RSI: 25, AvgLoss: 1, ChangePct: -2},
+		"BBB": {Count: 60, RSI: 28, AvgLoss: 1, ChangePct: 4},
+		"CCC": {Count: 20, RSI: 29, AvgLoss: 1, ChangePct: 1},
+		"DDD": {Count: 60, RSI: 75, AvgLoss: 1, ChangePct: 3},
+	} {
+		if err := store.Save(context.Background(), sym, &st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewScreenerHandler(service.NewScreenerService(store))
+
+	cases := []struct {
+		query   string
+		status  int
+		want    string
+		matched int
+	}{
+		{"filter=rsi<30&filter=warmup_status=stable&sort=-change_pct", http.StatusOK, "BBB,AAA", 2},
+		{"filter=rsi<30,+warmup_status=stable&sort=-change_pct", http.StatusOK, "BBB,AAA", 2},
+		{"filter=rsi<30&sort=rsi&limit=2", http.StatusOK, "AAA,BBB", 3},
+		{"filter=alert=OVERSOLD&rsi_low=26", http.StatusOK, "AAA", 1},
+		{"limit=-1", http.StatusBadRequest, "", 0},
+		{"limit=ten", http.StatusBadRequest, "", 0},
+		{"filter=rsi<<30", http.StatusBadRequest, "", 0},
+		{"sort=volume", http.StatusBadRequest, "", 0},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.Screen(rec, httptest.NewRequest(http.MethodGet, "/v1/market/screener?"+c.query, nil))
+		if rec.Code != c.status {
+			t.Errorf("%s: status %d, want %d: %s", c.query, rec.Code, c.status, rec.Body)
+			continue
+		}
+		if c.status != http.StatusOK {
+			if e := errorBody(t, rec); e.Code != entity.CodeBadRequest {
+				t.Errorf("%s: code %s, want %s", c.query, e.Code, entity.CodeBadRequest)
+			}
+			continue
+		}
+		var resp entity.ScreenerResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for i, row := range resp.Rows {
+			if i > 0 {
+				got += ","
+			}
+			got += row.Symbol
+		}
+		if got != c.want || resp.Matched != c.matched || resp.Scanned != 4 {
+			t.Errorf("%s: rows %q matched %d scanned %d, want %q matched %d", c.query, got, resp.Matched, resp.Scanned, c.want, c.matched)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type StateHandler struct {
+	stateSvc *service.StateService
+}
+
+func NewStateHandler(svc *service.StateService) *StateHandler {
+	return &StateHandler{stateSvc: svc}
+}
+
+// Export streams all RSI states as JSON (default) or NDJSON (?format=ndjson).
+func (h *StateHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.FormatJSON
+	}
+
+	switch format {
+	case service.FormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+	case service.FormatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		renderError(w, r, entity.ErrBadRequest("format must be json or ndjson"))
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="marketpulse-state.%s"`, format))
+
+	cw := &countingWriter{w: w}
+	if _, err := h.stateSvc.Export(r.Context(), cw, format); err != nil {
+		if cw.n == 0 {
+			renderError(w, r, err)
+			return
+		}
+		// Part of the dump is out with a 200; cut the connection so the
+		// client can't mistake it for a complete export.
+		log.Printf("state export aborted after %d bytes: %v", cw.n, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Import loads a JSON array or NDJSON body produced by Export. Errors
+// report how many entries were saved before them.
+func (h *StateHandler) Import(w http.ResponseWriter, r *http.Request) {
+	n, err := h.stateSvc.Import(r.Context(), r.Body)
+	if err != nil {
+		renderError(w, r, entity.AsAPIError(err).WithDetails(map[string]int{"imported": n}))
+		return
+	}
+	render.JSON(w, r, map[string]int{"imported": n})
+}
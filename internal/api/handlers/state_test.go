@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/filestore"
+	"marketpulse/pkg/rsi"
+)
+
+// newTestStore opens a file-backed store in a temp dir; it serves as both
+// the state repository and the hash store.
+func newTestStore(t *testing.T) *filestore.Store {
+	t.Helper()
+	store, err := filestore.NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// errorBody decodes the {"error": {...}} envelope.
+func errorBody(t *testing.T, rec *httptest.ResponseRecorder) entity.APIError {
+	t.Helper()
+	var body struct {
+		Error entity.APIError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body)
+	}
+	return body.Error
+}
+
+// newTestStateHandler accepts symbols matching ^[A-Z]+$ except EVIL.
+func newTestStateHandler(t *testing.T, repo service.StateRepository) *StateHandler {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy(`^[A-Z]+$`, nil, []string{"EVIL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStateHandler(service.NewStateService(repo, policy))
+}
+
+func TestStateImportErrors(t *testing.T) {
+	h := newTestStateHandler(t, newTestStore(t))
+	cases := []struct {
+		name     string
+		body     string
+		status   int
+		code     string
+		imported float64
+	}{
+		{"bad array", `[{"symbol": 1}]`, http.StatusBadRequest, entity.CodeInvalidJSON, 0},
+		{"bad line", `{"symbol":"IBM","state":{"rsi_count":20}}` + "\n{nope\n", http.StatusBadRequest, entity.CodeInvalidJSON, 0},
+		{"missing state", `[{"symbol":"IBM"}]`, http.StatusBadRequest, entity.CodeBadRequest, 0},
+		{"bad symbol", `[{"symbol":"IBM!","state":{"rsi_count":20}}]`, http.StatusBadRequest, entity.CodeInvalidSymbol, 0},
+		{"denied symbol", `[{"symbol":"evil","state":{"rsi_count":20}}]`, http.StatusForbidden, entity.CodeSymbolNotAllowed, 0},
+		{"negative count", `[{"symbol":"IBM","state":{"rsi_count":-1}}]`, http.StatusBadRequest, entity.CodeBadRequest, 0},
+		{"out of range", `[{"symbol":"IBM","state":{"rsi":1e999}}]`, http.StatusBadRequest, entity.CodeInvalidJSON, 0},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.Import(rec, httptest.NewRequest(http.MethodPost, "/v1/admin/state/import", strings.NewReader(c.body)))
+		if rec.Code != c.status {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.status)
+			continue
+		}
+		e := errorBody(t, rec)
+		if e.Code != c.code {
+			t.Errorf("%s: code %s, want %s", c.name, e.Code, c.code)
+		}
+		// Decoder internals stay on the server
+		if strings.Contains(e.Message, "invalid character") || strings.Contains(e.Message, "Go struct") {
+			t.Errorf("%s: message leaks decoder error: %q", c.name, e.Message)
+		}
+		if d, _ := e.Details.(map[string]any); d["imported"] != c.imported {
+			t.Errorf("%s: details %v, want imported %v", c.name, e.Details, c.imported)
+		}
+	}
+}
+
+func TestStateImportNormalizes(t *testing.T) {
+	store := newTestStore(t)
+	h := newTestStateHandler(t, store)
+	rec := httptest.NewRecorder()
+	h.Import(rec, httptest.NewRequest(http.MethodPost, "/v1/admin/state/import",
+		strings.NewReader(`[{"symbol":" ibm ","state":{"rsi_count":20,"rsi":42}}]`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	all, err := store.All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all["IBM"] == nil || all["IBM"].RSI != 42 {
+		t.Errorf("stored %v, want the state under IBM", all)
+	}
+}
+
+// failingStates fails to list states.
+type failingStates struct{ service.StateRepository }
+
+func (failingStates) All(context.Context) (map[string]*rsi.CompactRSI, error) {
+	return nil, errors.New("redis down")
+}
+
+func TestStateExportErrors(t *testing.T) {
+	captureLog(t)
+
+	// Nothing written yet: a normal error response
+	rec := httptest.NewRecorder()
+	newTestStateHandler(t, failingStates{}).Export(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/state/export", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+
+	// Mid-stream: abort the connection instead of appending an error body
+	store := newTestStore(t)
+	for _, sym := range []string{"AAPL", "IBM", "MSFT"} {
+		store.Save(context.Background(), sym, &rsi.CompactRSI{Count: 20})
+	}
+	w := &brokenWriter{ResponseRecorder: httptest.NewRecorder(), limit: 250}
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), `{"symbol":"AAPL"`) || strings.Contains(w.Body.String(), "error") {
+			t.Errorf("status %d body %q, want the partial dump only", w.Code, w.Body)
+		}
+	}()
+	newTestStateHandler(t, store).Export(w, httptest.NewRequest(http.MethodGet, "/v1/admin/state/export?format=ndjson", nil))
+}
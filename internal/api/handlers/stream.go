@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/pkg/rsi"
+)
+
+// sseRetry is the reconnect delay suggested to EventSource clients.
+const sseRetry = 3 * time.Second
+
+// SymbolWatcher keeps symbols polled while someone is streaming them.
+type SymbolWatcher interface {
+	Watch(symbols []string) (release func())
+}
+
+type StreamHandler struct {
+	hub         *service.StreamHub
+	intradaySvc *service.IntradayService
+	watcher     SymbolWatcher
+	maxSymbols  int
+	heartbeat   time.Duration
+}
+
+func NewStreamHandler(hub *service.StreamHub, svc *service.IntradayService, watcher SymbolWatcher, maxSymbols int, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		hub:         hub,
+		intradaySvc: svc,
+		watcher:     watcher,
+		maxSymbols:  maxSymbols,
+		heartbeat:   heartbeat,
+	}
+}
+
+// Symbol handles GET /market/stream/{symbol}.
+func (h *StreamHandler) Symbol(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, []string{chi.URLParam(r, "symbol")})
+}
+
+// Symbols handles GET /market/stream?symbols=IBM,AAPL.
+func (h *StreamHandler) Symbols(w http.ResponseWriter, r *http.Request) {
+	var symbols []string
+	for _, sym := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+		if sym = strings.TrimSpace(sym); sym != "" {
+			symbols = append(symbols, sym)
+		}
+	}
+	if len(symbols) == 0 {
+		renderError(w, r, entity.ErrBadRequest("symbols required"))
+		return
+	}
+	if len(symbols) > h.maxSymbols {
+		renderError(w, r, entity.ErrLimitExceeded(fmt.Sprintf("at most %d symbols per stream", h.maxSymbols)))
+		return
+	}
+	h.serve(w, r, symbols)
+}
+
+// serve streams Server-Sent Events until the client goes away. A fresh
+// connection first gets one "snapshot" event per symbol; a reconnect with
+// Last-Event-ID gets the missed "update" events instead, or snapshots if
+// they have left the backlog. Each update carries the new candles and the
+// RSI, change and alert after them.
+func (h *StreamHandler) serve(w http.ResponseWriter, r *http.Request, symbols []string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderError(w, r, entity.ErrInternal("streaming unsupported"))
+		return
+	}
+
+	params := entity.IntradayRequest{}
+	parseIntradayParams(r, &params)
+	low, high := 30.0, 70.0
+	if params.RSILow != nil {
+		low = *params.RSILow
+	}
+	if params.RSIHigh != nil {
+		high = *params.RSIHigh
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	resumeFrom, _ := strconv.ParseUint(lastID, 10, 64)
+
+	// Subscribe before snapshotting so no update falls in between.
+	sub, err := h.hub.Subscribe(symbols, resumeFrom)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+	if h.watcher != nil {
+		defer h.watcher.Watch(sub.Symbols)()
+	}
+
+	var snapshots []*entity.IntradayResponse
+	if !sub.Resumed {
+		one := 1
+		for _, sym := range sub.Symbols {
+			req := params
+			req.Symbol, req.Tail = sym, &one
+			resp, err := h.intradaySvc.GetIntraday(r.Context(), req)
+			if err != nil {
+				renderError(w, r, err)
+				return
+			}
+			snapshots = append(snapshots, resp)
+		}
+	}
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "text/event-stream")
+	hdr.Set("Cache-Control", "no-cache")
+	hdr.Set("Connection", "keep-alive")
+	hdr.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+
+	for _, snap := range snapshots {
+		if err := writeEvent(w, sub.Seq, "snapshot", snap); err != nil {
+			return
+		}
+	}
+	for _, u := range sub.Missed {
+		u.Alert = rsi.CheckAlert(u.RSI, low, high)
+		if err := writeEvent(w, u.ID, "update", u); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case u, ok := <-sub.C:
+			if !ok {
+				// Too slow or shutting down; the client reconnects
+				// with Last-Event-ID and resumes from the backlog.
+				return
+			}
+			u.Alert = rsi.CheckAlert(u.RSI, low, high)
+			if err := writeEvent(w, u.ID, "update", u); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one SSE event; id 0 is omitted.
+func writeEvent(w http.ResponseWriter, id uint64, event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+// maxSearchQuery bounds search input; longer text matches nothing useful.
+const maxSearchQuery = 64
+
+type SymbolsHandler struct {
+	directorySvc *service.DirectoryService
+}
+
+func NewSymbolsHandler(svc *service.DirectoryService) *SymbolsHandler {
+	return &SymbolsHandler{directorySvc: svc}
+}
+
+// Search handles GET /market/symbols/search?q=intern.
+func (h *SymbolsHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	switch {
+	case q == "":
+		renderError(w, r, entity.ErrValidation([]entity.FieldError{{Field: "q", Message: "is required"}}))
+		return
+	case utf8.RuneCountInString(q) > maxSearchQuery:
+		renderError(w, r, entity.ErrValidation([]entity.FieldError{{Field: "q", Message: "must be at most 64 characters"}}))
+		return
+	}
+
+	resp, err := h.directorySvc.Search(r.Context(), q)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, resp)
+}
+
+// Get handles GET /market/symbols/{symbol}.
+func (h *SymbolsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	info, err := h.directorySvc.Lookup(r.Context(), chi.URLParam(r, "symbol"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, info)
+}
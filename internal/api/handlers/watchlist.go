@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type WatchlistHandler struct {
+	watchlistSvc *service.WatchlistService
+}
+
+func NewWatchlistHandler(svc *service.WatchlistService) *WatchlistHandler {
+	return &WatchlistHandler{watchlistSvc: svc}
+}
+
+func (h *WatchlistHandler) List(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.watchlistSvc.List(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, lists)
+}
+
+func (h *WatchlistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	req := entity.WatchlistRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wl, err := h.watchlistSvc.Create(r.Context(), middleware.UserIDFromContext(r.Context()), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, wl)
+}
+
+func (h *WatchlistHandler) Get(w http.ResponseWriter, r *http.Request) {
+	wl, err := h.watchlistSvc.Get(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wl)
+}
+
+func (h *WatchlistHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	req := entity.WatchlistRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wl, err := h.watchlistSvc.Rename(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wl)
+}
+
+func (h *WatchlistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.watchlistSvc.Delete(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+		renderError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WatchlistHandler) AddSymbol(w http.ResponseWriter, r *http.Request) {
+	req := entity.WatchlistSymbolRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wl, err := h.watchlistSvc.AddSymbol(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), req.Symbol)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wl)
+}
+
+func (h *WatchlistHandler) RemoveSymbol(w http.ResponseWriter, r *http.Request) {
+	wl, err := h.watchlistSvc.RemoveSymbol(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), chi.URLParam(r, "symbol"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wl)
+}
+
+func (h *WatchlistHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	req := entity.WatchlistOrderRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wl, err := h.watchlistSvc.Reorder(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), req.Symbols)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wl)
+}
+
+// Snapshot accepts the same rsi_low/rsi_high parameters as Intraday.
+func (h *WatchlistHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	low, high := 30.0, 70.0
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("rsi_low"), 64); err == nil {
+		low = v
+	}
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("rsi_high"), 64); err == nil {
+		high = v
+	}
+
+	snap, err := h.watchlistSvc.Snapshot(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), low, high)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, snap)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+)
+
+const testSecret = "test-secret"
+
+// brokenFeed fails every fetch of one symbol.
+type brokenFeed struct {
+	*stubFeed
+	symbol string
+}
+
+func (f brokenFeed) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]feed.Candle, error) {
+	if symbol == f.symbol {
+		return nil, errors.New("upstream exploded")
+	}
+	return f.stubFeed.FetchIntraday(ctx, symbol, since)
+}
+
+// newTestWatchlistRouter mounts the watchlist routes behind Auth, as the
+// API router does.
+func newTestWatchlistRouter(t *testing.T) http.Handler {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newTestStore(t)
+	intraday := service.NewIntradayService(store, brokenFeed{&stubFeed{n: 30}, "BAD"}, policy, nil)
+	h := NewWatchlistHandler(service.NewWatchlistService(store, store, intraday, policy))
+
+	r := chi.NewRouter()
+	r.Use(middleware.Auth([]byte(testSecret)))
+	r.Route("/v1/watchlists", func(r chi.Router) {
+		r.Get("/", h.List)
+		r.Post("/", h.Create)
+		r.Get("/{id}", h.Get)
+		r.Patch("/{id}", h.Rename)
+		r.Delete("/{id}", h.Delete)
+		r.Post("/{id}/symbols", h.AddSymbol)
+		r.Put("/{id}/symbols", h.Reorder)
+		r.Delete("/{id}/symbols/{symbol}", h.RemoveSymbol)
+		r.Get("/{id}/snapshot", h.Snapshot)
+	})
+	return r
+}
+
+// call sends an authenticated request as user and decodes a successful
+// response into out.
+func call(t *testing.T, h http.Handler, user, method, path, body string, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	token, _ := middleware.GenerateJWT(testSecret, user, "user", time.Hour)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: %v: %s", method, path, err, rec.Body)
+		}
+	}
+	return rec
+}
+
+func TestWatchlistRoutes(t *testing.T) {
+	h := newTestWatchlistRouter(t)
+
+	var wl entity.Watchlist
+	if rec := call(t, h, "u1", http.MethodPost, "/v1/watchlists", `{"name":"tech","symbols":["ibm","aapl"]}`, &wl); rec.Code != http.StatusCreated {
+		t.Fatalf("create status %d: %s", rec.Code, rec.Body)
+	}
+	base := "/v1/watchlists/" + wl.ID
+
+	steps := []struct {
+		method, path, body string
+		status             int
+		symbols            string
+	}{
+		{http.MethodPatch, base, `{"name":"megacaps"}`, http.StatusOK, "IBM,AAPL"},
+		{http.MethodPost, base + "/symbols", `{"symbol":"msft"}`, http.StatusOK, "IBM,AAPL,MSFT"},
+		{http.MethodPut, base + "/symbols", `{"symbols":["MSFT","IBM","AAPL"]}`, http.StatusOK, "MSFT,IBM,AAPL"},
+		{http.MethodPut, base + "/symbols", `{"symbols":["MSFT"]}`, http.StatusBadRequest, ""},
+		{http.MethodDelete, base + "/symbols/aapl", "", http.StatusOK, "MSFT,IBM"},
+		{http.MethodDelete, base + "/symbols/AAPL", "", http.StatusNotFound, ""},
+		{http.MethodGet, base, "", http.StatusOK, "MSFT,IBM"},
+	}
+	for _, s := range steps {
+		var got entity.Watchlist
+		rec := call(t, h, "u1", s.method, s.path, s.body, &got)
+		if rec.Code != s.status {
+			t.Errorf("%s %s: status %d, want %d: %s", s.method, s.path, rec.Code, s.status, rec.Body)
+			continue
+		}
+		if s.status != http.StatusOK {
+			if e := errorBody(t, rec); e.Code == "" || e.Message == "" {
+				t.Errorf("%s %s: error envelope %+v", s.method, s.path, e)
+			}
+			continue
+		}
+		if strings.Join(got.Symbols, ",") != s.symbols {
+			t.Errorf("%s %s: symbols %v, want %s", s.method, s.path, got.Symbols, s.symbols)
+		}
+	}
+
+	var lists []entity.Watchlist
+	if call(t, h, "u1", http.MethodGet, "/v1/watchlists", "", &lists); len(lists) != 1 || lists[0].Name != "megacaps" {
+		t.Errorf("list = %+v", lists)
+	}
+	if rec := call(t, h, "u2", http.MethodGet, base, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("another user's list: status %d, want 404", rec.Code)
+	}
+	if rec := call(t, h, "u1", http.MethodDelete, base, "", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status %d, want 204", rec.Code)
+	}
+	if rec := call(t, h, "u1", http.MethodGet, base, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", rec.Code)
+	}
+}
+
+func TestWatchlistCreateRejects(t *testing.T) {
+	h := newTestWatchlistRouter(t)
+	for _, body := range []string{`{"symbols":["IBM"]}`, `{"name":`} {
+		rec := call(t, h, "u1", http.MethodPost, "/v1/watchlists", body, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+			continue
+		}
+		if e := errorBody(t, rec); e.Code == "" {
+			t.Errorf("%s: no error code in %s", body, rec.Body)
+		}
+	}
+}
+
+func TestWatchlistSnapshotRoute(t *testing.T) {
+	h := newTestWatchlistRouter(t)
+	var wl entity.Watchlist
+	call(t, h, "u1", http.MethodPost, "/v1/watchlists", `{"name":"mixed","symbols":["IBM","BAD"]}`, &wl)
+
+	rec := call(t, h, "u1", http.MethodGet, "/v1/watchlists/"+wl.ID+"/snapshot?rsi_low=20&rsi_high=99", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var snap struct {
+		Name    string `json:"name"`
+		Symbols []struct {
+			Symbol    string           `json:"symbol"`
+			LastClose float64          `json:"last_close"`
+			Alert     string           `json:"alert"`
+			Error     *json.RawMessage `json:"error"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Name != "mixed" || len(snap.Symbols) != 2 {
+		t.Fatalf("snapshot %s", rec.Body)
+	}
+	ibm, bad := snap.Symbols[0], snap.Symbols[1]
+	if ibm.Symbol != "IBM" || ibm.LastClose == 0 || ibm.Error != nil || ibm.Alert != "" {
+		t.Errorf("IBM row %+v", ibm)
+	}
+	if bad.Symbol != "BAD" || bad.Error == nil {
+		t.Fatalf("BAD row %+v, want an error", bad)
+	}
+	var e entity.APIError
+	if err := json.Unmarshal(*bad.Error, &e); err != nil || e.Code != entity.CodeUpstreamError || e.Message == "" {
+		t.Errorf("BAD error %s, want the upstream error envelope", *bad.Error)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+)
+
+type WebhookHandler struct {
+	webhookSvc *service.WebhookService
+}
+
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookSvc: svc}
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.webhookSvc.List(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, hooks)
+}
+
+// Create registers a webhook. The response carries the signing secret;
+// it is not shown again.
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	req := entity.WebhookRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wh, err := h.webhookSvc.Create(r.Context(), middleware.UserIDFromContext(r.Context()), req)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, wh)
+}
+
+// Update toggles a webhook with {"enabled": bool}.
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Enabled *bool `json:"enabled" validate:"required"`
+	}{}
+	if err := decodeJSON(r, &req); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	wh, err := h.webhookSvc.SetEnabled(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), *req.Enabled)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, wh)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookSvc.Delete(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+		renderError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries returns the caller's delivery log, newest first.
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	out, err := h.webhookSvc.Deliveries(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, out)
+}
+
+func (h *WebhookHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	out, err := h.webhookSvc.DeadLetters(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.JSON(w, r, out)
+}
+
+// Replay re-queues a dead letter with a fresh retry budget.
+func (h *WebhookHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	d, err := h.webhookSvc.Replay(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, d)
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
+	"golang.org/x/sync/errgroup"
+
+	"marketpulse/internal/api/middleware"
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	wsReadLimit    = 64 << 10
+	wsWriteTimeout = 10 * time.Second
+	// wsReplyBuffer bounds queued replies; a client that lets it fill is
+	// not reading at all and is disconnected.
+	wsReplyBuffer = 256
+	// wsMaxPendingCandles bounds candles held per symbol for a slow
+	// client; older ones are dropped, indicators are always coalesced.
+	wsMaxPendingCandles = 100
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// Connections are authenticated by token, not cookies, so any origin
+	// may connect, matching the CORS policy.
+	CheckOrigin: func(r *http.Request) bool { return true },
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		renderError(w, r, entity.NewError(status, entity.CodeBadRequest, reason.Error()))
+	},
+}
+
+type WSHandler struct {
+	hub              *service.StreamHub
+	intradaySvc      *service.IntradayService
+	watcher          SymbolWatcher
+	maxSubscriptions int
+	snapshotParallel int
+	heartbeat        time.Duration
+}
+
+func NewWSHandler(hub *service.StreamHub, svc *service.IntradayService, watcher SymbolWatcher, maxSubscriptions, snapshotParallel int, heartbeat time.Duration) *WSHandler {
+	return &WSHandler{
+		hub:              hub,
+		intradaySvc:      svc,
+		watcher:          watcher,
+		maxSubscriptions: maxSubscriptions,
+		snapshotParallel: snapshotParallel,
+		heartbeat:        heartbeat,
+	}
+}
+
+// Serve handles GET /ws. After the upgrade, clients send WSRequest
+// messages to subscribe to and unsubscribe from symbols and receive
+// snapshot, candle, indicator and alert messages for them over the one
+// connection.
+func (h *WSHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error.
+		return
+	}
+	defer conn.Close()
+
+	sub, err := h.hub.Subscribe(nil, 0)
+	if err != nil {
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	c := &wsConn{
+		WSHandler: h,
+		conn:      conn,
+		requestID: chimw.GetReqID(r.Context()),
+		sub:       sub,
+		replies:   make(chan entity.WSMessage, wsReplyBuffer),
+		wake:      make(chan struct{}, 1),
+		symbols:   make(map[string]*wsSymbol),
+	}
+	defer c.releaseAll()
+
+	go func() {
+		defer cancel()
+		c.readLoop(ctx)
+	}()
+	go func() {
+		defer cancel()
+		c.pump(ctx)
+	}()
+	c.writeLoop(ctx)
+}
+
+// wsConn is one WebSocket client. readLoop handles requests, pump moves
+// hub updates into per-symbol pending state, and writeLoop is the only
+// writer. Pending updates for a symbol are merged while the client is
+// behind, so a slow reader gets fewer, fuller messages instead of being
+// dropped.
+type wsConn struct {
+	*WSHandler
+	conn      *websocket.Conn
+	requestID string // of the upgrade request, stamped on errors
+	sub       *service.Subscription
+	replies   chan entity.WSMessage
+	wake      chan struct{}
+
+	mu      sync.Mutex
+	symbols map[string]*wsSymbol
+	dirty   []string // symbols with pending output, in arrival order
+}
+
+// wsSymbol is the per-symbol subscription state of a connection.
+type wsSymbol struct {
+	low, high float64
+	release   func()
+	alert     string // last alert sent
+
+	// Pending output. Updates are held until the snapshot is sent so
+	// clients never see an update before the state it applies to, and
+	// bars up to the snapshot's (asOf) are not sent again.
+	awaiting  bool
+	asOf      time.Time
+	snapshot  *entity.IntradayResponse
+	update    *entity.StreamUpdate
+	candles   []entity.Candle
+	coalesced int
+	queued    bool
+}
+
+func (c *wsConn) readLoop(ctx context.Context) {
+	pongWait := 2 * c.heartbeat
+	c.conn.SetReadLimit(wsReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var req entity.WSRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			if !c.replyError(ctx, "", "", entity.NewError(http.StatusBadRequest, entity.CodeInvalidJSON, "invalid JSON")) {
+				return
+			}
+			continue
+		}
+
+		var ok bool
+		switch req.Op {
+		case "subscribe":
+			ok = c.subscribe(ctx, req)
+		case "unsubscribe":
+			ok = c.unsubscribe(ctx, req)
+		case "list":
+			ok = c.reply(ctx, entity.WSMessage{Type: entity.WSTypeSubscriptions, ID: req.ID, Interval: feed.Interval, Symbols: c.subscribed()})
+		default:
+			ok = c.replyError(ctx, req.ID, "", entity.ErrBadRequest(fmt.Sprintf("unknown op %q", req.Op)))
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+func (c *wsConn) subscribe(ctx context.Context, req entity.WSRequest) bool {
+	fail := func(err error) bool {
+		return c.replyError(ctx, req.ID, "", err)
+	}
+	if req.Interval != "" && req.Interval != feed.Interval {
+		return fail(entity.ErrBadRequest(fmt.Sprintf("unsupported interval %q (available: %s)", req.Interval, feed.Interval)))
+	}
+	if len(req.Symbols) == 0 {
+		return fail(entity.ErrBadRequest("symbols required"))
+	}
+	if err := middleware.ValidateStruct(req); err != nil {
+		return fail(err)
+	}
+	low, high := 30.0, 70.0
+	if req.RSILow != nil {
+		low = *req.RSILow
+	}
+	if req.RSIHigh != nil {
+		high = *req.RSIHigh
+	}
+
+	symbols, added, err := c.hub.Add(c.sub, req.Symbols)
+	if err != nil {
+		return fail(err)
+	}
+
+	c.mu.Lock()
+	if len(c.symbols)+len(added) > c.maxSubscriptions {
+		c.mu.Unlock()
+		c.hub.Remove(c.sub, added)
+		return fail(entity.ErrLimitExceeded(fmt.Sprintf("at most %d subscriptions per connection", c.maxSubscriptions)))
+	}
+	// Re-subscribing an existing symbol just updates its thresholds.
+	for _, sym := range symbols {
+		if st := c.symbols[sym]; st != nil {
+			st.low, st.high = low, high
+		}
+	}
+	for _, sym := range added {
+		st := &wsSymbol{low: low, high: high, awaiting: true}
+		if c.watcher != nil {
+			st.release = c.watcher.Watch([]string{sym})
+		}
+		c.symbols[sym] = st
+	}
+	c.mu.Unlock()
+
+	if !c.reply(ctx, entity.WSMessage{Type: entity.WSTypeSubscribed, ID: req.ID, Interval: feed.Interval, Symbols: added}) {
+		return false
+	}
+	if len(added) > 0 {
+		// Seeding many cold symbols can take a while behind the upstream
+		// limiter; keep reading (and answering pings) meanwhile.
+		go c.snapshot(ctx, req.ID, added, low, high)
+	}
+	return true
+}
+
+// snapshot sends the current state of newly subscribed symbols as each
+// becomes available, releasing updates held for it. Symbols that fail are
+// unsubscribed again.
+func (c *wsConn) snapshot(ctx context.Context, id string, symbols []string, low, high float64) {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.snapshotParallel)
+	one := 1
+	for _, sym := range symbols {
+		g.Go(func() error {
+			resp, err := c.intradaySvc.GetIntraday(ctx, entity.IntradayRequest{Symbol: sym, Tail: &one, RSILow: &low, RSIHigh: &high})
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				c.drop(sym)
+				c.replyError(ctx, id, sym, err)
+				return nil
+			}
+			c.mu.Lock()
+			if st := c.symbols[sym]; st != nil && st.awaiting {
+				st.snapshot, st.awaiting = resp, false
+				st.alert = resp.Alert
+				st.asOf = resp.DataAsOf
+				// Held updates the snapshot already reflects are dropped
+				st.candles = newerCandles(st.candles, st.asOf)
+				if len(st.candles) == 0 {
+					st.update, st.coalesced = nil, 0
+				}
+				c.markLocked(sym, st)
+			}
+			c.mu.Unlock()
+			c.signal()
+			return nil
+		})
+	}
+	g.Wait()
+}
+
+func (c *wsConn) unsubscribe(ctx context.Context, req entity.WSRequest) bool {
+	removed := c.hub.Remove(c.sub, req.Symbols)
+	c.mu.Lock()
+	for _, sym := range removed {
+		c.forgetLocked(sym)
+	}
+	c.mu.Unlock()
+	return c.reply(ctx, entity.WSMessage{Type: entity.WSTypeUnsubscribed, ID: req.ID, Symbols: removed})
+}
+
+// drop removes a symbol whose snapshot failed.
+func (c *wsConn) drop(sym string) {
+	c.hub.Remove(c.sub, []string{sym})
+	c.mu.Lock()
+	c.forgetLocked(sym)
+	c.mu.Unlock()
+}
+
+func (c *wsConn) forgetLocked(sym string) {
+	if st := c.symbols[sym]; st != nil && st.release != nil {
+		st.release()
+	}
+	delete(c.symbols, sym)
+}
+
+func (c *wsConn) releaseAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sym := range c.symbols {
+		c.forgetLocked(sym)
+	}
+}
+
+func (c *wsConn) subscribed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, 0, len(c.symbols))
+	for sym := range c.symbols {
+		out = append(out, sym)
+	}
+	return out
+}
+
+// reply queues a message for the writer; false means the connection is
+// done.
+func (c *wsConn) reply(ctx context.Context, msg entity.WSMessage) bool {
+	select {
+	case c.replies <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	default:
+		// Not reading at all.
+		return false
+	}
+}
+
+// replyError queues an error message in the uniform error shape.
+func (c *wsConn) replyError(ctx context.Context, id, symbol string, err error) bool {
+	ae := entity.AsAPIError(err)
+	ae.RequestID = c.requestID
+	return c.reply(ctx, entity.WSMessage{Type: entity.WSTypeError, ID: id, Symbol: symbol, Error: &ae})
+}
+
+// pump merges hub updates into pending per-symbol state. It never blocks
+// on the client, so the hub never has to drop this subscription.
+func (c *wsConn) pump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-c.sub.C:
+			if !ok {
+				return
+			}
+			c.mu.Lock()
+			if st := c.symbols[u.Symbol]; st != nil && len(newerCandles(u.Candles, st.asOf)) > 0 {
+				if st.update != nil {
+					st.coalesced++
+				}
+				st.update = &u
+				st.candles = append(st.candles, newerCandles(u.Candles, st.asOf)...)
+				if n := len(st.candles); n > wsMaxPendingCandles {
+					st.candles = append([]entity.Candle(nil), st.candles[n-wsMaxPendingCandles:]...)
+				}
+				c.markLocked(u.Symbol, st)
+			}
+			c.mu.Unlock()
+			c.signal()
+		}
+	}
+}
+
+// newerCandles returns the candles after asOf. Updates carry their bars
+// in order, so it is a suffix of candles.
+func newerCandles(candles []entity.Candle, asOf time.Time) []entity.Candle {
+	i := 0
+	for i < len(candles) && !candles[i].Timestamp.After(asOf) {
+		i++
+	}
+	return candles[i:]
+}
+
+func (c *wsConn) markLocked(sym string, st *wsSymbol) {
+	if !st.queued && !st.awaiting {
+		st.queued = true
+		c.dirty = append(c.dirty, sym)
+	}
+}
+
+func (c *wsConn) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *wsConn) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(c.heartbeat)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		case msg := <-c.replies:
+			if c.write(msg) != nil {
+				return
+			}
+		case <-c.wake:
+			for _, msg := range c.takePending() {
+				if c.write(msg) != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			if c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) write(msg entity.WSMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// takePending turns pending state into messages: snapshot, then candles,
+// the latest indicator, and an alert message if the alert changed.
+func (c *wsConn) takePending() []entity.WSMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []entity.WSMessage
+	for _, sym := range c.dirty {
+		st := c.symbols[sym]
+		if st == nil {
+			continue
+		}
+		st.queued = false
+		if st.snapshot != nil {
+			out = append(out, entity.WSMessage{Type: entity.WSTypeSnapshot, Symbol: sym, Interval: feed.Interval, Snapshot: st.snapshot})
+			st.snapshot = nil
+		}
+		u := st.update
+		if u == nil {
+			continue
+		}
+		for i := range st.candles {
+			out = append(out, entity.WSMessage{Type: entity.WSTypeCandle, Symbol: sym, Interval: feed.Interval, Seq: u.ID, Candle: &st.candles[i]})
+		}
+		out = append(out, entity.WSMessage{
+			Type:     entity.WSTypeIndicator,
+			Symbol:   sym,
+			Interval: feed.Interval,
+			Seq:      u.ID,
+			Indicator: &entity.WSIndicator{
+				RSI:          u.RSI,
+				ChangePct:    u.ChangePct,
+				IsValidRSI:   u.IsValidRSI,
+				WarmupStatus: u.WarmupStatus,
+				RSICount:     u.RSICount,
+				UpdatedAt:    u.UpdatedAt,
+				Coalesced:    st.coalesced,
+			},
+		})
+		if alert := rsi.CheckAlert(u.RSI, st.low, st.high); alert != st.alert {
+			out = append(out, entity.WSMessage{Type: entity.WSTypeAlert, Symbol: sym, Interval: feed.Interval, Seq: u.ID,
+				Alert: &entity.WSAlert{Alert: alert, Previous: st.alert, RSI: u.RSI}})
+			st.alert = alert
+		}
+		st.update, st.candles, st.coalesced = nil, nil, 0
+	}
+	c.dirty = c.dirty[:0]
+	return out
+}
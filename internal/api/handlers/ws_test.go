@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+var wsT0 = time.Date(2024, 3, 6, 14, 30, 0, 0, time.UTC)
+
+// barAt is the start of the i-th 5-minute bar after wsT0.
+func barAt(i int) time.Time { return wsT0.Add(time.Duration(i) * 5 * time.Minute) }
+
+// stubFeed serves n rising closes, newest first.
+type stubFeed struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (f *stubFeed) FetchIntraday(_ context.Context, _ string, since time.Time) ([]feed.Candle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []feed.Candle
+	for i := f.n - 1; i >= 0; i-- {
+		if ts := barAt(i); ts.After(since) {
+			c := 100 + float64(i%7)
+			out = append(out, feed.Candle{Timestamp: ts, Open: c, High: c, Low: c, Close: c, Volume: 10})
+		}
+	}
+	return out, nil
+}
+
+func newTestWS(t *testing.T, maxSubs int) (*websocket.Conn, *service.StreamHub) {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := service.NewStreamHub(policy)
+	svc := service.NewIntradayService(newTestStore(t), &stubFeed{n: 30}, policy, nil)
+	h := NewWSHandler(hub, svc, nil, maxSubs, 4, time.Second)
+
+	srv := httptest.NewServer(http.HandlerFunc(h.Serve))
+	t.Cleanup(srv.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, hub
+}
+
+func wsSend(t *testing.T, conn *websocket.Conn, req entity.WSRequest) {
+	t.Helper()
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wsRead returns the next message.
+func wsRead(t *testing.T, conn *websocket.Conn) entity.WSMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var msg entity.WSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func f64(v float64) *float64 { return &v }
+
+func TestWSSubscribe(t *testing.T) {
+	conn, _ := newTestWS(t, 10)
+
+	wsSend(t, conn, entity.WSRequest{Op: "subscribe", ID: "1", Symbols: []string{"ibm"}})
+	if msg := wsRead(t, conn); msg.Type != entity.WSTypeSubscribed || msg.ID != "1" || len(msg.Symbols) != 1 || msg.Symbols[0] != "IBM" {
+		t.Fatalf("got %+v, want subscribed IBM", msg)
+	}
+	msg := wsRead(t, conn)
+	if msg.Type != entity.WSTypeSnapshot || msg.Symbol != "IBM" || msg.Snapshot == nil || !msg.Snapshot.DataAsOf.Equal(barAt(29)) {
+		t.Fatalf("got %+v, want IBM snapshot as of the last bar", msg)
+	}
+
+	wsSend(t, conn, entity.WSRequest{Op: "list", ID: "2"})
+	if msg := wsRead(t, conn); msg.Type != entity.WSTypeSubscriptions || len(msg.Symbols) != 1 {
+		t.Errorf("got %+v, want one subscription", msg)
+	}
+	wsSend(t, conn, entity.WSRequest{Op: "unsubscribe", ID: "3", Symbols: []string{"IBM"}})
+	if msg := wsRead(t, conn); msg.Type != entity.WSTypeUnsubscribed || len(msg.Symbols) != 1 {
+		t.Errorf("got %+v, want IBM unsubscribed", msg)
+	}
+}
+
+func TestWSSubscribeRejects(t *testing.T) {
+	conn, _ := newTestWS(t, 2)
+	cases := []struct {
+		name  string
+		req   entity.WSRequest
+		code  string
+		field string
+	}{
+		{"low above high", entity.WSRequest{Symbols: []string{"IBM"}, RSILow: f64(80), RSIHigh: f64(20)}, entity.CodeValidationFailed, "rsi_low"},
+		{"low above default high", entity.WSRequest{Symbols: []string{"IBM"}, RSILow: f64(75)}, entity.CodeValidationFailed, "rsi_low"},
+		{"out of range", entity.WSRequest{Symbols: []string{"IBM"}, RSIHigh: f64(150)}, entity.CodeValidationFailed, "rsi_high"},
+		{"bad interval", entity.WSRequest{Symbols: []string{"IBM"}, Interval: "1min"}, entity.CodeBadRequest, ""},
+		{"no symbols", entity.WSRequest{}, entity.CodeBadRequest, ""},
+		{"too many", entity.WSRequest{Symbols: []string{"IBM", "AAPL", "MSFT"}}, entity.CodeLimitExceeded, ""},
+	}
+	for _, c := range cases {
+		c.req.Op, c.req.ID = "subscribe", c.name
+		wsSend(t, conn, c.req)
+		msg := wsRead(t, conn)
+		if msg.Type != entity.WSTypeError || msg.ID != c.name || msg.Error == nil || msg.Error.Code != c.code {
+			t.Errorf("%s: got %+v, want %s error", c.name, msg, c.code)
+			continue
+		}
+		if c.field != "" {
+			fields, _ := msg.Error.Details.([]any)
+			if len(fields) != 1 || fields[0].(map[string]any)["field"] != c.field {
+				t.Errorf("%s: details %v, want %s", c.name, msg.Error.Details, c.field)
+			}
+		}
+	}
+
+	wsSend(t, conn, entity.WSRequest{Op: "list", ID: "list"})
+	if msg := wsRead(t, conn); len(msg.Symbols) != 0 {
+		t.Errorf("rejected subscribes left %v subscribed", msg.Symbols)
+	}
+}
+
+func TestWSSkipsBarsInSnapshot(t *testing.T) {
+	conn, hub := newTestWS(t, 10)
+	wsSend(t, conn, entity.WSRequest{Op: "subscribe", Symbols: []string{"IBM"}})
+	wsRead(t, conn) // subscribed
+	if msg := wsRead(t, conn); msg.Type != entity.WSTypeSnapshot {
+		t.Fatalf("got %+v, want snapshot", msg)
+	}
+
+	// The snapshot already has bar 29; only bar 30 is new
+	st := &rsi.CompactRSI{Count: 30, RSI: 55, AvgLoss: 1}
+	hub.Publish(context.Background(), "IBM", st, []entity.Candle{{Timestamp: barAt(29), Close: 101}}, nil)
+	hub.Publish(context.Background(), "IBM", st, []entity.Candle{{Timestamp: barAt(29), Close: 101}, {Timestamp: barAt(30), Close: 102}}, nil)
+
+	msg := wsRead(t, conn)
+	if msg.Type != entity.WSTypeCandle || !msg.Candle.Timestamp.Equal(barAt(30)) {
+		t.Fatalf("got %+v, want only the bar after the snapshot", msg)
+	}
+	if msg := wsRead(t, conn); msg.Type != entity.WSTypeIndicator || msg.Indicator.Coalesced != 0 {
+		t.Errorf("got %+v, want one uncoalesced indicator", msg)
+	}
+}
+
+// newPumpConn is a wsConn subscribed to IBM, with its pump running and no
+// writer, as if the client stopped reading.
+func newPumpConn(t *testing.T) (*wsConn, *service.StreamHub) {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := service.NewStreamHub(policy)
+	sub, err := hub.Subscribe([]string{"IBM"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &wsConn{
+		WSHandler: &WSHandler{},
+		sub:       sub,
+		replies:   make(chan entity.WSMessage, wsReplyBuffer),
+		wake:      make(chan struct{}, 1),
+		symbols:   map[string]*wsSymbol{"IBM": {low: 30, high: 70, asOf: barAt(0)}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go c.pump(ctx)
+	return c, hub
+}
+
+// waitPending waits until the pump has merged n pending candles.
+func waitPending(t *testing.T, c *wsConn, n int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		got := len(c.symbols["IBM"].candles)
+		c.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("pump did not merge %d candles", n)
+}
+
+func TestWSCoalescesSlowReader(t *testing.T) {
+	c, hub := newPumpConn(t)
+	for i := 1; i <= 5; i++ {
+		st := &rsi.CompactRSI{Count: 20 + i, RSI: 50 + float64(i)*5, AvgLoss: 1}
+		hub.Publish(context.Background(), "IBM", st, []entity.Candle{{Timestamp: barAt(i)}}, nil)
+	}
+	waitPending(t, c, 5)
+
+	var candles, indicators, alerts int
+	for _, m := range c.takePending() {
+		switch m.Type {
+		case entity.WSTypeCandle:
+			candles++
+			if !m.Candle.Timestamp.Equal(barAt(candles)) {
+				t.Errorf("candle %d at %v, want bars in order", candles, m.Candle.Timestamp)
+			}
+		case entity.WSTypeIndicator:
+			indicators++
+			if m.Indicator.Coalesced != 4 || m.Indicator.RSI != 75 {
+				t.Errorf("indicator %+v, want the latest of 5 updates", m.Indicator)
+			}
+		case entity.WSTypeAlert:
+			alerts++
+			if m.Alert.Alert != "OVERBOUGHT" {
+				t.Errorf("alert %+v", m.Alert)
+			}
+		}
+	}
+	if candles != 5 || indicators != 1 || alerts != 1 {
+		t.Errorf("got %d candles, %d indicators, %d alerts; want 5, 1, 1", candles, indicators, alerts)
+	}
+	if again := c.takePending(); len(again) != 0 {
+		t.Errorf("%d messages left after taking pending", len(again))
+	}
+}
+
+func TestWSBackpressure(t *testing.T) {
+	c, hub := newPumpConn(t)
+	// 30 updates of 5 bars: more than a slow client may hold
+	bar := 1
+	for i := 0; i < 30; i++ {
+		candles := make([]entity.Candle, 5)
+		for j := range candles {
+			candles[j] = entity.Candle{Timestamp: barAt(bar)}
+			bar++
+		}
+		hub.Publish(context.Background(), "IBM", &rsi.CompactRSI{Count: 20 + bar, RSI: 50, AvgLoss: 1}, candles, nil)
+	}
+	waitPending(t, c, wsMaxPendingCandles)
+
+	msgs := c.takePending()
+	if len(msgs) != wsMaxPendingCandles+1 {
+		t.Fatalf("got %d messages, want %d candles and an indicator", len(msgs), wsMaxPendingCandles)
+	}
+	// The newest bars are kept
+	if first := msgs[0].Candle.Timestamp; !first.Equal(barAt(bar - wsMaxPendingCandles)) {
+		t.Errorf("oldest kept candle at %v, want %v", first, barAt(bar-wsMaxPendingCandles))
+	}
+	if ind := msgs[len(msgs)-1].Indicator; ind == nil || ind.Coalesced != 29 {
+		t.Errorf("indicator %+v, want 29 coalesced", ind)
+	}
+
+	// The hub never had to drop the subscription
+	select {
+	case _, ok := <-c.sub.C:
+		if !ok {
+			t.Error("subscription closed by the hub")
+		}
+	default:
+	}
+
+	// A client that doesn't read replies at all is let go
+	for i := 0; i < wsReplyBuffer; i++ {
+		if !c.reply(context.Background(), entity.WSMessage{Type: entity.WSTypeSubscriptions}) {
+			t.Fatalf("reply %d refused before the buffer filled", i)
+		}
+	}
+	if c.reply(context.Background(), entity.WSMessage{Type: entity.WSTypeSubscriptions}) {
+		t.Error("reply accepted with a full buffer")
+	}
+}
+
+func TestNewerCandles(t *testing.T) {
+	candles := []entity.Candle{{Timestamp: barAt(1)}, {Timestamp: barAt(2)}, {Timestamp: barAt(3)}}
+	cases := []struct {
+		asOf time.Time
+		want int
+	}{
+		{time.Time{}, 3},
+		{barAt(1), 2},
+		{barAt(3), 0},
+		{barAt(9), 0},
+	}
+	for _, c := range cases {
+		if got := newerCandles(candles, c.asOf); len(got) != c.want {
+			t.Errorf("newerCandles(%v) kept %d, want %d", c.asOf, len(got), c.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"symbol":"IBM","rsi":42.56}`+"\n", 100)
+	serve := func(contentType string) http.Handler {
+		return Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			io.WriteString(w, body)
+		}))
+	}
+
+	cases := []struct {
+		name, contentType, accept, want string
+	}{
+		{"brotli preferred", "application/json", "gzip, deflate, br", "br"},
+		{"gzip only", "application/x-ndjson", "gzip", "gzip"},
+		{"msgpack", "application/msgpack", "br", "br"},
+		{"csv", "text/csv; charset=utf-8", "gzip", "gzip"},
+		{"no accept-encoding", "application/json", "", ""},
+		{"unsupported encoding", "application/json", "zstd", ""},
+		{"event stream", "text/event-stream", "gzip, br", ""},
+		{"binary", "image/png", "gzip, br", ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.accept != "" {
+			req.Header.Set("Accept-Encoding", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		serve(c.contentType).ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != c.want {
+			t.Errorf("%s: Content-Encoding = %q, want %q", c.name, got, c.want)
+			continue
+		}
+		var r io.Reader = rec.Body
+		switch c.want {
+		case "br":
+			r = brotli.NewReader(rec.Body)
+		case "gzip":
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			r = zr
+		}
+		if c.want != "" && rec.Body.Len() >= len(body) {
+			t.Errorf("%s: %d compressed bytes for a %d byte body", c.name, rec.Body.Len(), len(body))
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != body {
+			t.Errorf("%s: decoded body differs (%v)", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecated marks responses of a deprecated route set: Deprecation
+// (RFC 9745) carries since, Sunset (RFC 8594) carries sunset unless it is
+// zero, and Link points at the same path under successor.
+func Deprecated(successor string, since, sunset time.Time) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor, r.URL.Path))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDeprecated(t *testing.T) {
+	since := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	cases := []struct {
+		name   string
+		sunset time.Time
+		want   string
+	}{
+		{"announced", sunset, "Thu, 01 Apr 2027 10:00:00 GMT"},
+		{"no date yet", time.Time{}, ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		Deprecated("/v1", since, c.sunset)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/market/intraday/IBM?tail=5", nil))
+
+		if got := rec.Header().Get("Deprecation"); got != "@1792281600" {
+			t.Errorf("%s: Deprecation = %q, want @1792281600", c.name, got)
+		}
+		if got, present := rec.Header().Get("Sunset"), rec.Header().Values("Sunset") != nil; got != c.want || present != (c.want != "") {
+			t.Errorf("%s: Sunset = %q, want %q", c.name, got, c.want)
+		}
+		if got, want := rec.Header().Get("Link"), `</v1/market/intraday/IBM>; rel="successor-version"`; got != want {
+			t.Errorf("%s: Link = %q, want %q", c.name, got, want)
+		}
+	}
+}
+
+// Mounted the way the router mounts the legacy alias: only the
+// unversioned routes are marked.
+func TestDeprecatedOnlyLegacyRoutes(t *testing.T) {
+	mount := func(r chi.Router) {
+		r.Get("/health/{x}", func(w http.ResponseWriter, r *http.Request) {})
+	}
+	r := chi.NewRouter()
+	r.Route("/v1", mount)
+	r.Group(func(r chi.Router) {
+		r.Use(Deprecated("/v1", time.Unix(0, 0), time.Time{}))
+		mount(r)
+	})
+
+	for path, deprecated := range map[string]bool{"/v1/health/a": false, "/health/a": true} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("Deprecation") != ""; got != deprecated {
+			t.Errorf("%s: deprecated = %v, want %v", path, got, deprecated)
+		}
+		if deprecated && rec.Header().Get("Link") != `</v1/health/a>; rel="successor-version"` {
+			t.Errorf("%s: Link = %q", path, rec.Header().Get("Link"))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// RequestIDHeader carries the request ID back to the client, and is
+// honoured when the client sends one.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID assigns every request an ID, exposes it in the response
+// header and makes it available to RenderError.
+func RequestID(next http.Handler) http.Handler {
+	return chimw.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RequestIDHeader, chimw.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// RenderError writes err as the uniform envelope
+// {"error": {"code", "message", "details", "request_id"}}. Errors that are
+// not entity.APIError values become 500 INTERNAL_ERROR.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	ae := entity.AsAPIError(err)
+	ae.RequestID = chimw.GetReqID(r.Context())
+	render.Status(r, ae.Status)
+	render.JSON(w, r, map[string]entity.APIError{"error": ae})
+}
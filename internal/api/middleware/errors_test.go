@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"marketpulse/internal/domain/entity"
+)
+
+func TestAsAPIError(t *testing.T) {
+	notFound := entity.ErrNotFound("no such rule")
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"api error", notFound, http.StatusNotFound, entity.CodeNotFound},
+		{"wrapped", fmt.Errorf("load: %w", notFound), http.StatusNotFound, entity.CodeNotFound},
+		{"limit", entity.ErrLimitExceeded("too many"), http.StatusBadRequest, entity.CodeLimitExceeded},
+		{"forbidden", entity.ErrForbidden("no"), http.StatusForbidden, entity.CodeForbidden},
+		{"plain", errors.New("disk full"), http.StatusInternalServerError, entity.CodeInternal},
+	}
+	for _, c := range cases {
+		ae := entity.AsAPIError(c.err)
+		if ae.Status != c.status || ae.Code != c.code {
+			t.Errorf("%s: got %d %s, want %d %s", c.name, ae.Status, ae.Code, c.status, c.code)
+		}
+	}
+	if ae := entity.AsAPIError(errors.New("dial tcp 10.0.0.7:6379: connection refused")); ae.Message != "internal error" {
+		t.Errorf("plain error message = %q, want the generic one", ae.Message)
+	}
+}
+
+func TestRenderErrorHidesInternals(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RenderError(w, r, fmt.Errorf("load rules: %w", errors.New("dial tcp 10.0.0.7:6379: connection refused")))
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/alerts/rules", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "10.0.0.7") || !strings.Contains(rec.Body.String(), `"message":"internal error"`) {
+		t.Errorf("body = %s", rec.Body)
+	}
+}
+
+func TestRenderErrorEnvelope(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RenderError(w, r, entity.ErrValidation([]entity.FieldError{{Field: "tail", Message: "must be a non-negative integer"}}))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/market/intraday/IBM?tail=-1", nil)
+	req.Header.Set(chimw.RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "req-1" {
+		t.Errorf("%s = %q, want req-1", RequestIDHeader, got)
+	}
+	var body struct {
+		Error struct {
+			Code      string              `json:"code"`
+			Message   string              `json:"message"`
+			Details   []entity.FieldError `json:"details"`
+			RequestID string              `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body)
+	}
+	e := body.Error
+	if e.Code != entity.CodeValidationFailed || e.RequestID != "req-1" || len(e.Details) != 1 || e.Details[0].Field != "tail" {
+		t.Errorf("body = %s", rec.Body)
+	}
+	if e.Message != "invalid request: tail must be a non-negative integer" {
+		t.Errorf("message = %q", e.Message)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -10,23 +11,49 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rs/cors"
 	"go.uber.org/zap"
+
+	"marketpulse/internal/domain/entity"
 )
 
 type ctxKey string
 
-const ctxUserIDKey ctxKey = "userID"
+const (
+	ctxUserIDKey ctxKey = "userID"
+	ctxRoleKey   ctxKey = "role"
+)
+
+// RoleAdmin is granted at login to the users in ADMIN_USERS and is
+// required by the /admin routes.
+const RoleAdmin = "admin"
 
 type Claims struct {
 	UserID string `json:"user_id"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Auth requires a valid JWT in the Authorization header.
 func Auth(secret []byte) func(http.Handler) http.Handler {
+	return auth(secret, false)
+}
+
+// StreamAuth is Auth for the SSE and WebSocket routes. Browser
+// EventSource and WebSocket clients cannot set headers, so an
+// access_token query parameter is accepted instead. Tokens in URLs end
+// up in logs and history, so no other route takes one.
+func StreamAuth(secret []byte) func(http.Handler) http.Handler {
+	return auth(secret, true)
+}
+
+func auth(secret []byte, queryToken bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
+			if auth == "" && queryToken {
+				auth = r.URL.Query().Get("access_token")
+			}
 			if auth == "" {
-				http.Error(w, "missing auth", http.StatusUnauthorized)
+				RenderError(w, r, entity.NewError(http.StatusUnauthorized, entity.CodeMissingToken, "missing auth"))
 				return
 			}
 
@@ -36,12 +63,17 @@ func Auth(secret []byte) func(http.Handler) http.Handler {
 			token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
 				return secret, nil
 			})
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				RenderError(w, r, entity.NewError(http.StatusUnauthorized, entity.CodeTokenExpired, "token expired"))
+				return
+			}
 			if err != nil || !token.Valid {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+				RenderError(w, r, entity.NewError(http.StatusUnauthorized, entity.CodeInvalidToken, "invalid token"))
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), ctxUserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, ctxRoleKey, claims.Role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -56,9 +88,24 @@ func UserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
-func GenerateJWT(secret string, userID string, expiry time.Duration) (string, time.Time) {
+// RequireRole rejects requests whose token lacks role with 403. Must run
+// after Auth.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got, _ := r.Context().Value(ctxRoleKey).(string); got != role {
+				RenderError(w, r, entity.NewError(http.StatusForbidden, entity.CodeForbidden, role+" role required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func GenerateJWT(secret string, userID, role string, expiry time.Duration) (string, time.Time) {
 	claims := Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -73,8 +120,9 @@ func GenerateJWT(secret string, userID string, expiry time.Duration) (string, ti
 func CORS() func(http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", RequestIDHeader},
+		ExposedHeaders:   []string{RequestIDHeader, "ETag", "Deprecation", "Sunset", "Link"},
 		AllowCredentials: true,
 	})
 	return c.Handler
@@ -88,6 +136,7 @@ func Logger(logger *zap.Logger) func(http.Handler) http.Handler {
 					zap.String("method", r.Method),
 					zap.String("path", r.URL.Path),
 					zap.String("remote", r.RemoteAddr),
+					zap.String("request_id", chimw.GetReqID(r.Context())),
 				)
 			}
 			next.ServeHTTP(w, r)
@@ -95,6 +144,23 @@ func Logger(logger *zap.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// Recoverer turns a handler panic into a 500 INTERNAL_ERROR envelope
+// after printing the stack.
 func Recoverer(next http.Handler) http.Handler {
-	return chimw.Recoverer(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+			chimw.PrintPrettyStack(rvr)
+			if r.Header.Get("Connection") != "Upgrade" {
+				RenderError(w, r, entity.ErrInternal("unexpected error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
 }
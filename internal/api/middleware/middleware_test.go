@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequireRole(t *testing.T) {
+	const secret = "test-secret"
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if UserIDFromContext(r.Context()) != "u1" {
+			t.Errorf("user id = %q, want u1", UserIDFromContext(r.Context()))
+		}
+	})
+	h := Auth([]byte(secret))(RequireRole(RoleAdmin)(ok))
+
+	cases := []struct {
+		name string
+		auth string
+		want int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"user token", bearer(secret, "u1", ""), http.StatusForbidden},
+		{"other role", bearer(secret, "u1", "viewer"), http.StatusForbidden},
+		{"admin token", bearer(secret, "u1", RoleAdmin), http.StatusOK},
+		{"admin token, wrong key", bearer("other", "u1", RoleAdmin), http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/state/export", nil)
+			if c.auth != "" {
+				req.Header.Set("Authorization", c.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, c.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestQueryToken(t *testing.T) {
+	const secret = "test-secret"
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	token, _ := GenerateJWT(secret, "u1", "", time.Minute)
+
+	cases := []struct {
+		name string
+		mw   func(http.Handler) http.Handler
+		want int
+	}{
+		{"stream route", StreamAuth([]byte(secret)), http.StatusOK},
+		{"other route", Auth([]byte(secret)), http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/market/stream?access_token="+token, nil)
+			rec := httptest.NewRecorder()
+			c.mw(ok).ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, c.want, rec.Body)
+			}
+		})
+	}
+}
+
+func bearer(secret, userID, role string) string {
+	token, _ := GenerateJWT(secret, userID, role, time.Minute)
+	return "Bearer " + token
+}
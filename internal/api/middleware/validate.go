@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// Default alert thresholds, applied when only one side is given.
+const (
+	defaultRSILow  = 30.0
+	defaultRSIHigh = 70.0
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report JSON names, so errors match what the client sent.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterStructValidation(batchThresholds, entity.BatchIntradayRequest{})
+	v.RegisterStructValidation(backtestThresholds, entity.BacktestRequest{})
+	v.RegisterStructValidation(wsThresholds, entity.WSRequest{})
+	return v
+}
+
+// ValidateStruct enforces the validate tags on a decoded request body and
+// returns a VALIDATION_FAILED entity.APIError listing every invalid field.
+func ValidateStruct(v any) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	fields := make([]entity.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, entity.FieldError{Field: fieldPath(fe), Message: fieldMessage(fe)})
+	}
+	return entity.ErrValidation(fields)
+}
+
+// ValidateQuery rejects requests whose shared query parameters are
+// malformed or out of range, instead of letting handlers ignore them:
+// tail and limit must be non-negative integers, rsi_low and rsi_high
+// numbers in [0, 100] with rsi_low < rsi_high (30 and 70 when omitted).
+func ValidateQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fields := QueryErrors(r.URL.Query()); len(fields) > 0 {
+			RenderError(w, r, entity.ErrValidation(fields))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// QueryErrors validates the shared query parameters in q.
+func QueryErrors(q url.Values) []entity.FieldError {
+	var fields []entity.FieldError
+	for _, name := range []string{"tail", "limit"} {
+		if v := q.Get(name); v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				fields = append(fields, entity.FieldError{Field: name, Message: "must be a non-negative integer"})
+			}
+		}
+	}
+
+	var low, high *float64
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"rsi_low", &low}, {"rsi_high", &high}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			fields = append(fields, entity.FieldError{Field: p.name, Message: "must be a number"})
+			continue
+		}
+		*p.dst = &f
+	}
+	return append(fields, thresholdErrors("", low, high)...)
+}
+
+// thresholdErrors checks an alert threshold pair, filling the defaults for
+// a missing side. prefix is prepended to field names.
+func thresholdErrors(prefix string, low, high *float64) []entity.FieldError {
+	var fields []entity.FieldError
+	l, h := defaultRSILow, defaultRSIHigh
+	if low != nil {
+		l = *low
+		if l < 0 || l > 100 {
+			fields = append(fields, entity.FieldError{Field: prefix + "rsi_low", Message: "must be between 0 and 100"})
+		}
+	}
+	if high != nil {
+		h = *high
+		if h < 0 || h > 100 {
+			fields = append(fields, entity.FieldError{Field: prefix + "rsi_high", Message: "must be between 0 and 100"})
+		}
+	}
+	if len(fields) == 0 && l >= h {
+		fe := entity.FieldError{Field: prefix + "rsi_low", Message: fmt.Sprintf("must be less than rsi_high (%g)", h)}
+		if low == nil {
+			fe = entity.FieldError{Field: prefix + "rsi_high", Message: fmt.Sprintf("must be greater than rsi_low (%g)", l)}
+		}
+		fields = append(fields, fe)
+	}
+	return fields
+}
+
+// batchThresholds checks each symbol's effective thresholds: its own, or
+// the batch-level ones it inherits.
+func batchThresholds(sl validator.StructLevel) {
+	req := sl.Current().Interface().(entity.BatchIntradayRequest)
+	reportThresholds(sl, "", req.RSILow, req.RSIHigh)
+	for i, s := range req.Symbols {
+		low, high := s.RSILow, s.RSIHigh
+		if low == nil {
+			low = req.RSILow
+		}
+		if high == nil {
+			high = req.RSIHigh
+		}
+		if s.RSILow == nil && s.RSIHigh == nil {
+			continue // same pair as the batch, already reported
+		}
+		reportThresholds(sl, fmt.Sprintf("symbols[%d].", i), low, high)
+	}
+}
+
+func backtestThresholds(sl validator.StructLevel) {
+	req := sl.Current().Interface().(entity.BacktestRequest)
+	reportThresholds(sl, "", req.RSILow, req.RSIHigh)
+}
+
+func wsThresholds(sl validator.StructLevel) {
+	req := sl.Current().Interface().(entity.WSRequest)
+	reportThresholds(sl, "", req.RSILow, req.RSIHigh)
+}
+
+// reportThresholds reports only the ordering error; range errors come from
+// the field tags.
+func reportThresholds(sl validator.StructLevel, prefix string, low, high *float64) {
+	for _, fe := range thresholdErrors(prefix, low, high) {
+		if fe.Message == "must be between 0 and 100" {
+			continue
+		}
+		sl.ReportError(nil, fe.Field, fe.Field, "rsi_order", fe.Message)
+	}
+}
+
+// fieldPath drops the top-level struct name from the namespace:
+// "BatchIntradayRequest.symbols[0].tail" -> "symbols[0].tail".
+func fieldPath(fe validator.FieldError) string {
+	if fe.Tag() == "rsi_order" {
+		return fe.Field()
+	}
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	isNumber := false
+	switch fe.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64, reflect.Float32, reflect.Int32:
+		isNumber = true
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "rsi_order":
+		return fe.Param()
+	case "min":
+		if isNumber {
+			return "must be at least " + fe.Param()
+		}
+		return "must have at least " + fe.Param() + " item(s)"
+	case "max":
+		if isNumber {
+			return "must be at most " + fe.Param()
+		}
+		return "must have at most " + fe.Param() + " item(s)"
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "http_url":
+		return "must be an absolute http(s) URL"
+	}
+	return "failed " + fe.Tag() + " validation"
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"marketpulse/internal/domain/entity"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// fieldNames returns the invalid fields listed by a VALIDATION_FAILED
+// error, or fails the test if err is anything else.
+func fieldNames(t *testing.T, err error) []string {
+	t.Helper()
+	ae := entity.AsAPIError(err)
+	if ae.Status != http.StatusBadRequest || ae.Code != entity.CodeValidationFailed {
+		t.Fatalf("err = %d %s %q, want 400 VALIDATION_FAILED", ae.Status, ae.Code, ae.Message)
+	}
+	var names []string
+	for _, f := range ae.Details.([]entity.FieldError) {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+func TestQueryErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"tail=10&limit=0&rsi_low=20&rsi_high=80", nil},
+		{"tail=-1", []string{"tail"}},
+		{"tail=ten&limit=1.5", []string{"tail", "limit"}},
+		{"rsi_low=abc", []string{"rsi_low"}},
+		{"rsi_low=-5&rsi_high=101", []string{"rsi_low", "rsi_high"}},
+		{"rsi_low=80&rsi_high=20", []string{"rsi_low"}},
+		// A missing side takes its default
+		{"rsi_low=75", []string{"rsi_low"}},
+		{"rsi_high=25", []string{"rsi_high"}},
+	}
+	for _, c := range cases {
+		q, _ := url.ParseQuery(c.query)
+		var got []string
+		for _, f := range QueryErrors(q) {
+			got = append(got, f.Field)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%q: invalid fields %v, want %v", c.query, got, c.want)
+		}
+	}
+}
+
+func TestValidateStructBatch(t *testing.T) {
+	cases := []struct {
+		name string
+		req  entity.BatchIntradayRequest
+		want []string
+	}{
+		{"empty", entity.BatchIntradayRequest{}, []string{"symbols"}},
+		{"valid", entity.BatchIntradayRequest{Symbols: []entity.IntradayRequest{{Symbol: "IBM"}}}, nil},
+		{"nested", entity.BatchIntradayRequest{
+			Tail:    ptr(-1),
+			Symbols: []entity.IntradayRequest{{Symbol: "IBM"}, {Tail: ptr(-2)}},
+		}, []string{"symbols[1].symbol", "symbols[1].tail", "tail"}},
+		{"batch thresholds inverted", entity.BatchIntradayRequest{
+			RSILow: ptr(80.0), RSIHigh: ptr(20.0),
+			Symbols: []entity.IntradayRequest{{Symbol: "IBM"}},
+		}, []string{"rsi_low"}},
+		// The symbol's own low against the inherited batch high
+		{"symbol threshold against batch", entity.BatchIntradayRequest{
+			RSIHigh: ptr(60.0),
+			Symbols: []entity.IntradayRequest{{Symbol: "IBM"}, {Symbol: "AAPL", RSILow: ptr(65.0)}},
+		}, []string{"symbols[1].rsi_low"}},
+	}
+	for _, c := range cases {
+		err := ValidateStruct(c.req)
+		if c.want == nil {
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			}
+			continue
+		}
+		if got := fieldNames(t, err); !slices.Equal(got, c.want) {
+			t.Errorf("%s: invalid fields %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestValidateQueryRejects(t *testing.T) {
+	called := false
+	h := ValidateQuery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?rsi_low=90", nil))
+	if called || rec.Code != http.StatusBadRequest {
+		t.Errorf("called = %v, status = %d; want the request rejected with 400", called, rec.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// openAPISpec describes every route registered by NewRouter. Keep it in
+// step with the router and the entity types.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// openAPIJSON is openAPISpec converted once at startup.
+var openAPIJSON = mustSpecJSON(openAPISpec)
+
+func mustSpecJSON(spec []byte) []byte {
+	var doc any
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		panic("openapi.yaml: " + err.Error())
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		panic("openapi.yaml: " + err.Error())
+	}
+	return b
+}
+
+func serveOpenAPIYAML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+func serveOpenAPIJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON)
+}
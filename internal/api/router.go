@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -10,29 +12,59 @@ import (
 	"marketpulse/internal/api/handlers"
 	"marketpulse/internal/api/middleware"
 	"marketpulse/internal/config"
+	"marketpulse/internal/domain/entity"
 	"marketpulse/internal/domain/service"
 )
 
-func NewRouter(cfg *config.Config, logger *zap.Logger, marketSvc *service.IntradayService) *chi.Mux {
+func NewRouter(cfg *config.Config, logger *zap.Logger, marketSvc *service.IntradayService, intradayCache *service.IntradayCache, stateSvc *service.StateService, directorySvc *service.DirectoryService, quoteSvc *service.QuoteService, screenerSvc *service.ScreenerService, backtestSvc *service.BacktestService, watchlistSvc *service.WatchlistService, alertSvc *service.AlertService, webhookSvc *service.WebhookService, streamHub *service.StreamHub, watcher handlers.SymbolWatcher) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
 	r.Use(middleware.CORS())
 	r.Use(middleware.Logger(logger))
 	r.Use(middleware.Recoverer)
+	r.Use(middleware.Compress())
 	r.Use(render.SetContentType(render.ContentTypeJSON))
 
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		middleware.RenderError(w, r, entity.ErrNotFound("no such route"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		middleware.RenderError(w, r, entity.NewError(http.StatusMethodNotAllowed, entity.CodeMethodNotAllowed, "method not allowed"))
+	})
+
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
+	r.Get("/openapi.yaml", serveOpenAPIYAML)
+	r.Get("/openapi.json", serveOpenAPIJSON)
 
-	authHandler := handlers.NewAuthHandler(cfg)
-	r.Post("/login", authHandler.Login)
+	h := apiHandlers{
+		auth:      handlers.NewAuthHandler(cfg),
+		market:    handlers.NewMarketHandler(marketSvc, intradayCache, cfg.BatchMaxSymbols, cfg.BatchConcurrency),
+		stream:    handlers.NewStreamHandler(streamHub, marketSvc, watcher, cfg.BatchMaxSymbols, cfg.StreamHeartbeat),
+		ws:        handlers.NewWSHandler(streamHub, marketSvc, watcher, cfg.WSMaxSubscriptions, cfg.BatchConcurrency, cfg.StreamHeartbeat),
+		symbols:   handlers.NewSymbolsHandler(directorySvc),
+		quote:     handlers.NewQuoteHandler(quoteSvc),
+		screener:  handlers.NewScreenerHandler(screenerSvc),
+		backtest:  handlers.NewBacktestHandler(backtestSvc),
+		state:     handlers.NewStateHandler(stateSvc),
+		watchlist: handlers.NewWatchlistHandler(watchlistSvc),
+		alert:     handlers.NewAlertHandler(alertSvc),
+		webhook:   handlers.NewWebhookHandler(webhookSvc),
+	}
 
-	r.Group(func(r chi.Router) {
-		r.Use(middleware.Auth([]byte(cfg.JWTSecret)))
+	r.Route("/v1", func(r chi.Router) { mountAPI(r, cfg, h, 1) })
+	r.Route("/v2", func(r chi.Router) { mountAPI(r, cfg, h, 2) })
 
-		marketHandler := handlers.NewMarketHandler(marketSvc)
-		r.Get("/market/intraday/{symbol}", marketHandler.Intraday)
+	// Legacy unversioned routes behave as /v1 until the sunset date
+	sunset, err := parseSunset(cfg.LegacySunset)
+	if err != nil {
+		logger.Warn("ignoring LEGACY_SUNSET", zap.Error(err))
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Deprecated("/v1", legacyDeprecatedAt, sunset))
+		mountAPI(r, cfg, h, 1)
 	})
 
 	staticDir := http.Dir("./web/static/")
@@ -45,3 +77,118 @@ func NewRouter(cfg *config.Config, logger *zap.Logger, marketSvc *service.Intrad
 
 	return r
 }
+
+// legacyDeprecatedAt is when the unversioned routes were deprecated in
+// favour of /v1.
+var legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// apiHandlers are shared by every API version; versions differ only in
+// which handler serves a route.
+type apiHandlers struct {
+	auth      *handlers.AuthHandler
+	market    *handlers.MarketHandler
+	stream    *handlers.StreamHandler
+	ws        *handlers.WSHandler
+	symbols   *handlers.SymbolsHandler
+	quote     *handlers.QuoteHandler
+	screener  *handlers.ScreenerHandler
+	backtest  *handlers.BacktestHandler
+	state     *handlers.StateHandler
+	watchlist *handlers.WatchlistHandler
+	alert     *handlers.AlertHandler
+	webhook   *handlers.WebhookHandler
+}
+
+// mountAPI registers the routes of API version on r. A new version
+// inherits every route of the previous one; routes whose response shape
+// changed are switched on version below.
+func mountAPI(r chi.Router, cfg *config.Config, h apiHandlers, version int) {
+	r.Post("/login", h.auth.Login)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.StreamAuth([]byte(cfg.JWTSecret)))
+		r.Use(middleware.ValidateQuery)
+
+		r.Get("/market/stream", h.stream.Symbols)
+		r.Get("/market/stream/{symbol}", h.stream.Symbol)
+		r.Get("/ws", h.ws.Serve)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth([]byte(cfg.JWTSecret)))
+		r.Use(middleware.ValidateQuery)
+
+		r.Get("/market/intraday", h.market.IntradayBatch)
+		r.Post("/market/intraday", h.market.IntradayBatchPost)
+		if version >= 2 {
+			// Bars oldest first with nested indicators
+			r.Get("/market/intraday/{symbol}", h.market.IntradayV2)
+			r.Get("/market/history/{symbol}", h.market.HistoryV2)
+		} else {
+			r.Get("/market/intraday/{symbol}", h.market.Intraday)
+			r.Get("/market/history/{symbol}", h.market.History)
+		}
+
+		r.Get("/market/symbols/search", h.symbols.Search)
+		r.Get("/market/symbols/{symbol}", h.symbols.Get)
+		r.Get("/market/quote/{symbol}", h.quote.Get)
+
+		r.Get("/market/screener", h.screener.Screen)
+		r.Post("/backtest", h.backtest.Run)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireRole(middleware.RoleAdmin))
+			r.Get("/admin/state/export", h.state.Export)
+			r.Post("/admin/state/import", h.state.Import)
+		})
+
+		r.Route("/watchlists", func(r chi.Router) {
+			r.Get("/", h.watchlist.List)
+			r.Post("/", h.watchlist.Create)
+			r.Get("/{id}", h.watchlist.Get)
+			r.Patch("/{id}", h.watchlist.Rename)
+			r.Delete("/{id}", h.watchlist.Delete)
+			r.Post("/{id}/symbols", h.watchlist.AddSymbol)
+			r.Put("/{id}/symbols", h.watchlist.Reorder)
+			r.Delete("/{id}/symbols/{symbol}", h.watchlist.RemoveSymbol)
+			r.Get("/{id}/snapshot", h.watchlist.Snapshot)
+		})
+
+		r.Route("/alerts", func(r chi.Router) {
+			r.Get("/", h.alert.ListEvents)
+			r.Post("/{id}/ack", h.alert.Acknowledge)
+			r.Get("/rules", h.alert.ListRules)
+			r.Post("/rules", h.alert.CreateRule)
+			r.Patch("/rules/{id}", h.alert.UpdateRule)
+			r.Delete("/rules/{id}", h.alert.DeleteRule)
+			r.Post("/rules/{id}/snooze", h.alert.Snooze)
+			r.Delete("/rules/{id}/snooze", h.alert.Unsnooze)
+			r.Get("/mutes", h.alert.ListMutes)
+			r.Put("/mutes/{symbol}", h.alert.Mute)
+			r.Delete("/mutes/{symbol}", h.alert.Unmute)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Get("/", h.webhook.List)
+			r.Post("/", h.webhook.Create)
+			r.Get("/deliveries", h.webhook.Deliveries)
+			r.Get("/dead-letters", h.webhook.DeadLetters)
+			r.Post("/dead-letters/{id}/replay", h.webhook.Replay)
+			r.Patch("/{id}", h.webhook.Update)
+			r.Delete("/{id}", h.webhook.Delete)
+		})
+	})
+}
+
+// parseSunset parses LEGACY_SUNSET (YYYY-MM-DD, UTC); empty means no
+// sunset date has been announced.
+func parseSunset(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LEGACY_SUNSET %q: want YYYY-MM-DD", s)
+	}
+	return t, nil
+}
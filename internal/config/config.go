@@ -8,15 +8,117 @@ import (
 )
 
 type Config struct {
-	RedisAddr   string        `mapstructure:"REDIS_ADDR"`
-	RedisPass   string        `mapstructure:"REDIS_PASSWORD"`
-	RedisDB     int           `mapstructure:"REDIS_DB"`
+	RedisAddr string `mapstructure:"REDIS_ADDR"`
+	RedisPass string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB   int    `mapstructure:"REDIS_DB"`
+
+	// Sentinel/Cluster. RedisAddrs (comma separated) overrides RedisAddr;
+	// RedisMasterName selects Sentinel failover, RedisCluster or more than
+	// one address selects Cluster.
+	RedisAddrs         []string      `mapstructure:"REDIS_ADDRS"`
+	RedisMasterName    string        `mapstructure:"REDIS_MASTER_NAME"`
+	RedisCluster       bool          `mapstructure:"REDIS_CLUSTER"`
+	RedisUsername      string        `mapstructure:"REDIS_USERNAME"`
+	RedisSentinelPass  string        `mapstructure:"REDIS_SENTINEL_PASSWORD"`
+	RedisTLS           bool          `mapstructure:"REDIS_TLS"`
+	RedisTLSServerName string        `mapstructure:"REDIS_TLS_SERVER_NAME"`
+	RedisTLSInsecure   bool          `mapstructure:"REDIS_TLS_INSECURE"`
+	RedisPoolSize      int           `mapstructure:"REDIS_POOL_SIZE"`
+	RedisMinIdleConns  int           `mapstructure:"REDIS_MIN_IDLE_CONNS"`
+	RedisDialTimeout   time.Duration `mapstructure:"REDIS_DIAL_TIMEOUT"`
+	RedisReadTimeout   time.Duration `mapstructure:"REDIS_READ_TIMEOUT"`
+	RedisWriteTimeout  time.Duration `mapstructure:"REDIS_WRITE_TIMEOUT"`
+
 	UpstreamURL string        `mapstructure:"UPSTREAM_URL"`
 	HTTPPort    string        `mapstructure:"HTTP_PORT"`
 	JWTSecret   string        `mapstructure:"JWT_SECRET"`
 	JWTExpiry   time.Duration `mapstructure:"JWT_EXPIRY"`
 	LogLevel    string        `mapstructure:"LOG_LEVEL"`
 	MaxSymbols  int           `mapstructure:"MAX_SYMBOLS_MEMORY"`
+
+	// AdminUsers get the admin role at login, which the /admin routes
+	// require. Empty: nobody can use them.
+	AdminUsers []string `mapstructure:"ADMIN_USERS"`
+
+	// FeedMode is "live" (UpstreamURL) or "replay" (ReplayFile on a
+	// virtual clock running ReplaySpeed times faster than real time).
+	FeedMode         string  `mapstructure:"FEED_MODE"`
+	ReplayFile       string  `mapstructure:"REPLAY_FILE"`
+	ReplaySpeed      float64 `mapstructure:"REPLAY_SPEED"`
+	ReplayWarmupBars int     `mapstructure:"REPLAY_WARMUP_BARS"`
+	ReplayLoop       bool    `mapstructure:"REPLAY_LOOP"`
+
+	// Market calendar: "us" (exchange hours below, default) or "always"
+	// for 24/7 markets. Replay mode always uses "always".
+	MarketCalendar      string        `mapstructure:"MARKET_CALENDAR"`
+	MarketTimezone      string        `mapstructure:"MARKET_TIMEZONE"`
+	MarketPreOpen       string        `mapstructure:"MARKET_PRE_OPEN"`
+	MarketOpen          string        `mapstructure:"MARKET_OPEN"`
+	MarketClose         string        `mapstructure:"MARKET_CLOSE"`
+	MarketPostClose     string        `mapstructure:"MARKET_POST_CLOSE"`
+	MarketHolidaysFile  string        `mapstructure:"MARKET_HOLIDAYS_FILE"`
+	MarketExtendedHours bool          `mapstructure:"MARKET_EXTENDED_HOURS"`
+	MarketStaleAfter    time.Duration `mapstructure:"MARKET_STALE_AFTER"`
+
+	// StateBackend selects the RSI state store: "redis" (default) or "file".
+	StateBackend          string        `mapstructure:"STATE_BACKEND"`
+	StateDir              string        `mapstructure:"STATE_DIR"`
+	StateSnapshotInterval time.Duration `mapstructure:"STATE_SNAPSHOT_INTERVAL"`
+
+	// Retention: Redis keys expire after this long without access.
+	// StateTTL covers per-symbol RSI state, HistoryTTL the per-user alert
+	// event and webhook delivery logs. A negative value disables expiry.
+	// The file backend has no expiry and ignores both.
+	StateTTL   time.Duration `mapstructure:"STATE_TTL"`
+	HistoryTTL time.Duration `mapstructure:"HISTORY_TTL"`
+
+	// Symbol policy. SymbolPattern is matched after upper-casing; a
+	// non-empty allowlist rejects everything not on it.
+	SymbolPattern   string   `mapstructure:"SYMBOL_PATTERN"`
+	SymbolAllowlist []string `mapstructure:"SYMBOL_ALLOWLIST"`
+	SymbolDenylist  []string `mapstructure:"SYMBOL_DENYLIST"`
+
+	// Background poller. It always runs: PollSymbols are polled every
+	// round, streamed symbols while watched. PollInterval should match the
+	// upstream candle interval.
+	PollSymbols     []string      `mapstructure:"POLL_SYMBOLS"`
+	PollInterval    time.Duration `mapstructure:"POLL_INTERVAL"`
+	PollDelay       time.Duration `mapstructure:"POLL_DELAY"`
+	PollConcurrency int           `mapstructure:"POLL_CONCURRENCY"`
+
+	// Multi-symbol intraday endpoint limits.
+	BatchMaxSymbols  int `mapstructure:"BATCH_MAX_SYMBOLS"`
+	BatchConcurrency int `mapstructure:"BATCH_CONCURRENCY"`
+
+	// Alert webhook delivery.
+	WebhookWorkers     int           `mapstructure:"WEBHOOK_WORKERS"`
+	WebhookMaxAttempts int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookTimeout     time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookQueueSize   int           `mapstructure:"WEBHOOK_QUEUE_SIZE"`
+	// WebhookAllowPrivate lets webhooks target loopback, private and
+	// link-local addresses. Tests and local development only.
+	WebhookAllowPrivate bool `mapstructure:"WEBHOOK_ALLOW_PRIVATE"`
+
+	// Live streams: SSE comment / WebSocket ping interval, and the
+	// symbol limit per WebSocket connection.
+	StreamHeartbeat    time.Duration `mapstructure:"STREAM_HEARTBEAT"`
+	WSMaxSubscriptions int           `mapstructure:"WS_MAX_SUBSCRIPTIONS"`
+
+	// MicroCacheTTL is how long single-symbol intraday responses are
+	// reused for identical requests; a negative value disables the
+	// micro-cache.
+	MicroCacheTTL time.Duration `mapstructure:"MICRO_CACHE_TTL"`
+
+	// LegacySunset (YYYY-MM-DD) is announced in the Sunset header of the
+	// unversioned routes; empty sends none.
+	LegacySunset string `mapstructure:"LEGACY_SUNSET"`
+
+	// SymbolCacheTTL is how long upstream symbol search and reference
+	// data are cached.
+	SymbolCacheTTL time.Duration `mapstructure:"SYMBOL_CACHE_TTL"`
+	// QuoteCacheTTL is how long a latest quote is served before upstream
+	// is asked again.
+	QuoteCacheTTL time.Duration `mapstructure:"QUOTE_CACHE_TTL"`
 }
 
 func Load() *Config {
@@ -43,6 +145,99 @@ func Load() *Config {
 	if cfg.HTTPPort == "" {
 		cfg.HTTPPort = ":8080"
 	}
+	if len(cfg.RedisAddrs) == 0 && cfg.RedisAddr != "" {
+		cfg.RedisAddrs = []string{cfg.RedisAddr}
+	}
+	if cfg.FeedMode == "" {
+		cfg.FeedMode = "live"
+	}
+	if cfg.ReplaySpeed == 0 {
+		cfg.ReplaySpeed = 300
+	}
+	if cfg.ReplayWarmupBars == 0 {
+		cfg.ReplayWarmupBars = 50
+	}
+	if cfg.MarketCalendar == "" {
+		cfg.MarketCalendar = "us"
+	}
+	if cfg.MarketTimezone == "" {
+		cfg.MarketTimezone = "America/New_York"
+	}
+	if cfg.MarketPreOpen == "" {
+		cfg.MarketPreOpen = "04:00"
+	}
+	if cfg.MarketOpen == "" {
+		cfg.MarketOpen = "09:30"
+	}
+	if cfg.MarketClose == "" {
+		cfg.MarketClose = "16:00"
+	}
+	if cfg.MarketPostClose == "" {
+		cfg.MarketPostClose = "20:00"
+	}
+	if cfg.MarketStaleAfter == 0 {
+		cfg.MarketStaleAfter = 15 * time.Minute
+	}
+	if cfg.StateBackend == "" {
+		cfg.StateBackend = "redis"
+	}
+	if cfg.StateDir == "" {
+		cfg.StateDir = "./data"
+	}
+	if cfg.StateSnapshotInterval == 0 {
+		cfg.StateSnapshotInterval = time.Minute
+	}
+	if cfg.StateTTL == 0 {
+		cfg.StateTTL = 7 * 24 * time.Hour
+	}
+	if cfg.HistoryTTL == 0 {
+		cfg.HistoryTTL = 7 * 24 * time.Hour
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 5 * time.Minute
+	}
+	if cfg.PollDelay == 0 {
+		cfg.PollDelay = 5 * time.Second
+	}
+	if cfg.PollConcurrency == 0 {
+		cfg.PollConcurrency = 4
+	}
+	if cfg.BatchMaxSymbols == 0 {
+		cfg.BatchMaxSymbols = 100
+	}
+	if cfg.BatchConcurrency == 0 {
+		cfg.BatchConcurrency = 8
+	}
+	if cfg.WebhookWorkers == 0 {
+		cfg.WebhookWorkers = 2
+	}
+	if cfg.WebhookMaxAttempts == 0 {
+		cfg.WebhookMaxAttempts = 5
+	}
+	if cfg.WebhookTimeout == 0 {
+		cfg.WebhookTimeout = 5 * time.Second
+	}
+	if cfg.WebhookQueueSize == 0 {
+		cfg.WebhookQueueSize = 1000
+	}
+	if cfg.StreamHeartbeat == 0 {
+		cfg.StreamHeartbeat = 15 * time.Second
+	}
+	if cfg.WSMaxSubscriptions == 0 {
+		cfg.WSMaxSubscriptions = 500
+	}
+	if cfg.MicroCacheTTL == 0 {
+		cfg.MicroCacheTTL = 5 * time.Second
+	}
+	if cfg.SymbolCacheTTL == 0 {
+		cfg.SymbolCacheTTL = 24 * time.Hour
+	}
+	if cfg.QuoteCacheTTL == 0 {
+		cfg.QuoteCacheTTL = 15 * time.Second
+	}
+	if cfg.SymbolPattern == "" {
+		cfg.SymbolPattern = `^[A-Z0-9^][A-Z0-9.=/_-]{0,19}$`
+	}
 
 	return cfg
 }
@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Error codes clients can branch on. They are stable; messages are not.
+const (
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeInvalidJSON        = "INVALID_JSON"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeInvalidSymbol      = "INVALID_SYMBOL"
+	CodeLimitExceeded      = "LIMIT_EXCEEDED"
+	CodeMissingToken       = "MISSING_TOKEN"
+	CodeInvalidToken       = "INVALID_TOKEN"
+	CodeTokenExpired       = "TOKEN_EXPIRED"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeForbidden          = "FORBIDDEN"
+	CodeSymbolNotAllowed   = "SYMBOL_NOT_ALLOWED"
+	CodeNotFound           = "NOT_FOUND"
+	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	CodeSymbolNotFound     = "SYMBOL_NOT_FOUND"
+	CodeUpstreamRateLimit  = "UPSTREAM_RATE_LIMITED"
+	CodeUpstreamError      = "UPSTREAM_ERROR"
+	CodeUpstreamTimeout    = "UPSTREAM_TIMEOUT"
+	CodeClientClosed       = "CLIENT_CLOSED_REQUEST"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+// StatusClientClosedRequest is nginx's non-standard status for a request
+// the client abandoned before the response was ready. Nobody reads it but
+// the access log.
+const StatusClientClosedRequest = 499
+
+// APIError is the one error type surfaced to clients, rendered as
+// {"error": {...}}. Status is the HTTP status; Details carries structured
+// context such as the invalid fields of a request. RequestID is filled in
+// when the error is rendered.
+type APIError struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func (e APIError) Error() string { return e.Message }
+
+// NewError returns an APIError with the given status, code and message.
+func NewError(status int, code, msg string) APIError {
+	return APIError{Status: status, Code: code, Message: msg}
+}
+
+// WithDetails returns a copy of e carrying details.
+func (e APIError) WithDetails(details any) APIError {
+	e.Details = details
+	return e
+}
+
+func ErrInternal(msg string) error {
+	return NewError(http.StatusInternalServerError, CodeInternal, fmt.Sprintf("internal: %s", msg))
+}
+
+func ErrBadRequest(msg string) error {
+	return NewError(http.StatusBadRequest, CodeBadRequest, msg)
+}
+
+// ErrLimitExceeded reports a request over a per-user or per-request cap.
+func ErrLimitExceeded(msg string) error {
+	return NewError(http.StatusBadRequest, CodeLimitExceeded, msg)
+}
+
+func ErrForbidden(msg string) error {
+	return NewError(http.StatusForbidden, CodeForbidden, msg)
+}
+
+func ErrNotFound(msg string) error {
+	return NewError(http.StatusNotFound, CodeNotFound, msg)
+}
+
+// FieldError describes one invalid request field. Field is the JSON or
+// query parameter name, with a path for nested fields ("symbols[0].tail").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrValidation is a 400 listing every invalid field of a request in its
+// details.
+func ErrValidation(fields []FieldError) error {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	msg := "invalid request: " + strings.Join(parts, "; ")
+	return NewError(http.StatusBadRequest, CodeValidationFailed, msg).WithDetails(fields)
+}
+
+// AsAPIError returns err as an APIError. Errors that are not APIErrors
+// anywhere in their chain are logged and become a generic 500
+// INTERNAL_ERROR, so internals such as store addresses never reach
+// clients.
+func AsAPIError(err error) APIError {
+	var ae APIError
+	if errors.As(err, &ae) {
+		return ae
+	}
+	log.Printf("internal error: %v", err)
+	return NewError(http.StatusInternalServerError, CodeInternal, "internal error")
+}
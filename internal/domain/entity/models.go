@@ -1,28 +1,39 @@
 package entity
 
 import (
-	"fmt"
+	"encoding/json"
 	"time"
 
 	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
 )
 
-type HTTPError struct {
-	StatusCode int
-	Msg        string
+type IntradayRequest struct {
+	Symbol  string   `json:"symbol" validate:"required"`
+	Tail    *int     `json:"tail,omitempty" validate:"omitempty,min=0"`
+	RSILow  *float64 `json:"rsi_low,omitempty" validate:"omitempty,min=0,max=100"`
+	RSIHigh *float64 `json:"rsi_high,omitempty" validate:"omitempty,min=0,max=100"`
 }
 
-func (e HTTPError) Error() string { return e.Msg }
+// BatchIntradayRequest is the POST body of the multi-symbol endpoint.
+// Top-level tail/rsi_low/rsi_high apply to symbols that don't set their own.
+type BatchIntradayRequest struct {
+	Symbols []IntradayRequest `json:"symbols" validate:"required,min=1,dive"`
+	Tail    *int              `json:"tail,omitempty" validate:"omitempty,min=0"`
+	RSILow  *float64          `json:"rsi_low,omitempty" validate:"omitempty,min=0,max=100"`
+	RSIHigh *float64          `json:"rsi_high,omitempty" validate:"omitempty,min=0,max=100"`
+}
 
-func ErrInternal(msg string) error {
-	return HTTPError{StatusCode: 500, Msg: fmt.Sprintf("internal: %s", msg)}
+// BatchResult is one symbol's outcome; Data is nil when Error is set.
+type BatchResult struct {
+	Symbol string            `json:"symbol"`
+	Status int               `json:"status"`
+	Data   *IntradayResponse `json:"data,omitempty"`
+	Error  *APIError         `json:"error,omitempty"`
 }
 
-type IntradayRequest struct {
-	Symbol  string   `json:"symbol" validate:"required"`
-	Tail    *int     `json:"tail,omitempty"`
-	RSILow  *float64 `json:"rsi_low,omitempty"`
-	RSIHigh *float64 `json:"rsi_high,omitempty"`
+type BatchIntradayResponse struct {
+	Results []BatchResult `json:"results"`
 }
 
 type IntradayResponse struct {
@@ -36,6 +47,50 @@ type IntradayResponse struct {
 	WarmupStatus string 	`json:"warmup_status"`
     SeededCandles int    	`json:"seeded_candles"`
 	RSICount   int     		`json:"rsi_count"`
+
+	// MarketStatus is open, pre_market, post_market or closed.
+	MarketStatus string    `json:"market_status"`
+	DataAsOf     time.Time `json:"data_as_of,omitempty"`
+	StalenessSec int64     `json:"staleness_sec"`
+	// Stale is set when the market is trading but no bar arrived within
+	// the configured staleness window.
+	Stale bool `json:"stale"`
+
+	// Indicators holds the state after each of Candles, index for index.
+	// Only CSV/NDJSON exports include it.
+	Indicators []BarIndicators `json:"-"`
+}
+
+// BarIndicators is the RSI state right after a bar. RSICount is 0 for
+// bars before the state covered them (the SMA seed period).
+type BarIndicators struct {
+	RSI          float64 `json:"rsi"`
+	ChangePct    float64 `json:"change_pct"`
+	RSICount     int     `json:"rsi_count"`
+	WarmupStatus string  `json:"warmup_status"`
+}
+
+func IndicatorsFromState(st *rsi.CompactRSI) BarIndicators {
+	return BarIndicators{
+		RSI:          st.RSI,
+		ChangePct:    st.ChangePct,
+		RSICount:     st.Count,
+		WarmupStatus: string(st.WarmupStatus()),
+	}
+}
+
+// HistoryBar is a candle with the indicators after it.
+type HistoryBar struct {
+	Candle
+	BarIndicators
+}
+
+// HistoryResponse is a symbol's upstream history, oldest first, replayed
+// through the same RSI seeding as live state.
+type HistoryResponse struct {
+	Symbol   string       `json:"symbol"`
+	Interval string       `json:"interval"`
+	Bars     []HistoryBar `json:"bars"`
 }
 
 type Candle struct {
@@ -67,3 +122,390 @@ type LoginResponse struct {
 	Token  string `json:"token"`
 	UserID string `json:"user_id"`
 }
+
+// StateEntry is the portable form of one symbol's RSI state used by
+// state export/import.
+type StateEntry struct {
+	Symbol string          `json:"symbol"`
+	State  *rsi.CompactRSI `json:"state"`
+}
+
+type Watchlist struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WatchlistRequest struct {
+	Name    string   `json:"name" validate:"required"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+type WatchlistSymbolRequest struct {
+	Symbol string `json:"symbol" validate:"required"`
+}
+
+type WatchlistOrderRequest struct {
+	Symbols []string `json:"symbols" validate:"required,min=1"`
+}
+
+type WatchlistSnapshot struct {
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Symbols []SymbolSnapshot `json:"symbols"`
+	AsOf    time.Time        `json:"as_of"`
+}
+
+// ScreenerRequest selects and ranks tracked symbols. Filters look like
+// "rsi<30" or "warmup_status=stable"; Sort names a field, prefixed with
+// "-" for descending.
+type ScreenerRequest struct {
+	Filters []string
+	Sort    string
+	Limit   int
+	RSILow  *float64
+	RSIHigh *float64
+}
+
+type ScreenerResponse struct {
+	// Matched counts every symbol that passed the filters, before Limit.
+	Matched int              `json:"matched"`
+	Scanned int              `json:"scanned"`
+	Rows    []SymbolSnapshot `json:"rows"`
+	AsOf    time.Time        `json:"as_of"`
+}
+
+// SymbolSnapshot is one row of a multi-symbol view.
+type SymbolSnapshot struct {
+	Symbol       string    `json:"symbol"`
+	RSI          float64   `json:"rsi"`
+	ChangePct    float64   `json:"change_pct"`
+	LastClose    float64   `json:"last_close"`
+	LastTs       time.Time `json:"last_ts"`
+	Alert        string    `json:"alert,omitempty"`
+	IsValidRSI   bool      `json:"is_valid_rsi"`
+	WarmupStatus string    `json:"warmup_status"`
+	RSICount     int       `json:"rsi_count"`
+	// Error is set, in the uniform error shape, when the symbol could
+	// not be loaded.
+	Error *APIError `json:"error,omitempty"`
+}
+
+// Alert rule types. *_below rules fire when the value crosses down through
+// the threshold, *_above when it crosses up.
+const (
+	RuleRSIBelow    = "rsi_below"
+	RuleRSIAbove    = "rsi_above"
+	RuleChangeBelow = "change_pct_below"
+	RuleChangeAbove = "change_pct_above"
+	RulePriceBelow  = "price_below"
+	RulePriceAbove  = "price_above"
+)
+
+type AlertRule struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"user_id"`
+	Symbol    string  `json:"symbol"`
+	Type      string  `json:"type"`
+	Threshold float64 `json:"threshold"`
+	// Hysteresis is how far back past the threshold the value must move
+	// before the rule re-arms.
+	Hysteresis float64 `json:"hysteresis"`
+	// CooldownSec suppresses repeat fires for this long after a fire.
+	CooldownSec int       `json:"cooldown_sec"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Edge-trigger state, maintained by the engine.
+	Primed      bool      `json:"primed"`
+	Armed       bool      `json:"armed"`
+	LastFiredAt time.Time `json:"last_fired_at,omitempty"`
+	// LastBarTs is the last bar evaluated, so no bar is stepped twice.
+	LastBarTs time.Time `json:"last_bar_ts,omitempty"`
+
+	// SnoozedUntil suppresses notifications until the given time. Fires
+	// are still recorded, marked as snoozed.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+type AlertRuleRequest struct {
+	Symbol      string  `json:"symbol" validate:"required"`
+	Type        string  `json:"type" validate:"required,oneof=rsi_below rsi_above change_pct_below change_pct_above price_below price_above"`
+	Threshold   float64 `json:"threshold"`
+	Hysteresis  float64 `json:"hysteresis" validate:"min=0"`
+	CooldownSec int     `json:"cooldown_sec" validate:"min=0"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+}
+
+// AlertEvent records one firing of a rule.
+type AlertEvent struct {
+	ID        string    `json:"id"`
+	RuleID    string    `json:"rule_id"`
+	UserID    string    `json:"user_id"`
+	Symbol    string    `json:"symbol"`
+	Type      string    `json:"type"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	RSI       float64   `json:"rsi"`
+	ChangePct float64   `json:"change_pct"`
+	Price     float64   `json:"price"`
+	CandleTs  time.Time `json:"candle_ts"`
+	FiredAt   time.Time `json:"fired_at"`
+
+	// Suppressed is "snoozed" or "muted" when the event was recorded but
+	// not delivered.
+	Suppressed     string     `json:"suppressed,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// Reasons an alert event was not delivered.
+const (
+	SuppressedSnoozed = "snoozed"
+	SuppressedMuted   = "muted"
+)
+
+// AlertEventFilter narrows an alert history query. Zero fields match
+// everything; From is inclusive and To exclusive.
+type AlertEventFilter struct {
+	Symbol       string
+	Type         string
+	From         time.Time
+	To           time.Time
+	Acknowledged *bool
+	Limit        int
+}
+
+// AlertMute silences every rule on one symbol for one user.
+type AlertMute struct {
+	Symbol  string    `json:"symbol"`
+	MutedAt time.Time `json:"muted_at"`
+}
+
+type SnoozeRequest struct {
+	// Duration is a Go duration string such as "30m" or "4h".
+	Duration string     `json:"duration,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+type Webhook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+	// Enabled webhooks receive every alert fired for the owning user.
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookRequest struct {
+	URL     string `json:"url" validate:"required,http_url"`
+	Secret  string `json:"secret,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one alert sent (or being sent) to one webhook.
+// Failed deliveries stay in the dead-letter list until replayed.
+type WebhookDelivery struct {
+	ID           string          `json:"id"`
+	UserID       string          `json:"user_id"`
+	WebhookID    string          `json:"webhook_id"`
+	EventID      string          `json:"event_id"`
+	URL          string          `json:"url"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode int             `json:"response_code,omitempty"`
+	LastError    string          `json:"last_error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// WebhookPayload is the JSON body POSTed to receivers.
+type WebhookPayload struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	Data      AlertEvent `json:"data"`
+}
+
+// BacktestRequest describes a long-only RSI strategy: buy when RSI falls
+// to RSILow, sell when it reaches RSIHigh or a stop loss, take profit or
+// holding limit triggers first. Zero StopLossPct, TakeProfitPct and
+// MaxHoldBars disable those exits.
+type BacktestRequest struct {
+	Symbol string `json:"symbol" validate:"required"`
+	// Candles to replay; fetched from the feed when empty.
+	Candles        []Candle `json:"candles,omitempty"`
+	RSILow         *float64 `json:"rsi_low,omitempty" validate:"omitempty,min=0,max=100"`
+	RSIHigh        *float64 `json:"rsi_high,omitempty" validate:"omitempty,min=0,max=100"`
+	StopLossPct    float64  `json:"stop_loss_pct,omitempty" validate:"min=0,lt=100"`
+	TakeProfitPct  float64  `json:"take_profit_pct,omitempty" validate:"min=0"`
+	MaxHoldBars    int      `json:"max_hold_bars,omitempty" validate:"min=0"`
+	InitialCapital float64  `json:"initial_capital,omitempty" validate:"min=0"`
+}
+
+// Backtest exit reasons.
+const (
+	ExitRSI        = "rsi_high"
+	ExitStopLoss   = "stop_loss"
+	ExitTakeProfit = "take_profit"
+	ExitMaxHold    = "max_hold"
+	ExitEndOfData  = "end_of_data"
+)
+
+type BacktestTrade struct {
+	EntryTs    time.Time `json:"entry_ts"`
+	ExitTs     time.Time `json:"exit_ts"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	EntryRSI   float64   `json:"entry_rsi"`
+	ExitRSI    float64   `json:"exit_rsi"`
+	Bars       int       `json:"bars"`
+	ReturnPct  float64   `json:"return_pct"`
+	PnL        float64   `json:"pnl"`
+	ExitReason string    `json:"exit_reason"`
+}
+
+type EquityPoint struct {
+	Timestamp time.Time `json:"ts"`
+	Equity    float64   `json:"equity"`
+}
+
+type BacktestResult struct {
+	Symbol         string    `json:"symbol"`
+	Candles        int       `json:"candles"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	RSILow         float64   `json:"rsi_low"`
+	RSIHigh        float64   `json:"rsi_high"`
+	InitialCapital float64   `json:"initial_capital"`
+	FinalEquity    float64   `json:"final_equity"`
+	TotalReturnPct float64   `json:"total_return_pct"`
+	Wins           int       `json:"wins"`
+	Losses         int       `json:"losses"`
+	WinRate        float64   `json:"win_rate"`
+	MaxDrawdownPct float64   `json:"max_drawdown_pct"`
+	// Sharpe is annualized from per-bar returns with a zero risk-free rate.
+	Sharpe      float64         `json:"sharpe"`
+	Trades      []BacktestTrade `json:"trades"`
+	EquityCurve []EquityPoint   `json:"equity_curve"`
+}
+
+// StreamUpdate is pushed to stream subscribers each time a symbol's state
+// advances. ID orders updates across all symbols for resuming.
+type StreamUpdate struct {
+	ID           uint64    `json:"id"`
+	Symbol       string    `json:"symbol"`
+	Candles      []Candle  `json:"candles"`
+	RSI          float64   `json:"rsi"`
+	ChangePct    float64   `json:"change_pct"`
+	Alert        string    `json:"alert,omitempty"`
+	IsValidRSI   bool      `json:"is_valid_rsi"`
+	WarmupStatus string    `json:"warmup_status"`
+	RSICount     int       `json:"rsi_count"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// WSRequest is a client message on the /ws endpoint.
+type WSRequest struct {
+	// Op is "subscribe", "unsubscribe" or "list".
+	Op string `json:"op"`
+	// ID is echoed in the reply so clients can match it.
+	ID       string   `json:"id,omitempty"`
+	Symbols  []string `json:"symbols,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+	RSILow   *float64 `json:"rsi_low,omitempty" validate:"omitempty,min=0,max=100"`
+	RSIHigh  *float64 `json:"rsi_high,omitempty" validate:"omitempty,min=0,max=100"`
+}
+
+// WebSocket message types sent by the server.
+const (
+	WSTypeSubscribed    = "subscribed"
+	WSTypeUnsubscribed  = "unsubscribed"
+	WSTypeSubscriptions = "subscriptions"
+	WSTypeSnapshot      = "snapshot"
+	WSTypeCandle        = "candle"
+	WSTypeIndicator     = "indicator"
+	WSTypeAlert         = "alert"
+	WSTypeError         = "error"
+)
+
+// WSMessage is a server message on the /ws endpoint. Which payload field
+// is set depends on Type.
+type WSMessage struct {
+	Type     string   `json:"type"`
+	ID       string   `json:"id,omitempty"`
+	Symbol   string   `json:"symbol,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+	Symbols  []string `json:"symbols,omitempty"`
+	// Seq is the stream update ID the message came from.
+	Seq uint64 `json:"seq,omitempty"`
+
+	Snapshot  *IntradayResponse `json:"snapshot,omitempty"`
+	Candle    *Candle           `json:"candle,omitempty"`
+	Indicator *WSIndicator      `json:"indicator,omitempty"`
+	Alert     *WSAlert          `json:"alert,omitempty"`
+	Error     *APIError         `json:"error,omitempty"`
+}
+
+// WSIndicator is a symbol's RSI state after an update. Coalesced counts
+// earlier updates merged into it because the client was reading slowly.
+type WSIndicator struct {
+	RSI          float64   `json:"rsi"`
+	ChangePct    float64   `json:"change_pct"`
+	IsValidRSI   bool      `json:"is_valid_rsi"`
+	WarmupStatus string    `json:"warmup_status"`
+	RSICount     int       `json:"rsi_count"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Coalesced    int       `json:"coalesced,omitempty"`
+}
+
+// WSAlert reports a change of a symbol's threshold alert ("OVERSOLD",
+// "OVERBOUGHT" or "" when cleared).
+type WSAlert struct {
+	Alert    string  `json:"alert"`
+	Previous string  `json:"previous"`
+	RSI      float64 `json:"rsi"`
+}
+
+// SymbolInfo is upstream reference data about a symbol. Search results
+// carry MatchScore; exchange is only known for looked-up symbols.
+type SymbolInfo struct {
+	Symbol     string  `json:"symbol"`
+	Name       string  `json:"name"`
+	Exchange   string  `json:"exchange,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Type       string  `json:"type,omitempty"`
+	Region     string  `json:"region,omitempty"`
+	Timezone   string  `json:"timezone,omitempty"`
+	MatchScore float64 `json:"match_score,omitempty"`
+}
+
+type SymbolSearchResponse struct {
+	Query   string       `json:"query"`
+	Results []SymbolInfo `json:"results"`
+}
+
+// Quote is a symbol's latest trade summary for its trading day, straight
+// from upstream rather than derived from RSI state.
+type Quote struct {
+	Symbol        string    `json:"symbol"`
+	Price         float64   `json:"price"`
+	Open          float64   `json:"open"`
+	High          float64   `json:"high"`
+	Low           float64   `json:"low"`
+	PreviousClose float64   `json:"previous_close"`
+	Change        float64   `json:"change"`
+	ChangePct     float64   `json:"change_pct"`
+	Volume        int64     `json:"volume"`
+	TradingDay    string    `json:"trading_day"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
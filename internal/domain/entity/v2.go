@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+// Response shapes of /v2. Candles are always oldest first and carry the
+// indicators after them as a nested object; the symbol's current
+// indicators are grouped the same way.
+
+// Bar is a candle with the indicators after it.
+type Bar struct {
+	Candle
+	Indicators BarIndicators `json:"indicators"`
+}
+
+// CurrentIndicators is the symbol's latest RSI state.
+type CurrentIndicators struct {
+	BarIndicators
+	IsValidRSI bool `json:"is_valid_rsi"`
+}
+
+type IntradayResponseV2 struct {
+	Symbol        string            `json:"symbol"`
+	Bars          []Bar             `json:"bars"`
+	Indicators    CurrentIndicators `json:"indicators"`
+	Alert         string            `json:"alert,omitempty"`
+	SeededCandles int               `json:"seeded_candles"`
+	LastFetch     time.Time         `json:"last_fetch"`
+	MarketStatus  string            `json:"market_status"`
+	DataAsOf      time.Time         `json:"data_as_of,omitempty"`
+	StalenessSec  int64             `json:"staleness_sec"`
+	Stale         bool              `json:"stale"`
+}
+
+type HistoryResponseV2 struct {
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+	Bars     []Bar  `json:"bars"`
+}
+
+// IntradayV2 converts resp to the /v2 shape. tail, when positive, keeps
+// the newest tail bars.
+func IntradayV2(resp *IntradayResponse, tail *int) *IntradayResponseV2 {
+	bars := make([]Bar, len(resp.Candles))
+	for i, c := range resp.Candles {
+		bars[i].Candle = c
+		if i < len(resp.Indicators) {
+			bars[i].Indicators = resp.Indicators[i]
+		}
+	}
+	return &IntradayResponseV2{
+		Symbol: resp.Symbol,
+		Bars:   sortTailBars(bars, tail),
+		Indicators: CurrentIndicators{
+			BarIndicators: BarIndicators{
+				RSI:          resp.RSI,
+				ChangePct:    resp.ChangePct,
+				RSICount:     resp.RSICount,
+				WarmupStatus: resp.WarmupStatus,
+			},
+			IsValidRSI: resp.IsValidRSI,
+		},
+		Alert:         resp.Alert,
+		SeededCandles: resp.SeededCandles,
+		LastFetch:     resp.LastFetch,
+		MarketStatus:  resp.MarketStatus,
+		DataAsOf:      resp.DataAsOf,
+		StalenessSec:  resp.StalenessSec,
+		Stale:         resp.Stale,
+	}
+}
+
+// HistoryV2 converts resp to the /v2 shape.
+func HistoryV2(resp *HistoryResponse) *HistoryResponseV2 {
+	bars := make([]Bar, len(resp.Bars))
+	for i, b := range resp.Bars {
+		bars[i] = Bar{Candle: b.Candle, Indicators: b.BarIndicators}
+	}
+	return &HistoryResponseV2{Symbol: resp.Symbol, Interval: resp.Interval, Bars: sortTailBars(bars, nil)}
+}
+
+func sortTailBars(bars []Bar, tail *int) []Bar {
+	sort.SliceStable(bars, func(i, j int) bool {
+		return bars[i].Timestamp.Before(bars[j].Timestamp)
+	})
+	if tail != nil && *tail > 0 && *tail < len(bars) {
+		bars = bars[len(bars)-*tail:]
+	}
+	return bars
+}
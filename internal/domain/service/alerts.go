@@ -0,0 +1,678 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	maxAlertRules  = 100
+	maxAlertEvents = 1000
+
+	// pruneEvery is how many writes to an append-only log go between
+	// trims, which each read the whole hash.
+	pruneEvery = 50
+)
+
+// AlertService stores per-user alert rules and evaluates them on every
+// state update. Rules are edge triggered: a rule fires once when its value
+// crosses the threshold, then re-arms only after the value moves back past
+// threshold ± hysteresis, and never fires twice within its cooldown.
+//
+// Rules live in a per-symbol hash (so evaluation is one read per update),
+// with a per-user index mapping rule ID to symbol. Fired events are kept
+// per user and trimmed to the newest maxAlertEvents.
+type AlertService struct {
+	store   HashStore
+	symbols *SymbolPolicy
+
+	// Serializes read-modify-write cycles: per symbol for rule state,
+	// shared by evaluation and the API, and per user for the rule index,
+	// mutes and event log. Lock a user's rule index before a symbol, and a
+	// symbol before a user's event log.
+	locks  keyedMutex
+	events pruner
+
+	now Clock
+
+	fireListeners []FireListener
+}
+
+// FireListener is called for every recorded alert event.
+type FireListener func(ctx context.Context, ev entity.AlertEvent)
+
+func NewAlertService(store HashStore, symbols *SymbolPolicy) *AlertService {
+	return &AlertService{store: store, symbols: symbols, now: time.Now}
+}
+
+// UseClock sets the clock for cooldowns and event timestamps. Snoozes
+// stay on wall time, since users set them in wall time.
+func (s *AlertService) UseClock(now Clock) {
+	s.now = now
+}
+
+func symbolRulesKey(symbol string) string {
+	return "alerts:{" + symbol + "}:rules"
+}
+
+func userRulesKey(userID string) string {
+	return "user:{" + userID + "}:alert_rules"
+}
+
+func userEventsKey(userID string) string {
+	return "user:{" + userID + "}:alert_events"
+}
+
+func userMutesKey(userID string) string {
+	return "user:{" + userID + "}:alert_mutes"
+}
+
+func (s *AlertService) CreateRule(ctx context.Context, userID string, req entity.AlertRuleRequest) (*entity.AlertRule, error) {
+	sym, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRule(req); err != nil {
+		return nil, err
+	}
+
+	defer s.locks.lock(userRulesKey(userID))()
+
+	index, err := s.store.HGetAll(ctx, userRulesKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load rules: %w", err)
+	}
+	if len(index) >= maxAlertRules {
+		return nil, entity.ErrLimitExceeded(fmt.Sprintf("at most %d alert rules per user", maxAlertRules))
+	}
+
+	rule := &entity.AlertRule{
+		ID:          newID(),
+		UserID:      userID,
+		Symbol:      sym,
+		Type:        req.Type,
+		Threshold:   req.Threshold,
+		Hysteresis:  req.Hysteresis,
+		CooldownSec: req.CooldownSec,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.putRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.store.HSet(ctx, userRulesKey(userID), rule.ID, []byte(sym)); err != nil {
+		return nil, fmt.Errorf("index rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListRules returns the user's rules ordered by creation time.
+func (s *AlertService) ListRules(ctx context.Context, userID string) ([]entity.AlertRule, error) {
+	index, err := s.store.HGetAll(ctx, userRulesKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load rules: %w", err)
+	}
+
+	out := make([]entity.AlertRule, 0, len(index))
+	for id, sym := range index {
+		rule, err := s.getRule(ctx, userID, string(sym), id)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			out = append(out, *rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// SetRuleEnabled enables or disables a rule. Re-enabling re-primes it, so
+// a condition that is already true does not fire immediately.
+func (s *AlertService) SetRuleEnabled(ctx context.Context, userID, id string, enabled bool) (*entity.AlertRule, error) {
+	rule, unlock, err := s.lockRule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if enabled && !rule.Enabled {
+		rule.Primed = false
+	}
+	rule.Enabled = enabled
+	if err := s.putRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *AlertService) DeleteRule(ctx context.Context, userID, id string) error {
+	defer s.locks.lock(userRulesKey(userID))()
+	rule, unlock, err := s.lockRule(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.store.HDel(ctx, symbolRulesKey(rule.Symbol), ruleField(userID, id)); err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+	return s.store.HDel(ctx, userRulesKey(userID), id)
+}
+
+// Events returns the user's fired alerts matching f, newest first.
+func (s *AlertService) Events(ctx context.Context, userID string, f entity.AlertEventFilter) ([]entity.AlertEvent, error) {
+	data, err := s.store.HGetAll(ctx, userEventsKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load events: %w", err)
+	}
+
+	out := make([]entity.AlertEvent, 0, len(data))
+	for id, b := range data {
+		var ev entity.AlertEvent
+		if err := json.Unmarshal(b, &ev); err != nil {
+			return nil, fmt.Errorf("decode event %s: %w", id, err)
+		}
+		if matchEvent(&ev, f) {
+			out = append(out, ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
+func matchEvent(ev *entity.AlertEvent, f entity.AlertEventFilter) bool {
+	switch {
+	case f.Symbol != "" && ev.Symbol != f.Symbol:
+		return false
+	case f.Type != "" && ev.Type != f.Type:
+		return false
+	case !f.From.IsZero() && ev.FiredAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !ev.FiredAt.Before(f.To):
+		return false
+	case f.Acknowledged != nil && (ev.AcknowledgedAt != nil) != *f.Acknowledged:
+		return false
+	}
+	return true
+}
+
+// Acknowledge marks an event as seen. Acknowledging twice keeps the first
+// acknowledgement time.
+func (s *AlertService) Acknowledge(ctx context.Context, userID, id string) (*entity.AlertEvent, error) {
+	key := userEventsKey(userID)
+	defer s.locks.lock(key)()
+
+	b, ok, err := s.store.HGet(ctx, key, id)
+	if err != nil {
+		return nil, fmt.Errorf("load event: %w", err)
+	}
+	if !ok {
+		return nil, entity.ErrNotFound("alert event not found")
+	}
+	var ev entity.AlertEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return nil, fmt.Errorf("decode event %s: %w", id, err)
+	}
+	if ev.AcknowledgedAt != nil {
+		return &ev, nil
+	}
+
+	now := time.Now().UTC()
+	ev.AcknowledgedAt = &now
+	if b, err = json.Marshal(ev); err != nil {
+		return nil, fmt.Errorf("encode event: %w", err)
+	}
+	if err := s.store.HSet(ctx, key, id, b); err != nil {
+		return nil, fmt.Errorf("save event: %w", err)
+	}
+	return &ev, nil
+}
+
+// Snooze suppresses notifications from a rule until until; a nil until
+// ends the snooze. The rule keeps tracking crossings while snoozed, so it
+// doesn't fire a stale edge when the snooze ends.
+func (s *AlertService) Snooze(ctx context.Context, userID, id string, until *time.Time) (*entity.AlertRule, error) {
+	if until != nil && !until.After(time.Now()) {
+		return nil, entity.ErrBadRequest("snooze must end in the future")
+	}
+
+	rule, unlock, err := s.lockRule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if until != nil {
+		t := until.UTC()
+		until = &t
+	}
+	rule.SnoozedUntil = until
+	if err := s.putRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Mute suppresses notifications from all of the user's rules on symbol
+// until Unmute.
+func (s *AlertService) Mute(ctx context.Context, userID, symbol string) (*entity.AlertMute, error) {
+	sym, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	key := userMutesKey(userID)
+	defer s.locks.lock(key)()
+
+	b, ok, err := s.store.HGet(ctx, key, sym)
+	if err != nil {
+		return nil, fmt.Errorf("load mute: %w", err)
+	}
+	var m entity.AlertMute
+	if ok {
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("decode mute %s: %w", sym, err)
+		}
+		return &m, nil
+	}
+
+	m = entity.AlertMute{Symbol: sym, MutedAt: time.Now().UTC()}
+	if b, err = json.Marshal(m); err != nil {
+		return nil, fmt.Errorf("encode mute: %w", err)
+	}
+	if err := s.store.HSet(ctx, key, sym, b); err != nil {
+		return nil, fmt.Errorf("save mute: %w", err)
+	}
+	return &m, nil
+}
+
+func (s *AlertService) Unmute(ctx context.Context, userID, symbol string) error {
+	sym := strings.ToUpper(strings.TrimSpace(symbol))
+	key := userMutesKey(userID)
+	defer s.locks.lock(key)()
+
+	_, ok, err := s.store.HGet(ctx, key, sym)
+	if err != nil {
+		return fmt.Errorf("load mute: %w", err)
+	}
+	if !ok {
+		return entity.ErrNotFound(fmt.Sprintf("symbol %s is not muted", sym))
+	}
+	return s.store.HDel(ctx, key, sym)
+}
+
+// Mutes returns the user's muted symbols in alphabetical order.
+func (s *AlertService) Mutes(ctx context.Context, userID string) ([]entity.AlertMute, error) {
+	data, err := s.store.HGetAll(ctx, userMutesKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load mutes: %w", err)
+	}
+	out := make([]entity.AlertMute, 0, len(data))
+	for sym, b := range data {
+		var m entity.AlertMute
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("decode mute %s: %w", sym, err)
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+// OnFire registers fn to run after each alert event is stored. Register
+// listeners before serving.
+func (s *AlertService) OnFire(fn FireListener) {
+	s.fireListeners = append(s.fireListeners, fn)
+}
+
+// Evaluate steps every enabled rule on symbol through each bar just
+// applied, so a crossing on an intermediate bar is not missed, and records
+// an event for each crossing. Bars a rule has already seen are skipped,
+// and a rule that has seen none starts at the latest bar, so seeding does
+// not replay history into alerts. Events from snoozed rules or muted
+// symbols are recorded but not passed to fire listeners. It matches
+// UpdateListener so it can be registered with IntradayService.OnUpdate.
+func (s *AlertService) Evaluate(ctx context.Context, symbol string, st *rsi.CompactRSI, candles []entity.Candle, indicators []entity.BarIndicators) {
+	fired, err := s.evaluate(ctx, symbol, alertBars(st, candles, indicators), s.now().UTC())
+	if err != nil {
+		fmt.Printf("alert evaluation failed for %s: %v\n", symbol, err)
+	}
+	for _, ev := range fired {
+		if ev.Suppressed != "" {
+			continue
+		}
+		for _, fn := range s.fireListeners {
+			fn(ctx, ev)
+		}
+	}
+}
+
+// alertBar is what rules watch, as of one bar.
+type alertBar struct {
+	ts          time.Time
+	rsi         float64
+	rsiValid    bool
+	changePct   float64
+	changeValid bool
+	price       float64
+}
+
+// alertBars pairs candles (chronological) with the indicators after
+// them. Without them the state is the only bar.
+func alertBars(st *rsi.CompactRSI, candles []entity.Candle, indicators []entity.BarIndicators) []alertBar {
+	if len(candles) == 0 || len(indicators) != len(candles) {
+		return []alertBar{{
+			ts:          st.LastTs,
+			rsi:         st.RSI,
+			rsiValid:    st.IsValid(),
+			changePct:   st.ChangePct,
+			changeValid: st.PrevClose != 0,
+			price:       st.LastClose,
+		}}
+	}
+	bars := make([]alertBar, len(candles))
+	for i, c := range candles {
+		ind := indicators[i]
+		bars[i] = alertBar{
+			ts:          c.Timestamp,
+			rsi:         ind.RSI,
+			rsiValid:    ind.WarmupStatus != string(rsi.Processing),
+			changePct:   ind.ChangePct,
+			changeValid: ind.RSICount > 1,
+			price:       c.Close,
+		}
+	}
+	return bars
+}
+
+func (s *AlertService) evaluate(ctx context.Context, symbol string, bars []alertBar, now time.Time) ([]entity.AlertEvent, error) {
+	defer s.locks.lock(symbolRulesKey(symbol))()
+
+	data, err := s.store.HGetAll(ctx, symbolRulesKey(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("load rules: %w", err)
+	}
+
+	latest := bars[len(bars)-1]
+	var fired []entity.AlertEvent
+	for field, b := range data {
+		var rule entity.AlertRule
+		if err := json.Unmarshal(b, &rule); err != nil {
+			return fired, fmt.Errorf("decode rule %s: %w", field, err)
+		}
+		if !rule.Enabled {
+			continue
+		}
+
+		changed := false
+		if rule.SnoozedUntil != nil && !time.Now().Before(*rule.SnoozedUntil) {
+			rule.SnoozedUntil = nil
+			changed = true
+		}
+		for _, bar := range bars {
+			if !bar.ts.After(rule.LastBarTs) {
+				continue // already seen
+			}
+			if rule.LastBarTs.IsZero() && bar.ts.Before(latest.ts) {
+				continue // first evaluation starts at the latest bar
+			}
+			value, ok := ruleValue(rule.Type, bar)
+			if !ok {
+				continue
+			}
+			// Earlier bars sit as far before now as before the latest
+			// bar, so cooldowns span bars as they did in feed time
+			ev, stepped := step(&rule, value, now.Add(bar.ts.Sub(latest.ts)))
+			rule.LastBarTs = bar.ts
+			changed = changed || stepped
+			if !ev {
+				continue
+			}
+			event := entity.AlertEvent{
+				// Wall-clock ID: pruning keeps the newest IDs, and a
+				// restarted replay's clock starts over
+				ID:        timeID(time.Now()),
+				RuleID:    rule.ID,
+				UserID:    rule.UserID,
+				Symbol:    symbol,
+				Type:      rule.Type,
+				Threshold: rule.Threshold,
+				Value:     value,
+				RSI:       bar.rsi,
+				ChangePct: bar.changePct,
+				Price:     bar.price,
+				CandleTs:  bar.ts,
+				FiredAt:   now,
+			}
+			if rule.SnoozedUntil != nil {
+				event.Suppressed = entity.SuppressedSnoozed
+			} else if _, muted, err := s.store.HGet(ctx, userMutesKey(rule.UserID), symbol); err != nil {
+				return fired, fmt.Errorf("load mute: %w", err)
+			} else if muted {
+				event.Suppressed = entity.SuppressedMuted
+			}
+			if err := s.recordEvent(ctx, event); err != nil {
+				return fired, err
+			}
+			fired = append(fired, event)
+		}
+		// LastBarTs alone is not worth a write: later bars are newer anyway
+		if changed {
+			if err := s.putRule(ctx, &rule); err != nil {
+				return fired, err
+			}
+		}
+	}
+	return fired, nil
+}
+
+// step advances a rule's edge-trigger state for value. It reports whether
+// the rule fires and whether its stored state changed.
+func step(rule *entity.AlertRule, value float64, now time.Time) (fire, changed bool) {
+	below := isBelowRule(rule.Type)
+	crossed := value >= rule.Threshold
+	rearm := value <= rule.Threshold-rule.Hysteresis
+	if below {
+		crossed = value <= rule.Threshold
+		rearm = value >= rule.Threshold+rule.Hysteresis
+	}
+
+	// First observation only establishes the baseline: a condition that is
+	// already true when the rule is created is not a crossing.
+	if !rule.Primed {
+		rule.Primed = true
+		rule.Armed = !crossed
+		return false, true
+	}
+
+	switch {
+	case rule.Armed && crossed:
+		rule.Armed = false
+		cooldown := time.Duration(rule.CooldownSec) * time.Second
+		if !rule.LastFiredAt.IsZero() && now.Sub(rule.LastFiredAt) < cooldown {
+			// Crossing consumed silently inside the cooldown window
+			return false, true
+		}
+		rule.LastFiredAt = now
+		return true, true
+	case !rule.Armed && rearm && !crossed:
+		rule.Armed = true
+		return false, true
+	}
+	return false, false
+}
+
+// ruleValue extracts the value a rule type watches. RSI rules are skipped
+// until the RSI is valid.
+func ruleValue(ruleType string, bar alertBar) (float64, bool) {
+	switch ruleType {
+	case entity.RuleRSIBelow, entity.RuleRSIAbove:
+		return bar.rsi, bar.rsiValid
+	case entity.RuleChangeBelow, entity.RuleChangeAbove:
+		return bar.changePct, bar.changeValid
+	case entity.RulePriceBelow, entity.RulePriceAbove:
+		return bar.price, bar.price != 0
+	}
+	return 0, false
+}
+
+func isBelowRule(ruleType string) bool {
+	switch ruleType {
+	case entity.RuleRSIBelow, entity.RuleChangeBelow, entity.RulePriceBelow:
+		return true
+	}
+	return false
+}
+
+func validateRule(req entity.AlertRuleRequest) error {
+	switch req.Type {
+	case entity.RuleRSIBelow, entity.RuleRSIAbove:
+		if req.Threshold < 0 || req.Threshold > 100 {
+			return entity.ErrBadRequest("rsi threshold must be between 0 and 100")
+		}
+	case entity.RuleChangeBelow, entity.RuleChangeAbove:
+	case entity.RulePriceBelow, entity.RulePriceAbove:
+		if req.Threshold <= 0 {
+			return entity.ErrBadRequest("price threshold must be positive")
+		}
+	default:
+		return entity.ErrBadRequest(fmt.Sprintf("unknown rule type %q", req.Type))
+	}
+	if req.Hysteresis < 0 {
+		return entity.ErrBadRequest("hysteresis must not be negative")
+	}
+	if req.CooldownSec < 0 {
+		return entity.ErrBadRequest("cooldown_sec must not be negative")
+	}
+	return nil
+}
+
+// ruleField is the field of a rule in its symbol hash; rule IDs are only
+// unique per user.
+func ruleField(userID, id string) string {
+	return userID + "/" + id
+}
+
+// lockRule locks the symbol of the user's rule id and returns the rule
+// as read under that lock. The caller must call unlock.
+func (s *AlertService) lockRule(ctx context.Context, userID, id string) (rule *entity.AlertRule, unlock func(), err error) {
+	sym, ok, err := s.store.HGet(ctx, userRulesKey(userID), id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load rule: %w", err)
+	}
+	if !ok {
+		return nil, nil, entity.ErrNotFound("alert rule not found")
+	}
+	unlock = s.locks.lock(symbolRulesKey(string(sym)))
+	rule, err = s.getRule(ctx, userID, string(sym), id)
+	if err == nil && rule == nil {
+		err = entity.ErrNotFound("alert rule not found")
+	}
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return rule, unlock, nil
+}
+
+// getRule returns nil without error when the rule does not exist.
+func (s *AlertService) getRule(ctx context.Context, userID, symbol, id string) (*entity.AlertRule, error) {
+	b, ok, err := s.store.HGet(ctx, symbolRulesKey(symbol), ruleField(userID, id))
+	if err != nil {
+		return nil, fmt.Errorf("load rule: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	var rule entity.AlertRule
+	if err := json.Unmarshal(b, &rule); err != nil {
+		return nil, fmt.Errorf("decode rule %s: %w", id, err)
+	}
+	return &rule, nil
+}
+
+func (s *AlertService) putRule(ctx context.Context, rule *entity.AlertRule) error {
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("encode rule: %w", err)
+	}
+	if err := s.store.HSet(ctx, symbolRulesKey(rule.Symbol), ruleField(rule.UserID, rule.ID), b); err != nil {
+		return fmt.Errorf("save rule: %w", err)
+	}
+	return nil
+}
+
+func (s *AlertService) recordEvent(ctx context.Context, ev entity.AlertEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	key := userEventsKey(ev.UserID)
+	// Same lock as Acknowledge, so a trim never races an acknowledgement
+	// back into a pruned event
+	defer s.locks.lock(key)()
+	if err := s.store.HSet(ctx, key, ev.ID, b); err != nil {
+		return fmt.Errorf("save event: %w", err)
+	}
+	return s.events.wrote(ctx, s.store, key, maxAlertEvents)
+}
+
+// pruner trims append-only hashes to their newest entries every
+// pruneEvery writes per key, so between trims a hash may hold up to
+// pruneEvery-1 entries over its limit. Counts are per process.
+type pruner struct {
+	writes sync.Map // key -> *atomic.Int64
+}
+
+// wrote counts a write to key and trims it to keep when due.
+func (p *pruner) wrote(ctx context.Context, store HashStore, key string, keep int) error {
+	v, _ := p.writes.LoadOrStore(key, new(atomic.Int64))
+	if v.(*atomic.Int64).Add(1)%pruneEvery != 0 {
+		return nil
+	}
+	return pruneOldest(ctx, store, key, keep)
+}
+
+// pruneOldest trims a hash whose fields are timeIDs to its newest keep
+// fields.
+func pruneOldest(ctx context.Context, store HashStore, key string, keep int) error {
+	all, err := store.HGetAll(ctx, key)
+	if err != nil || len(all) <= keep {
+		return err
+	}
+	ids := make([]string, 0, len(all))
+	for id := range all {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return store.HDel(ctx, key, ids[:len(ids)-keep]...)
+}
+
+// timeID is a unique ID that sorts chronologically as a string.
+func timeID(at time.Time) string {
+	return fmt.Sprintf("%016x%s", at.UnixNano(), newID()[:8])
+}
+
+// keyedMutex is a set of mutexes by key, so unrelated keys don't
+// contend. Mutexes are never freed; there is one per symbol and user.
+type keyedMutex struct {
+	m sync.Map
+}
+
+// lock locks key and returns its unlock.
+func (k *keyedMutex) lock(key string) (unlock func()) {
+	v, _ := k.m.LoadOrStore(key, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
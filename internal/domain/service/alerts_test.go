@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+var alertT0 = time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+
+func newTestAlertService(t *testing.T, store HashStore) *AlertService {
+	t.Helper()
+	policy, err := NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewAlertService(store, policy)
+	s.UseClock(func() time.Time { return alertT0.Add(time.Hour) })
+	return s
+}
+
+func addRule(t *testing.T, s *AlertService, ruleType string, threshold float64, cooldownSec int) *entity.AlertRule {
+	t.Helper()
+	rule, err := s.CreateRule(context.Background(), "u1", entity.AlertRuleRequest{
+		Symbol: "IBM", Type: ruleType, Threshold: threshold, CooldownSec: cooldownSec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
+
+// update feeds bars with the given RSIs, 5 minutes apart starting at
+// bar index first, to Evaluate.
+func update(s *AlertService, first int, rsis ...float64) {
+	candles := make([]entity.Candle, len(rsis))
+	indicators := make([]entity.BarIndicators, len(rsis))
+	for i, v := range rsis {
+		candles[i] = entity.Candle{Timestamp: alertT0.Add(time.Duration(first+i) * 5 * time.Minute), Close: 100}
+		indicators[i] = entity.BarIndicators{RSI: v, RSICount: 20 + first + i, WarmupStatus: string(rsi.Warming)}
+	}
+	last := len(rsis) - 1
+	st := &rsi.CompactRSI{RSI: rsis[last], Count: 20 + first + last, LastTs: candles[last].Timestamp, LastClose: 100}
+	s.Evaluate(context.Background(), "IBM", st, candles, indicators)
+}
+
+func events(t *testing.T, s *AlertService) []entity.AlertEvent {
+	t.Helper()
+	evs, err := s.Events(context.Background(), "u1", entity.AlertEventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return evs
+}
+
+func TestEvaluateIntermediateBar(t *testing.T) {
+	s := newTestAlertService(t, newMemHashStore())
+	addRule(t, s, entity.RuleRSIAbove, 70, 0)
+
+	update(s, 0, 50) // primes the rule
+	// Crosses 70 on the middle bar and falls back before the batch ends
+	update(s, 1, 60, 75, 65)
+
+	evs := events(t, s)
+	if len(evs) != 1 {
+		t.Fatalf("events = %d, want 1", len(evs))
+	}
+	if want := alertT0.Add(10 * time.Minute); !evs[0].CandleTs.Equal(want) || evs[0].RSI != 75 {
+		t.Errorf("event at %v rsi %v, want %v rsi 75", evs[0].CandleTs, evs[0].RSI, want)
+	}
+}
+
+func TestEvaluateNewRuleStartsAtLatestBar(t *testing.T) {
+	s := newTestAlertService(t, newMemHashStore())
+	addRule(t, s, entity.RuleRSIAbove, 70, 0)
+
+	// A seed: history crosses 70 twice, but the rule has seen none of it
+	update(s, 0, 50, 80, 50, 80, 60)
+	if evs := events(t, s); len(evs) != 0 {
+		t.Fatalf("events = %d, want 0", len(evs))
+	}
+	// Bars already seen are not stepped again
+	update(s, 4, 60)
+	update(s, 5, 75)
+	if evs := events(t, s); len(evs) != 1 {
+		t.Fatalf("events = %d, want 1", len(evs))
+	}
+}
+
+func TestEvaluateCooldownAcrossBars(t *testing.T) {
+	cases := []struct {
+		cooldownSec int
+		want        int
+	}{
+		{0, 2},
+		{5 * 60, 2},  // crossings are 10 minutes apart
+		{15 * 60, 1}, // second crossing is inside the cooldown
+	}
+	for _, c := range cases {
+		s := newTestAlertService(t, newMemHashStore())
+		addRule(t, s, entity.RuleRSIAbove, 70, c.cooldownSec)
+		update(s, 0, 50)
+		update(s, 1, 75, 50, 75)
+		if evs := events(t, s); len(evs) != c.want {
+			t.Errorf("cooldown %ds: events = %d, want %d", c.cooldownSec, len(evs), c.want)
+		}
+	}
+}
+
+func TestEvaluateUsesClock(t *testing.T) {
+	s := newTestAlertService(t, newMemHashStore())
+	addRule(t, s, entity.RuleRSIBelow, 30, 0)
+	update(s, 0, 50)
+	update(s, 1, 20)
+
+	evs := events(t, s)
+	if len(evs) != 1 || !evs[0].FiredAt.Equal(alertT0.Add(time.Hour)) {
+		t.Fatalf("events = %+v, want one fired at the service clock", evs)
+	}
+}
+
+// blockingStore blocks reads of one key until released.
+type blockingStore struct {
+	*memHashStore
+	key     string
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStore) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	if key == b.key {
+		close(b.entered)
+		<-b.release
+	}
+	return b.memHashStore.HGetAll(ctx, key)
+}
+
+func TestEvaluateLocksPerSymbol(t *testing.T) {
+	store := &blockingStore{
+		memHashStore: newMemHashStore(),
+		key:          symbolRulesKey("AAPL"),
+		entered:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	s := newTestAlertService(t, store)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Evaluate(context.Background(), "AAPL", &rsi.CompactRSI{LastTs: alertT0}, nil, nil)
+	}()
+	<-store.entered
+
+	// AAPL's evaluation is stuck in store I/O; IBM must not wait for it
+	done := make(chan struct{})
+	go func() {
+		addRule(t, s, entity.RuleRSIAbove, 70, 0)
+		update(s, 0, 50)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("IBM evaluation blocked behind AAPL")
+	}
+	close(store.release)
+	wg.Wait()
+}
+
+// scanCounter counts full hash reads.
+type scanCounter struct {
+	*memHashStore
+	scans int
+}
+
+func (c *scanCounter) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	c.scans++
+	return c.memHashStore.HGetAll(ctx, key)
+}
+
+func TestRecordEventPrunesPeriodically(t *testing.T) {
+	store := &scanCounter{memHashStore: newMemHashStore()}
+	s := newTestAlertService(t, store)
+	ctx := context.Background()
+
+	start := time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC)
+	total := maxAlertEvents + 2*pruneEvery + 7
+	var newest string
+	for i := 0; i < total; i++ {
+		newest = timeID(start.Add(time.Duration(i) * time.Second))
+		if err := s.recordEvent(ctx, entity.AlertEvent{ID: newest, UserID: "u1", Symbol: "IBM"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := total / pruneEvery; store.scans != want {
+		t.Errorf("%d full reads for %d events, want %d", store.scans, total, want)
+	}
+	evs := events(t, s)
+	if len(evs) > maxAlertEvents+pruneEvery-1 {
+		t.Errorf("%d events kept, want at most %d", len(evs), maxAlertEvents+pruneEvery-1)
+	}
+	if evs[0].ID != newest {
+		t.Errorf("newest event %s was pruned", newest)
+	}
+}
+
+// slowGets widens the window between a read and the write after it,
+// and counts writes.
+type slowGets struct {
+	*memHashStore
+	sets atomic.Int32
+}
+
+func (s *slowGets) HGet(ctx context.Context, key, field string) ([]byte, bool, error) {
+	b, ok, err := s.memHashStore.HGet(ctx, key, field)
+	time.Sleep(5 * time.Millisecond)
+	return b, ok, err
+}
+
+func (s *slowGets) HSet(ctx context.Context, key, field string, value []byte) error {
+	s.sets.Add(1)
+	return s.memHashStore.HSet(ctx, key, field, value)
+}
+
+func TestMuteConcurrent(t *testing.T) {
+	store := &slowGets{memHashStore: newMemHashStore()}
+	s := newTestAlertService(t, store)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Mute(ctx, "u1", "ibm"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := store.sets.Load(); n != 1 {
+		t.Errorf("mute written %d times, want once", n)
+	}
+
+	// Only one of two concurrent unmutes finds the mute
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- s.Unmute(ctx, "u1", "IBM") }()
+	}
+	if a, b := <-errs, <-errs; (a == nil) == (b == nil) {
+		t.Errorf("unmute errors %v and %v, want exactly one not found", a, b)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	defaultBacktestCapital = 10000.0
+	maxBacktestCandles     = 100000
+	// minBacktestCandles is the warmup window: the SMA seed needs 15
+	// closes and RSI is first computed on the bar after.
+	minBacktestCandles = 16
+
+	// Sharpe annualization assumes US equity sessions.
+	tradingDaysPerYear = 252
+	sessionLength      = 6*time.Hour + 30*time.Minute
+)
+
+// BacktestService replays historical candles through CompactRSI and
+// simulates a long-only threshold strategy on them.
+type BacktestService struct {
+	feedCli CandleFeed
+	symbols *SymbolPolicy
+}
+
+func NewBacktestService(feedCli CandleFeed, symbols *SymbolPolicy) *BacktestService {
+	return &BacktestService{feedCli: feedCli, symbols: symbols}
+}
+
+// Run backtests req against its own candles, or the feed's full history
+// for the symbol when none are given.
+func (s *BacktestService) Run(ctx context.Context, req entity.BacktestRequest) (*entity.BacktestResult, error) {
+	symbol, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	req.Symbol = symbol
+
+	candles := req.Candles
+	if len(candles) == 0 {
+		if s.feedCli == nil {
+			return nil, fmt.Errorf("feedCli is nil")
+		}
+		history, err := s.feedCli.FetchIntraday(ctx, symbol, time.Time{})
+		if err != nil {
+			return nil, upstreamError(symbol, err)
+		}
+		candles = make([]entity.Candle, 0, len(history))
+		for i := range history {
+			candles = append(candles, *entity.CandleFromFeed(&history[i]))
+		}
+	}
+	return RunBacktest(req, candles)
+}
+
+// RunBacktest simulates req over candles (any order). Positions are all-in
+// at the close of the entry bar. Stops and targets are checked against
+// each bar's low and high, stop first, and fill at the trigger price or
+// the open if the bar gapped through it; RSI and holding exits fill at the
+// close. A position still open at the end is closed at the last close.
+func RunBacktest(req entity.BacktestRequest, candles []entity.Candle) (*entity.BacktestResult, error) {
+	low, high := 30.0, 70.0
+	if req.RSILow != nil {
+		low = *req.RSILow
+	}
+	if req.RSIHigh != nil {
+		high = *req.RSIHigh
+	}
+	capital := req.InitialCapital
+	if capital == 0 {
+		capital = defaultBacktestCapital
+	}
+	if err := validateBacktest(req, low, high, capital, len(candles)); err != nil {
+		return nil, err
+	}
+
+	bars := append([]entity.Candle(nil), candles...)
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+
+	res := &entity.BacktestResult{
+		Symbol:         req.Symbol,
+		Candles:        len(bars),
+		From:           bars[0].Timestamp,
+		To:             bars[len(bars)-1].Timestamp,
+		RSILow:         low,
+		RSIHigh:        high,
+		InitialCapital: capital,
+		Trades:         []entity.BacktestTrade{},
+		EquityCurve:    make([]entity.EquityPoint, 0, len(bars)),
+	}
+
+	var (
+		state  rsi.CompactRSI
+		cash   = capital
+		shares float64
+		open   *entity.BacktestTrade
+	)
+	closeTrade := func(c entity.Candle, price float64, reason string) {
+		cash = shares * price
+		open.ExitTs = c.Timestamp
+		open.ExitPrice = price
+		open.ExitRSI = state.RSI
+		open.ExitReason = reason
+		open.ReturnPct = (price/open.EntryPrice - 1) * 100
+		open.PnL = shares * (price - open.EntryPrice)
+		res.Trades = append(res.Trades, *open)
+		shares, open = 0, nil
+	}
+
+	feedBars := make([]feed.Candle, len(bars))
+	for i, c := range bars {
+		feedBars[i] = feed.Candle{Timestamp: c.Timestamp, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+	}
+
+	for i, c := range bars {
+		// Same warmup as live state: SMA seed over the first
+		// minBacktestCandles bars, then Wilder smoothing per bar.
+		switch {
+		case i == minBacktestCandles-1:
+			state.SeedFromHistory(feedBars[:minBacktestCandles])
+		case i >= minBacktestCandles:
+			state.UpdateIncremental(feedBars[i])
+		}
+
+		exited := false
+		if open != nil {
+			open.Bars++
+			stop := open.EntryPrice * (1 - req.StopLossPct/100)
+			target := open.EntryPrice * (1 + req.TakeProfitPct/100)
+			switch {
+			case req.StopLossPct > 0 && c.Low <= stop:
+				closeTrade(c, math.Min(c.Open, stop), entity.ExitStopLoss)
+			case req.TakeProfitPct > 0 && c.High >= target:
+				closeTrade(c, math.Max(c.Open, target), entity.ExitTakeProfit)
+			case state.IsValid() && state.RSI >= high:
+				closeTrade(c, c.Close, entity.ExitRSI)
+			case req.MaxHoldBars > 0 && open.Bars >= req.MaxHoldBars:
+				closeTrade(c, c.Close, entity.ExitMaxHold)
+			case i == len(bars)-1:
+				closeTrade(c, c.Close, entity.ExitEndOfData)
+			}
+			exited = open == nil
+		}
+
+		if open == nil && !exited && i < len(bars)-1 && state.IsValid() && state.RSI <= low && c.Close > 0 {
+			shares = cash / c.Close
+			cash = 0
+			open = &entity.BacktestTrade{
+				EntryTs:    c.Timestamp,
+				EntryPrice: c.Close,
+				EntryRSI:   state.RSI,
+			}
+		}
+
+		equity := cash
+		if open != nil {
+			equity = shares * c.Close
+		}
+		res.EquityCurve = append(res.EquityCurve, entity.EquityPoint{Timestamp: c.Timestamp, Equity: equity})
+	}
+
+	summarizeBacktest(res, bars)
+	return res, nil
+}
+
+func validateBacktest(req entity.BacktestRequest, low, high, capital float64, n int) error {
+	switch {
+	case low < 0 || high > 100 || low >= high:
+		return entity.ErrBadRequest("need 0 <= rsi_low < rsi_high <= 100")
+	case req.StopLossPct < 0 || req.StopLossPct >= 100:
+		return entity.ErrBadRequest("stop_loss_pct must be in [0, 100)")
+	case req.TakeProfitPct < 0:
+		return entity.ErrBadRequest("take_profit_pct must be >= 0")
+	case req.MaxHoldBars < 0:
+		return entity.ErrBadRequest("max_hold_bars must be >= 0")
+	case capital <= 0:
+		return entity.ErrBadRequest("initial_capital must be > 0")
+	case n < minBacktestCandles:
+		return entity.ErrBadRequest(fmt.Sprintf("need at least %d candles, got %d", minBacktestCandles, n))
+	case n > maxBacktestCandles:
+		return entity.ErrLimitExceeded(fmt.Sprintf("at most %d candles per backtest", maxBacktestCandles))
+	}
+	return nil
+}
+
+// summarizeBacktest fills the aggregate statistics from trades and the
+// equity curve.
+func summarizeBacktest(res *entity.BacktestResult, bars []entity.Candle) {
+	for _, t := range res.Trades {
+		if t.PnL > 0 {
+			res.Wins++
+		} else {
+			res.Losses++
+		}
+	}
+	if n := len(res.Trades); n > 0 {
+		res.WinRate = float64(res.Wins) / float64(n)
+	}
+
+	curve := res.EquityCurve
+	res.FinalEquity = curve[len(curve)-1].Equity
+	res.TotalReturnPct = (res.FinalEquity/res.InitialCapital - 1) * 100
+
+	peak := res.InitialCapital
+	returns := make([]float64, 0, len(curve))
+	prev := res.InitialCapital
+	for _, p := range curve {
+		peak = math.Max(peak, p.Equity)
+		if dd := (peak - p.Equity) / peak * 100; dd > res.MaxDrawdownPct {
+			res.MaxDrawdownPct = dd
+		}
+		returns = append(returns, p.Equity/prev-1)
+		prev = p.Equity
+	}
+
+	mean, std := meanStd(returns)
+	if std > 0 {
+		res.Sharpe = mean / std * math.Sqrt(periodsPerYear(bars))
+	}
+}
+
+func meanStd(xs []float64) (float64, float64) {
+	if len(xs) < 2 {
+		return 0, 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)-1))
+}
+
+// periodsPerYear infers the bar interval from the median spacing of
+// chronological bars. Intraday bars count only session hours.
+func periodsPerYear(bars []entity.Candle) float64 {
+	gaps := make([]time.Duration, 0, len(bars)-1)
+	for i := 1; i < len(bars); i++ {
+		if d := bars[i].Timestamp.Sub(bars[i-1].Timestamp); d > 0 {
+			gaps = append(gaps, d)
+		}
+	}
+	if len(gaps) == 0 {
+		return tradingDaysPerYear
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	interval := gaps[len(gaps)/2]
+	if interval >= 24*time.Hour {
+		return tradingDaysPerYear * float64(24*time.Hour) / float64(interval)
+	}
+	return tradingDaysPerYear * float64(sessionLength) / float64(interval)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// backtestBars builds 5-minute candles opening at the previous close.
+func backtestBars(closes ...float64) []entity.Candle {
+	start := time.Date(2024, 3, 6, 14, 30, 0, 0, time.UTC)
+	bars := make([]entity.Candle, len(closes))
+	prev := closes[0]
+	for i, c := range closes {
+		bars[i] = entity.Candle{
+			Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+			Open:      prev,
+			High:      math.Max(prev, c),
+			Low:       math.Min(prev, c),
+			Close:     c,
+			Volume:    1000,
+		}
+		prev = c
+	}
+	return bars
+}
+
+// ramp returns n closes stepping from start by step.
+func ramp(start, step float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = start + float64(i)*step
+	}
+	return out
+}
+
+func TestRunBacktestTrades(t *testing.T) {
+	// 16 falling closes seed RSI at 0, so the first valid bar is an entry.
+	fall := ramp(115, -1, 16)
+	cases := []struct {
+		name    string
+		closes  []float64
+		reason  string
+		entryTs int
+		exitTs  int
+		win     bool
+	}{
+		{"exit on rsi cross", append(append(fall, ramp(101, 1, 30)...), ramp(130, 0, 5)...), entity.ExitRSI, 15, -1, true},
+		{"open at end of data", append(fall, ramp(101, 1, 5)...), entity.ExitEndOfData, 15, 20, true},
+		{"losing position at end of data", append(fall, ramp(99, -1, 5)...), entity.ExitEndOfData, 15, 20, false},
+	}
+	for _, c := range cases {
+		bars := backtestBars(c.closes...)
+		// Input order does not matter
+		shuffled := append([]entity.Candle(nil), bars...)
+		for i, j := 0, len(shuffled)-1; i < j; i, j = i+1, j-1 {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		}
+		res, err := RunBacktest(entity.BacktestRequest{Symbol: "IBM"}, shuffled)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(res.Trades) != 1 {
+			t.Fatalf("%s: got %d trades, want 1: %+v", c.name, len(res.Trades), res.Trades)
+		}
+		tr := res.Trades[0]
+		if tr.ExitReason != c.reason {
+			t.Errorf("%s: exit reason %q, want %q", c.name, tr.ExitReason, c.reason)
+		}
+		if !tr.EntryTs.Equal(bars[c.entryTs].Timestamp) || tr.EntryPrice != bars[c.entryTs].Close {
+			t.Errorf("%s: entry %v @ %v, want bar %d", c.name, tr.EntryTs, tr.EntryPrice, c.entryTs)
+		}
+		if tr.EntryRSI > 30 {
+			t.Errorf("%s: entry rsi %v above rsi_low", c.name, tr.EntryRSI)
+		}
+		if c.exitTs >= 0 && !tr.ExitTs.Equal(bars[c.exitTs].Timestamp) {
+			t.Errorf("%s: exit at %v, want bar %d", c.name, tr.ExitTs, c.exitTs)
+		}
+		if c.reason == entity.ExitRSI && tr.ExitRSI < 70 {
+			t.Errorf("%s: exit rsi %v below rsi_high", c.name, tr.ExitRSI)
+		}
+		if (tr.PnL > 0) != c.win || (res.Wins == 1) != c.win {
+			t.Errorf("%s: pnl %v wins %d, want win=%v", c.name, tr.PnL, res.Wins, c.win)
+		}
+		if got := res.InitialCapital + tr.PnL; math.Abs(got-res.FinalEquity) > 1e-6 {
+			t.Errorf("%s: final equity %v, want capital+pnl %v", c.name, res.FinalEquity, got)
+		}
+		if len(res.EquityCurve) != len(bars) {
+			t.Errorf("%s: equity curve has %d points, want %d", c.name, len(res.EquityCurve), len(bars))
+		}
+	}
+}
+
+func TestRunBacktestStopsAndHolds(t *testing.T) {
+	fall := ramp(115, -1, 16)
+	cases := []struct {
+		name   string
+		req    entity.BacktestRequest
+		closes []float64
+		reason string
+		price  float64
+	}{
+		// Entry at 100; the next bar trades down through 98
+		{"stop loss", entity.BacktestRequest{StopLossPct: 2}, append(fall, 97, 96), entity.ExitStopLoss, 98},
+		// The high crosses 101 on the second bar and fills at the target
+		{"take profit", entity.BacktestRequest{TakeProfitPct: 1}, append(fall, 100.5, 103, 104), entity.ExitTakeProfit, 101},
+		{"max hold", entity.BacktestRequest{MaxHoldBars: 2}, append(fall, 100, 100, 100, 100), entity.ExitMaxHold, 100},
+	}
+	for _, c := range cases {
+		c.req.Symbol = "IBM"
+		res, err := RunBacktest(c.req, backtestBars(c.closes...))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(res.Trades) == 0 {
+			t.Fatalf("%s: no trades", c.name)
+		}
+		tr := res.Trades[0]
+		if tr.ExitReason != c.reason || math.Abs(tr.ExitPrice-c.price) > 1e-9 {
+			t.Errorf("%s: exit %s @ %v, want %s @ %v", c.name, tr.ExitReason, tr.ExitPrice, c.reason, c.price)
+		}
+	}
+}
+
+func TestRunBacktestMaxDrawdown(t *testing.T) {
+	// Enter at 100, fall to 80, recover to 90 before the data ends
+	closes := append(ramp(115, -1, 16), 95, 90, 85, 80, 85, 90)
+	res, err := RunBacktest(entity.BacktestRequest{Symbol: "IBM"}, backtestBars(closes...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 20.0; math.Abs(res.MaxDrawdownPct-want) > 1e-9 {
+		t.Errorf("max drawdown %v, want %v", res.MaxDrawdownPct, want)
+	}
+	if want := -10.0; math.Abs(res.TotalReturnPct-want) > 1e-9 {
+		t.Errorf("total return %v, want %v", res.TotalReturnPct, want)
+	}
+}
+
+func TestRunBacktestFlatEquity(t *testing.T) {
+	// Rising closes never reach rsi_low: no trades, flat equity and
+	// a zero (not NaN) Sharpe.
+	res, err := RunBacktest(entity.BacktestRequest{Symbol: "IBM"}, backtestBars(ramp(100, 1, 40)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trades) != 0 || res.FinalEquity != defaultBacktestCapital {
+		t.Errorf("got %d trades, final equity %v", len(res.Trades), res.FinalEquity)
+	}
+	if res.Sharpe != 0 || res.MaxDrawdownPct != 0 || res.WinRate != 0 {
+		t.Errorf("sharpe %v drawdown %v win rate %v, want zeros", res.Sharpe, res.MaxDrawdownPct, res.WinRate)
+	}
+}
+
+func TestRunBacktestValidation(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	bars := backtestBars(ramp(100, 1, minBacktestCandles)...)
+	cases := []struct {
+		name string
+		req  entity.BacktestRequest
+		bars []entity.Candle
+	}{
+		{"too few candles", entity.BacktestRequest{}, bars[:minBacktestCandles-1]},
+		{"inverted thresholds", entity.BacktestRequest{RSILow: f(70), RSIHigh: f(30)}, bars},
+		{"stop loss 100", entity.BacktestRequest{StopLossPct: 100}, bars},
+		{"negative capital", entity.BacktestRequest{InitialCapital: -1}, bars},
+	}
+	for _, c := range cases {
+		if _, err := RunBacktest(c.req, c.bars); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+	if _, err := RunBacktest(entity.BacktestRequest{}, bars); err != nil {
+		t.Errorf("exactly %d candles: %v", minBacktestCandles, err)
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// GetIntradayBatch runs GetIntraday for every request with at most
+// parallelism in flight. Upstream pacing still goes through the shared
+// feed limiter. Failures are reported per symbol; the batch never fails
+// as a whole. Results keep the order of reqs.
+func (s *IntradayService) GetIntradayBatch(ctx context.Context, reqs []entity.IntradayRequest, parallelism int) []entity.BatchResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]entity.BatchResult, len(reqs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+
+	for i, req := range reqs {
+		g.Go(func() error {
+			resp, err := s.GetIntraday(gctx, req)
+			results[i] = entity.BatchResult{Symbol: req.Symbol, Data: resp}
+			if err != nil {
+				ae := entity.AsAPIError(err)
+				results[i].Error = &ae
+				results[i].Status = ae.Status
+			} else {
+				results[i].Symbol = resp.Symbol
+				results[i].Status = 200
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Market statuses reported in responses.
+const (
+	MarketOpen       = "open"
+	MarketPreMarket  = "pre_market"
+	MarketPostMarket = "post_market"
+	MarketClosed     = "closed"
+)
+
+// sessionCloseGrace is how long after a trading window ends the final bar
+// may still be fetched before upstream calls stop.
+const sessionCloseGrace = 5 * time.Minute
+
+const dateLayout = "2006-01-02"
+
+// CalendarConfig describes an exchange's weekly hours. Times are "HH:MM"
+// in Location.
+type CalendarConfig struct {
+	Location  string
+	PreOpen   string
+	Open      string
+	Close     string
+	PostClose string
+	// HolidaysFile is an optional JSON file:
+	// {"holidays": ["2026-12-25"], "early_closes": {"2026-11-27": "13:00"}}
+	HolidaysFile string
+	// Extended counts pre and post market as trading time for polling and
+	// upstream fetches.
+	Extended bool
+	// StaleAfter flags data older than this as stale while trading.
+	StaleAfter time.Duration
+}
+
+// Calendar knows when an exchange trades. A nil *Calendar is always open,
+// for 24/7 markets and replayed feeds.
+type Calendar struct {
+	loc         *time.Location
+	preOpen     clock
+	open        clock
+	close       clock
+	postClose   clock
+	holidays    map[string]bool
+	earlyCloses map[string]clock
+	extended    bool
+	staleAfter  time.Duration
+}
+
+// clock is a wall time of day.
+type clock struct{ h, m int }
+
+func (c clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.h, c.m, 0, 0, day.Location())
+}
+
+func (c clock) minutes() int { return c.h*60 + c.m }
+
+func parseClock(s string) (clock, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return clock{}, fmt.Errorf("invalid time of day %q (want HH:MM)", s)
+	}
+	return clock{t.Hour(), t.Minute()}, nil
+}
+
+func NewCalendar(cfg CalendarConfig) (*Calendar, error) {
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return nil, fmt.Errorf("market timezone: %w", err)
+	}
+	c := &Calendar{
+		loc:         loc,
+		holidays:    map[string]bool{},
+		earlyCloses: map[string]clock{},
+		extended:    cfg.Extended,
+		staleAfter:  cfg.StaleAfter,
+	}
+	for _, f := range []struct {
+		dst *clock
+		src string
+	}{{&c.preOpen, cfg.PreOpen}, {&c.open, cfg.Open}, {&c.close, cfg.Close}, {&c.postClose, cfg.PostClose}} {
+		if *f.dst, err = parseClock(f.src); err != nil {
+			return nil, err
+		}
+	}
+	if !(c.preOpen.minutes() <= c.open.minutes() && c.open.minutes() < c.close.minutes() && c.close.minutes() <= c.postClose.minutes()) {
+		return nil, fmt.Errorf("market hours must satisfy pre open <= open < close <= post close")
+	}
+
+	if cfg.HolidaysFile != "" {
+		if err := c.loadHolidays(cfg.HolidaysFile); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Calendar) loadHolidays(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read holidays: %w", err)
+	}
+	var file struct {
+		Holidays    []string          `json:"holidays"`
+		EarlyCloses map[string]string `json:"early_closes"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return fmt.Errorf("decode holidays: %w", err)
+	}
+	for _, d := range file.Holidays {
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return fmt.Errorf("holiday %q: want YYYY-MM-DD", d)
+		}
+		c.holidays[d] = true
+	}
+	for d, at := range file.EarlyCloses {
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return fmt.Errorf("early close %q: want YYYY-MM-DD", d)
+		}
+		cl, err := parseClock(at)
+		if err != nil {
+			return fmt.Errorf("early close %s: %w", d, err)
+		}
+		c.earlyCloses[d] = cl
+	}
+	return nil
+}
+
+// Status returns the market status at t.
+func (c *Calendar) Status(t time.Time) string {
+	if c == nil {
+		return MarketOpen
+	}
+	day := t.In(c.loc)
+	if !c.tradingDay(day) {
+		return MarketClosed
+	}
+	closeAt, postAt := c.closes(day)
+	switch {
+	case day.Before(c.preOpen.on(day)):
+		return MarketClosed
+	case day.Before(c.open.on(day)):
+		return MarketPreMarket
+	case day.Before(closeAt):
+		return MarketOpen
+	case day.Before(postAt):
+		return MarketPostMarket
+	}
+	return MarketClosed
+}
+
+// Trading reports whether new bars are expected at t: regular hours, plus
+// pre and post market when extended hours are enabled.
+func (c *Calendar) Trading(t time.Time) bool {
+	switch c.Status(t) {
+	case MarketOpen:
+		return true
+	case MarketPreMarket, MarketPostMarket:
+		return c.extended
+	}
+	return false
+}
+
+// LastTradingEnd returns when the most recent trading window ended at or
+// before t, or the zero time if none ended in the last two weeks.
+func (c *Calendar) LastTradingEnd(t time.Time) time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	day := t.In(c.loc)
+	for i := 0; i < 14; i++ {
+		d := day.AddDate(0, 0, -i)
+		if !c.tradingDay(d) {
+			continue
+		}
+		end, postAt := c.closes(d)
+		if c.extended {
+			end = postAt
+		}
+		if !end.After(t) {
+			return end
+		}
+	}
+	return time.Time{}
+}
+
+// Stale reports whether data last updated at lastTs is older than
+// expected at now. Data is never stale while the market is not trading.
+func (c *Calendar) Stale(lastTs, now time.Time) bool {
+	if c == nil || c.staleAfter <= 0 || lastTs.IsZero() {
+		return false
+	}
+	return c.Trading(now) && now.Sub(lastTs) > c.staleAfter
+}
+
+// fetchNeeded reports whether upstream can have bars newer than the last
+// fetch at fetchedAt: always while trading, and once more after a trading
+// window ends to pick up its final bar.
+func (c *Calendar) fetchNeeded(fetchedAt, now time.Time) bool {
+	if c == nil || c.Trading(now) || fetchedAt.IsZero() {
+		return true
+	}
+	end := c.LastTradingEnd(now)
+	return !end.IsZero() && fetchedAt.Before(end.Add(sessionCloseGrace))
+}
+
+func (c *Calendar) tradingDay(day time.Time) bool {
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !c.holidays[day.Format(dateLayout)]
+}
+
+// closes returns the regular and post-market close for day, honoring
+// early closes; post market keeps its usual length after an early close.
+func (c *Calendar) closes(day time.Time) (time.Time, time.Time) {
+	closeAt, postAt := c.close.on(day), c.postClose.on(day)
+	if early, ok := c.earlyCloses[day.Format(dateLayout)]; ok {
+		shift := closeAt.Sub(early.on(day))
+		closeAt, postAt = closeAt.Add(-shift), postAt.Add(-shift)
+	}
+	return closeAt, postAt
+}
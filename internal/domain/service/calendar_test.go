@@ -0,0 +1,165 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCalendar is NYSE hours with Thanksgiving 2026 (Thursday 26
+// November) closed and the day after closing at 13:00.
+func newTestCalendar(t *testing.T, extended bool) *Calendar {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "holidays.json")
+	err := os.WriteFile(path, []byte(`{"holidays": ["2026-11-26", "2026-12-25"], "early_closes": {"2026-11-27": "13:00"}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCalendar(CalendarConfig{
+		Location: "America/New_York", PreOpen: "04:00", Open: "09:30", Close: "16:00", PostClose: "20:00",
+		HolidaysFile: path, Extended: extended, StaleAfter: 10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func nyTime(t *testing.T, day, clock string) time.Time {
+	t.Helper()
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, err := time.ParseInLocation("2006-01-02 15:04", day+" "+clock, ny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func TestCalendarStatus(t *testing.T) {
+	c := newTestCalendar(t, false)
+	cases := []struct {
+		day, clock string
+		want       string
+	}{
+		{"2026-11-25", "03:59", MarketClosed},
+		{"2026-11-25", "04:00", MarketPreMarket},
+		{"2026-11-25", "09:30", MarketOpen},
+		{"2026-11-25", "15:59", MarketOpen},
+		{"2026-11-25", "16:00", MarketPostMarket},
+		{"2026-11-25", "20:00", MarketClosed},
+		// Holiday
+		{"2026-11-26", "11:00", MarketClosed},
+		// Early close: post market keeps its four hours
+		{"2026-11-27", "12:59", MarketOpen},
+		{"2026-11-27", "13:00", MarketPostMarket},
+		{"2026-11-27", "16:59", MarketPostMarket},
+		{"2026-11-27", "17:00", MarketClosed},
+		// Weekend
+		{"2026-11-28", "11:00", MarketClosed},
+		{"2026-12-25", "11:00", MarketClosed},
+	}
+	for _, tc := range cases {
+		if got := c.Status(nyTime(t, tc.day, tc.clock)); got != tc.want {
+			t.Errorf("%s %s: status = %s, want %s", tc.day, tc.clock, got, tc.want)
+		}
+	}
+
+	// Status is by exchange time whatever the zone of t
+	utc := nyTime(t, "2026-11-27", "12:30").UTC()
+	if got := c.Status(utc); got != MarketOpen {
+		t.Errorf("UTC instant: status = %s, want open", got)
+	}
+}
+
+func TestCalendarLastTradingEnd(t *testing.T) {
+	cases := []struct {
+		extended   bool
+		day, clock string
+		wantDay    string
+		wantClock  string
+	}{
+		{false, "2026-11-28", "10:00", "2026-11-27", "13:00"},
+		{true, "2026-11-28", "10:00", "2026-11-27", "17:00"},
+		// Monday before the open reaches back over the weekend
+		{false, "2026-11-30", "08:00", "2026-11-27", "13:00"},
+		// Friday morning skips the holiday
+		{false, "2026-11-27", "09:00", "2026-11-25", "16:00"},
+		{false, "2026-11-27", "13:00", "2026-11-27", "13:00"},
+	}
+	for _, tc := range cases {
+		c := newTestCalendar(t, tc.extended)
+		got := c.LastTradingEnd(nyTime(t, tc.day, tc.clock))
+		if want := nyTime(t, tc.wantDay, tc.wantClock); !got.Equal(want) {
+			t.Errorf("extended=%v %s %s: last end = %v, want %v", tc.extended, tc.day, tc.clock, got, want)
+		}
+	}
+}
+
+func TestCalendarFetchNeeded(t *testing.T) {
+	c := newTestCalendar(t, false)
+	saturday := nyTime(t, "2026-11-28", "10:00")
+	cases := []struct {
+		name      string
+		fetchedAt time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"never fetched", time.Time{}, saturday, true},
+		{"while trading", nyTime(t, "2026-11-27", "12:00"), nyTime(t, "2026-11-27", "12:01"), true},
+		{"before the early close", nyTime(t, "2026-11-27", "12:58"), saturday, true},
+		{"within the grace", nyTime(t, "2026-11-27", "13:03"), saturday, true},
+		{"after the grace", nyTime(t, "2026-11-27", "13:06"), saturday, false},
+		{"holiday", nyTime(t, "2026-11-25", "16:10"), nyTime(t, "2026-11-26", "11:00"), false},
+	}
+	for _, tc := range cases {
+		if got := c.fetchNeeded(tc.fetchedAt, tc.now); got != tc.want {
+			t.Errorf("%s: fetch needed = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCalendarTradingAndStale(t *testing.T) {
+	post := nyTime(t, "2026-11-27", "14:00")
+	if newTestCalendar(t, false).Trading(post) {
+		t.Error("post market counted as trading without extended hours")
+	}
+	extended := newTestCalendar(t, true)
+	if !extended.Trading(post) {
+		t.Error("post market not counted as trading with extended hours")
+	}
+	if !extended.Stale(post.Add(-11*time.Minute), post) {
+		t.Error("11 minute old data not stale while trading")
+	}
+	if extended.Stale(post.Add(-time.Hour), nyTime(t, "2026-11-27", "18:00")) {
+		t.Error("data stale while the market is closed")
+	}
+
+	var always *Calendar
+	if always.Status(post) != MarketOpen || !always.fetchNeeded(post, post) || always.Stale(time.Time{}.Add(time.Hour), post) {
+		t.Error("nil calendar is not always open")
+	}
+}
+
+func TestCalendarRejectsBadHolidays(t *testing.T) {
+	for _, file := range []string{
+		`{"holidays": ["26/11/2026"]}`,
+		`{"early_closes": {"2026-11-27": "1pm"}}`,
+		`not json`,
+	} {
+		path := filepath.Join(t.TempDir(), "holidays.json")
+		if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewCalendar(CalendarConfig{
+			Location: "America/New_York", PreOpen: "04:00", Open: "09:30", Close: "16:00", PostClose: "20:00",
+			HolidaysFile: path,
+		})
+		if err == nil {
+			t.Errorf("%s: accepted", file)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package service
+
+import "time"
+
+// Clock returns the current time. Services default to time.Now; in replay
+// mode they are given the replay's virtual clock, so alert cooldowns and
+// the timestamps clients see follow feed time.
+type Clock func() time.Time
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+)
+
+// SymbolDirectory is the upstream source of symbol reference data.
+// Implemented by the live feed and the replay feed.
+type SymbolDirectory interface {
+	SearchSymbols(ctx context.Context, keywords string) ([]feed.SymbolMatch, error)
+	Overview(ctx context.Context, symbol string) (*feed.Overview, error)
+}
+
+// DirectoryService answers symbol searches and lookups from upstream,
+// caching the answers for ttl so reference data costs one upstream call
+// per symbol a day, not one per keystroke. Unknown symbols are cached too,
+// for at most an hour, so repeated typos don't reach upstream either.
+type DirectoryService struct {
+	dir     SymbolDirectory
+	cache   Cache
+	symbols *SymbolPolicy
+	ttl     time.Duration
+	sf      singleflight.Group
+}
+
+// maxUnknownTTL caps how long a symbol upstream did not know is reported
+// unknown without asking again.
+const maxUnknownTTL = time.Hour
+
+func NewDirectoryService(dir SymbolDirectory, cache Cache, symbols *SymbolPolicy, ttl time.Duration) *DirectoryService {
+	return &DirectoryService{dir: dir, cache: cache, symbols: symbols, ttl: ttl}
+}
+
+// cachedLookup is the cached outcome of a lookup; a nil Info means
+// upstream does not know the symbol.
+type cachedLookup struct {
+	Info *entity.SymbolInfo `json:"info,omitempty"`
+}
+
+func searchCacheKey(q string) string {
+	return "symbols:search:" + strings.ToUpper(q)
+}
+
+func lookupCacheKey(symbol string) string {
+	return "symbols:info:{" + symbol + "}"
+}
+
+// Search returns upstream matches for q, best first. Matches the symbol
+// policy rejects are left out, since they could not be queried anyway.
+func (s *DirectoryService) Search(ctx context.Context, q string) (*entity.SymbolSearchResponse, error) {
+	q = strings.TrimSpace(q)
+	key := searchCacheKey(q)
+
+	var results []entity.SymbolInfo
+	if s.getCached(ctx, key, &results) {
+		return &entity.SymbolSearchResponse{Query: q, Results: results}, nil
+	}
+
+	// Shared by every caller waiting on key: detach it from the first
+	fctx := context.WithoutCancel(ctx)
+	v, err, _ := s.sf.Do(key, func() (any, error) {
+		matches, err := s.dir.SearchSymbols(fctx, q)
+		if err != nil {
+			return nil, upstreamError(q, err)
+		}
+		results := make([]entity.SymbolInfo, 0, len(matches))
+		for _, m := range matches {
+			if _, err := s.symbols.Normalize(m.Symbol); err != nil {
+				continue
+			}
+			results = append(results, symbolInfoFromMatch(m))
+		}
+		s.setCached(fctx, key, results, s.ttl)
+		return results, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.SymbolSearchResponse{Query: q, Results: v.([]entity.SymbolInfo)}, nil
+}
+
+// Lookup returns reference data for symbol, merging the upstream
+// overview (name, exchange, currency, type) with the symbol's exact
+// search match (timezone, region). Symbols unknown to both are 404
+// SYMBOL_NOT_FOUND.
+func (s *DirectoryService) Lookup(ctx context.Context, symbol string) (*entity.SymbolInfo, error) {
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	key := lookupCacheKey(symbol)
+
+	var cached cachedLookup
+	if !s.getCached(ctx, key, &cached) {
+		v, err, _ := s.sf.Do(key, func() (any, error) {
+			return s.lookup(context.WithoutCancel(ctx), symbol, key)
+		})
+		if err != nil {
+			return nil, err
+		}
+		cached = v.(cachedLookup)
+	}
+	if cached.Info == nil {
+		return nil, upstreamError(symbol, feed.ErrUnknownSymbol)
+	}
+	return cached.Info, nil
+}
+
+func (s *DirectoryService) lookup(ctx context.Context, symbol, key string) (cachedLookup, error) {
+	ov, ovErr := s.dir.Overview(ctx, symbol)
+	matches, searchErr := s.dir.SearchSymbols(ctx, symbol)
+
+	var info *entity.SymbolInfo
+	for _, m := range matches {
+		if strings.EqualFold(m.Symbol, symbol) {
+			mi := symbolInfoFromMatch(m)
+			mi.MatchScore = 0
+			info = &mi
+			break
+		}
+	}
+	if ov != nil {
+		if info == nil {
+			info = &entity.SymbolInfo{}
+		}
+		info.Symbol = symbol
+		info.Name = ov.Name
+		info.Exchange = ov.Exchange
+		if ov.Currency != "" {
+			info.Currency = ov.Currency
+		}
+		if ov.AssetType != "" {
+			info.Type = ov.AssetType
+		}
+	}
+
+	// Don't cache answers a transient failure may have cut short
+	for _, err := range []error{ovErr, searchErr} {
+		if err != nil && !errors.Is(err, feed.ErrUnknownSymbol) {
+			if info != nil {
+				return cachedLookup{Info: info}, nil
+			}
+			return cachedLookup{}, upstreamError(symbol, err)
+		}
+	}
+
+	res := cachedLookup{Info: info}
+	if info == nil {
+		s.setCached(ctx, key, res, min(s.ttl, maxUnknownTTL))
+	} else {
+		s.setCached(ctx, key, res, s.ttl)
+	}
+	return res, nil
+}
+
+// getCached decodes the cached value of key into v. Cache failures are
+// treated as misses.
+func (s *DirectoryService) getCached(ctx context.Context, key string, v any) bool {
+	b, ok, err := s.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+func (s *DirectoryService) setCached(ctx context.Context, key string, v any, ttl time.Duration) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, b, ttl); err != nil {
+		fmt.Printf("symbol cache write failed for %s: %v\n", key, err)
+	}
+}
+
+func symbolInfoFromMatch(m feed.SymbolMatch) entity.SymbolInfo {
+	return entity.SymbolInfo{
+		Symbol:     strings.ToUpper(m.Symbol),
+		Name:       m.Name,
+		Type:       m.Type,
+		Region:     m.Region,
+		Timezone:   m.Timezone,
+		Currency:   m.Currency,
+		MatchScore: m.MatchScore,
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+)
+
+// stubDirectory knows IBM (overview and search) and TSLA (search only),
+// and fails every call with err when set.
+type stubDirectory struct {
+	searches, overviews atomic.Int32
+	err                 error
+}
+
+func (d *stubDirectory) SearchSymbols(_ context.Context, keywords string) ([]feed.SymbolMatch, error) {
+	d.searches.Add(1)
+	if d.err != nil {
+		return nil, d.err
+	}
+	all := []feed.SymbolMatch{
+		{Symbol: "IBM", Name: "International Business Machines", Region: "United States", Timezone: "UTC-04", Currency: "USD", MatchScore: 1},
+		{Symbol: "IBM.LON", Name: "IBM (London)", MatchScore: 0.8},
+		{Symbol: "IBMX", Name: "Denied Corp", MatchScore: 0.7},
+		{Symbol: "TSLA", Name: "Tesla Inc", Timezone: "UTC-04", MatchScore: 1},
+	}
+	var out []feed.SymbolMatch
+	for _, m := range all {
+		if len(m.Symbol) >= len(keywords) && m.Symbol[:len(keywords)] == keywords {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (d *stubDirectory) Overview(_ context.Context, symbol string) (*feed.Overview, error) {
+	d.overviews.Add(1)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if symbol != "IBM" {
+		return nil, fmt.Errorf("%w %s", feed.ErrUnknownSymbol, symbol)
+	}
+	return &feed.Overview{Symbol: "IBM", Name: "International Business Machines", AssetType: "Common Stock", Exchange: "NYSE", Currency: "USD"}, nil
+}
+
+// ttlCache is a MemoryCache that records the TTL of every write and can
+// expire keys on demand.
+type ttlCache struct {
+	*MemoryCache
+	mu   sync.Mutex
+	ttls map[string]time.Duration
+}
+
+func newTTLCache() *ttlCache {
+	return &ttlCache{MemoryCache: NewMemoryCache(), ttls: make(map[string]time.Duration)}
+}
+
+func (c *ttlCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	c.ttls[key] = ttl
+	c.mu.Unlock()
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func (c *ttlCache) ttl(key string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl, ok := c.ttls[key]
+	return ttl, ok
+}
+
+func (c *ttlCache) expire(key string) {
+	c.MemoryCache.Set(context.Background(), key, nil, -time.Second)
+}
+
+// newTestDirectory accepts symbols matching ^[A-Z]+$ except IBMX, and
+// caches for a day.
+func newTestDirectory(t *testing.T) (*DirectoryService, *stubDirectory, *ttlCache) {
+	t.Helper()
+	policy, err := NewSymbolPolicy(`^[A-Z]+$`, nil, []string{"IBMX"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, cache := &stubDirectory{}, newTTLCache()
+	return NewDirectoryService(dir, cache, policy, 24*time.Hour), dir, cache
+}
+
+func TestDirectorySearch(t *testing.T) {
+	s, dir, cache := newTestDirectory(t)
+	ctx := context.Background()
+
+	resp, err := s.Search(ctx, " IBM ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// IBM.LON fails the pattern and IBMX is denied
+	if resp.Query != "IBM" || len(resp.Results) != 1 || resp.Results[0].Symbol != "IBM" || resp.Results[0].Timezone != "UTC-04" {
+		t.Errorf("search = %+v, want only IBM", resp)
+	}
+	if ttl, _ := cache.ttl(searchCacheKey("IBM")); ttl != 24*time.Hour {
+		t.Errorf("cached for %v, want the symbol cache TTL", ttl)
+	}
+
+	// Hits, case-insensitively, until the entry expires
+	for _, q := range []string{"IBM", "ibm"} {
+		if resp, err := s.Search(ctx, q); err != nil || len(resp.Results) != 1 {
+			t.Errorf("cached search %q = %+v, %v", q, resp, err)
+		}
+	}
+	if n := dir.searches.Load(); n != 1 {
+		t.Errorf("%d upstream searches, want 1", n)
+	}
+	cache.expire(searchCacheKey("IBM"))
+	s.Search(ctx, "IBM")
+	if n := dir.searches.Load(); n != 2 {
+		t.Errorf("%d upstream searches after expiry, want 2", n)
+	}
+}
+
+func TestDirectoryLookup(t *testing.T) {
+	s, dir, cache := newTestDirectory(t)
+	ctx := context.Background()
+
+	info, err := s.Lookup(ctx, "ibm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := entity.SymbolInfo{Symbol: "IBM", Name: "International Business Machines", Exchange: "NYSE", Currency: "USD", Type: "Common Stock", Region: "United States", Timezone: "UTC-04"}
+	if *info != want {
+		t.Errorf("lookup = %+v, want overview merged with the search match %+v", *info, want)
+	}
+	// Known only to search: no overview fields
+	if info, err := s.Lookup(ctx, "TSLA"); err != nil || info.Name != "Tesla Inc" || info.Exchange != "" {
+		t.Errorf("TSLA = %+v, %v", info, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Lookup(ctx, "IBM"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := dir.overviews.Load(); n != 2 {
+		t.Errorf("%d upstream overviews for IBM and TSLA, want 2", n)
+	}
+
+	// Unknown symbols are remembered, for at most an hour
+	for i := 0; i < 2; i++ {
+		_, err := s.Lookup(ctx, "NOPE")
+		if !isStatus(err, http.StatusNotFound) {
+			t.Fatalf("NOPE err = %v, want 404", err)
+		}
+		var ae entity.APIError
+		if !errors.As(err, &ae) || ae.Code != entity.CodeSymbolNotFound {
+			t.Errorf("NOPE code = %s", ae.Code)
+		}
+	}
+	if n := dir.overviews.Load(); n != 3 {
+		t.Errorf("%d upstream overviews after two NOPE lookups, want 3", n)
+	}
+	if ttl, _ := cache.ttl(lookupCacheKey("NOPE")); ttl != time.Hour {
+		t.Errorf("unknown symbol cached for %v, want 1h", ttl)
+	}
+	if ttl, _ := cache.ttl(lookupCacheKey("IBM")); ttl != 24*time.Hour {
+		t.Errorf("IBM cached for %v, want 24h", ttl)
+	}
+}
+
+func TestDirectoryPolicy(t *testing.T) {
+	s, dir, _ := newTestDirectory(t)
+	for sym, status := range map[string]int{"IBM.LON": http.StatusBadRequest, "IBMX": http.StatusForbidden} {
+		if _, err := s.Lookup(context.Background(), sym); !isStatus(err, status) {
+			t.Errorf("%s err = %v, want %d", sym, err, status)
+		}
+	}
+	if n := dir.overviews.Load() + dir.searches.Load(); n != 0 {
+		t.Errorf("%d upstream calls for rejected symbols", n)
+	}
+}
+
+func TestDirectoryUpstreamErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errors.New("connection refused"), http.StatusBadGateway, entity.CodeUpstreamError},
+		{fmt.Errorf("search: %w", feed.ErrRateLimited), http.StatusServiceUnavailable, entity.CodeUpstreamRateLimit},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, entity.CodeUpstreamTimeout},
+	}
+	for _, c := range cases {
+		s, dir, cache := newTestDirectory(t)
+		dir.err = c.err
+		ctx := context.Background()
+
+		_, searchErr := s.Search(ctx, "IBM")
+		_, lookupErr := s.Lookup(ctx, "IBM")
+		for name, err := range map[string]error{"search": searchErr, "lookup": lookupErr} {
+			var ae entity.APIError
+			if !errors.As(err, &ae) || ae.Status != c.status || ae.Code != c.code {
+				t.Errorf("%v: %s err = %v, want %d %s", c.err, name, err, c.status, c.code)
+			}
+		}
+
+		// Failures aren't cached: the next call asks upstream again
+		if _, ok := cache.ttl(searchCacheKey("IBM")); ok {
+			t.Errorf("%v: failed search cached", c.err)
+		}
+		if _, ok := cache.ttl(lookupCacheKey("IBM")); ok {
+			t.Errorf("%v: failed lookup cached", c.err)
+		}
+		dir.err = nil
+		if _, err := s.Lookup(ctx, "IBM"); err != nil {
+			t.Errorf("%v: lookup after recovery: %v", c.err, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// memHashStore is an in-memory HashStore for tests.
+type memHashStore struct {
+	mu     sync.Mutex
+	hashes map[string]map[string][]byte
+}
+
+func newMemHashStore() *memHashStore {
+	return &memHashStore{hashes: make(map[string]map[string][]byte)}
+}
+
+func (m *memHashStore) HGet(_ context.Context, key, field string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.hashes[key][field]
+	return v, ok, nil
+}
+
+func (m *memHashStore) HGetAll(_ context.Context, key string) (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string][]byte, len(m.hashes[key]))
+	for f, v := range m.hashes[key] {
+		out[f] = v
+	}
+	return out, nil
+}
+
+func (m *memHashStore) HSet(_ context.Context, key, field string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.hashes[key] == nil {
+		m.hashes[key] = make(map[string][]byte)
+	}
+	m.hashes[key][field] = value
+	return nil
+}
+
+func (m *memHashStore) HDel(_ context.Context, key string, fields ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range fields {
+		delete(m.hashes[key], f)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+// GetHistory returns the full upstream history of symbol, oldest first,
+// with the indicators after each bar. It replays the bars through a fresh
+// RSI state seeded the same way as live state and leaves the stored state
+// untouched. tail keeps only the last tail bars.
+func (s *IntradayService) GetHistory(ctx context.Context, symbol string, tail *int) (*entity.HistoryResponse, error) {
+	if s.feedCli == nil {
+		return nil, fmt.Errorf("feedCli is nil")
+	}
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := s.feedCli.FetchIntraday(ctx, symbol, time.Time{})
+	if err != nil {
+		return nil, upstreamError(symbol, err)
+	}
+	indicators := seedIndicators(&rsi.CompactRSI{}, candles)
+
+	bars := make([]entity.HistoryBar, len(candles))
+	for i := range candles {
+		bars[i] = entity.HistoryBar{Candle: *entity.CandleFromFeed(&candles[i]), BarIndicators: indicators[i]}
+	}
+	if tail != nil && *tail > 0 && *tail < len(bars) {
+		bars = bars[len(bars)-*tail:]
+	}
+	return &entity.HistoryResponse{Symbol: symbol, Interval: feed.Interval, Bars: bars}, nil
+}
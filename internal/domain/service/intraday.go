@@ -2,7 +2,11 @@ package service
 
 import (
     "context"
+    "errors"
     "fmt"
+    "net/http"
+    "sort"
+    "sync"
     "time"
 
     "marketpulse/internal/domain/entity"
@@ -10,18 +14,84 @@ import (
     "marketpulse/pkg/rsi"
 )
 
+// recentCandles caps the candles kept per polled symbol.
+const recentCandles = 200
+
 type StateRepository interface {
     GetOrUpdate(ctx context.Context, symbol string) (*rsi.CompactRSI, error)
     Save(ctx context.Context, symbol string, st *rsi.CompactRSI) error
+    All(ctx context.Context) (map[string]*rsi.CompactRSI, error)
+    GetMany(ctx context.Context, symbols []string) (map[string]*rsi.CompactRSI, error)
+    SaveMany(ctx context.Context, states map[string]*rsi.CompactRSI) error
+}
+
+// CandleFeed supplies candles newer than since, newest first. Implemented
+// by the upstream HTTP client and by the replay provider.
+type CandleFeed interface {
+    FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]feed.Candle, error)
 }
 
 type IntradayService struct {
     stateRepo StateRepository
-    feedCli   *feed.Client
+    feedCli   CandleFeed
+    symbols   *SymbolPolicy
+    calendar  *Calendar
+
+    polledMu sync.RWMutex
+    polled   map[string]*polledSymbol
+
+    // Last successful incremental fetch per symbol, so upstream is not
+    // called again while the market is closed.
+    fetchedMu sync.Mutex
+    fetched   map[string]time.Time
+
+    // Serializes advance per symbol, so concurrent requests and poll
+    // rounds never apply the same candles to one state twice.
+    locks keyedMutex
+
+    listeners []UpdateListener
+
+    now Clock
 }
 
-func NewIntradayService(repo StateRepository, feedCli *feed.Client) *IntradayService {
-    return &IntradayService{stateRepo: repo, feedCli: feedCli}
+// UpdateListener is called after a symbol's state advanced by at least one
+// candle, with a copy of the new state, the candles just applied
+// (chronological) and the indicators after each of them.
+type UpdateListener func(ctx context.Context, symbol string, state *rsi.CompactRSI, candles []entity.Candle, indicators []entity.BarIndicators)
+
+// polledSymbol is a symbol kept current by a background poller. While
+// fresh, HTTP reads are served from state without calling upstream.
+type polledSymbol struct {
+    freshUntil time.Time
+    recent     []entity.Candle // chronological, capped at recentCandles
+    indicators []entity.BarIndicators // parallel to recent
+}
+
+// stateUpdate is the outcome of advancing one symbol's state.
+type stateUpdate struct {
+    state     *rsi.CompactRSI
+    candles   []entity.Candle // newly processed, chronological
+    indicators []entity.BarIndicators // state after each of candles
+    seeded    int
+    fetchErr  error
+}
+
+func NewIntradayService(repo StateRepository, feedCli CandleFeed, symbols *SymbolPolicy, calendar *Calendar) *IntradayService {
+    return &IntradayService{
+        stateRepo: repo,
+        feedCli:   feedCli,
+        symbols:   symbols,
+        calendar:  calendar,
+        polled:    make(map[string]*polledSymbol),
+        fetched:   make(map[string]time.Time),
+        now:       time.Now,
+    }
+}
+
+// UseClock sets the clock for response timestamps, staleness and market
+// hours checks.
+func (s *IntradayService) UseClock(now Clock) {
+    s.now = now
 }
 
 func (s *IntradayService) GetIntraday(ctx context.Context, req entity.IntradayRequest) (*entity.IntradayResponse, error) {
@@ -34,11 +104,135 @@ func (s *IntradayService) GetIntraday(ctx context.Context, req entity.IntradayRe
     if s.feedCli == nil {
         return nil, fmt.Errorf("feedCli is nil")
     }
-    if req.Symbol == "" {
-        return nil, fmt.Errorf("symbol required")
+    symbol, err := s.symbols.Normalize(req.Symbol)
+    if err != nil {
+        return nil, err
+    }
+    req.Symbol = symbol
+
+    // POLLED: a background poller keeps this symbol current
+    if recent, indicators, ok := s.freshCandles(req.Symbol); ok {
+        state, err := s.stateRepo.GetOrUpdate(ctx, req.Symbol)
+        if err != nil {
+            return nil, err
+        }
+        if state != nil && state.Count > 0 {
+            candles := tailCandles(recent, req.Tail)
+            indicators = indicators[len(indicators)-len(candles):]
+            if len(candles) == 0 {
+                candles = append(candles, lastKnownCandle(state))
+                indicators = append(indicators, entity.IndicatorsFromState(state))
+            }
+            resp := buildResponse(req, state, candles, 0, state.ChangePct, s.now())
+            resp.Indicators = indicators
+            s.setMarketInfo(resp, state)
+            return resp, nil
+        }
+    }
+
+    up, err := s.advance(ctx, req.Symbol, req.Tail)
+    if err != nil {
+        return nil, err
+    }
+    if up.fetchErr != nil {
+        // Nothing to fall back on for a symbol that was never seeded
+        if up.state.Count == 0 {
+            return nil, upstreamError(req.Symbol, up.fetchErr)
+        }
+        fmt.Printf("incremental fetch failed for %s: %v\n", req.Symbol, up.fetchErr)
+    }
+
+    candles, indicators := up.candles, up.indicators
+    // No new candles? Return last known (for fast polling)
+    if len(candles) == 0 && up.state.Count > 0 {
+        candles = append(candles, lastKnownCandle(up.state))
+        indicators = append(indicators, entity.IndicatorsFromState(up.state))
+    }
+
+    // Tail trim: keep the newest bars, as on the polled path
+    candles = tailCandles(candles, req.Tail)
+    indicators = indicators[len(indicators)-len(candles):]
+
+    resp := buildResponse(req, up.state, candles, up.seeded, up.state.ChangePct, s.now())
+    resp.Indicators = indicators
+    s.setMarketInfo(resp, up.state)
+    return resp, nil
+}
+
+// setMarketInfo stamps the market status and data age onto resp.
+func (s *IntradayService) setMarketInfo(resp *entity.IntradayResponse, state *rsi.CompactRSI) {
+    // Bar times are feed time, so measure them against the feed's clock
+    now := s.now()
+    resp.MarketStatus = s.calendar.Status(now)
+    if !state.LastTs.IsZero() {
+        resp.DataAsOf = state.LastTs
+        resp.StalenessSec = int64(now.Sub(state.LastTs) / time.Second)
+        resp.Stale = s.calendar.Stale(state.LastTs, now)
+    }
+}
+
+// OnUpdate registers fn to run on every state update. Listeners run
+// synchronously in registration order; register them before serving.
+func (s *IntradayService) OnUpdate(fn UpdateListener) {
+    s.listeners = append(s.listeners, fn)
+}
+
+// Refresh advances a symbol's state from upstream and marks it fresh for
+// freshFor, during which GetIntraday serves it without upstream calls.
+// Used by background pollers.
+func (s *IntradayService) Refresh(ctx context.Context, symbol string, freshFor time.Duration) error {
+    symbol, err := s.symbols.Normalize(symbol)
+    if err != nil {
+        return err
     }
 
-    state, err := s.stateRepo.GetOrUpdate(ctx, req.Symbol)
+    up, err := s.advance(ctx, symbol, nil)
+    if err != nil {
+        return err
+    }
+    if up.fetchErr != nil {
+        return fmt.Errorf("fetch %s: %w", symbol, up.fetchErr)
+    }
+
+    s.polledMu.Lock()
+    defer s.polledMu.Unlock()
+
+    p, ok := s.polled[symbol]
+    if !ok {
+        p = &polledSymbol{}
+        s.polled[symbol] = p
+    }
+    p.freshUntil = time.Now().Add(freshFor)
+    p.recent = append(p.recent, up.candles...)
+    p.indicators = append(p.indicators, up.indicators...)
+    if len(p.recent) > recentCandles {
+        p.recent = append([]entity.Candle(nil), p.recent[len(p.recent)-recentCandles:]...)
+        p.indicators = append([]entity.BarIndicators(nil), p.indicators[len(p.indicators)-recentCandles:]...)
+    }
+    return nil
+}
+
+// freshCandles returns a copy of the recent candles of a polled symbol,
+// and their indicators, if the poller refreshed it recently enough.
+func (s *IntradayService) freshCandles(symbol string) ([]entity.Candle, []entity.BarIndicators, bool) {
+    s.polledMu.RLock()
+    defer s.polledMu.RUnlock()
+
+    p, ok := s.polled[symbol]
+    if !ok || time.Now().After(p.freshUntil) {
+        return nil, nil, false
+    }
+    return append([]entity.Candle(nil), p.recent...), append([]entity.BarIndicators(nil), p.indicators...), true
+}
+
+// advance loads state, seeds it on first use, applies new candles and
+// persists it. Incremental fetch failures are reported in fetchErr so
+// callers can decide whether stale state is acceptable. Calls for one
+// symbol run one at a time, listeners included.
+func (s *IntradayService) advance(ctx context.Context, symbol string, tail *int) (*stateUpdate, error) {
+    defer s.locks.lock(symbol)()
+
+    state, err := s.stateRepo.GetOrUpdate(ctx, symbol)
     if err != nil {
         return nil, err
     }
@@ -46,59 +240,87 @@ func (s *IntradayService) GetIntraday(ctx context.Context, req entity.IntradayRe
         state = &rsi.CompactRSI{}
     }
 
-    var candles []entity.Candle
-    seededCandles := 0
-    changePct := 0.0
+    up := &stateUpdate{state: state}
 
     // SEEDING: If uninitialized (Count == 0), fetch full history & warm up
     if state.Count == 0 {
-        allCandles, err := s.feedCli.FetchIntraday(ctx, req.Symbol, time.Time{})
+        allCandles, err := s.feedCli.FetchIntraday(ctx, symbol, time.Time{})
         if err == nil && len(allCandles) > 0 {
-            state.SeedFromHistory(allCandles)
-            s.stateRepo.Save(ctx, req.Symbol, state)
-            candles = makeRecentCandles(allCandles, req.Tail)
-            seededCandles = len(allCandles)
+            indicators := seedIndicators(state, allCandles)
+            s.stateRepo.Save(ctx, symbol, state)
+            up.candles = makeRecentCandles(allCandles, tail)
+            up.indicators = indicators[len(indicators)-len(up.candles):]
+            up.seeded = len(allCandles)
         }
     }
 
-    // INCREMENTAL: Always fetch new candles (safe even after seeding)
-    newCandles, err := s.feedCli.FetchIntraday(ctx, req.Symbol, state.LastTs)
+    // INCREMENTAL: fetch new candles unless the market is closed and its
+    // final bar was already fetched (safe even after seeding)
+    if state.Count > 0 && !s.calendar.fetchNeeded(s.lastFetched(symbol), s.now()) {
+        return up, nil
+    }
+    fetchedAt := s.now()
+    newCandles, err := s.feedCli.FetchIntraday(ctx, symbol, state.LastTs)
     if err != nil {
-        fmt.Printf("incremental fetch failed for %s: %v\n", req.Symbol, err)
+        up.fetchErr = err
     } else {
-        prevClose := state.LastClose
+        s.fetchedMu.Lock()
+        s.fetched[symbol] = fetchedAt
+        s.fetchedMu.Unlock()
+
+        // Feed returns newest first; apply oldest first so no bar is skipped
+        sort.Slice(newCandles, func(i, j int) bool {
+            return newCandles[i].Timestamp.Before(newCandles[j].Timestamp)
+        })
+
         for _, c := range newCandles {
             // Dedupe: only process newer candles
             if !state.LastTs.IsZero() && !c.Timestamp.After(state.LastTs) {
                 continue
             }
 
-            // Compute change% before update
-            if prevClose != 0 {
-                changePct = ((c.Close - prevClose) / prevClose) * 100
-            }
-
             state.UpdateIncremental(c)
-            candles = append(candles, *entity.CandleFromFeed(&c))
-            prevClose = c.Close
+            up.candles = append(up.candles, *entity.CandleFromFeed(&c))
+            up.indicators = append(up.indicators, entity.IndicatorsFromState(state))
         }
     }
 
-    // No new candles? Return last known (for fast polling)
-    if len(candles) == 0 && state.Count > 0 {
-        candles = append(candles, lastKnownCandle(state))
+    // Persist only when candles were applied; polls that find nothing
+    // new leave the store untouched
+    if len(up.candles) > 0 {
+        if err := s.stateRepo.Save(ctx, symbol, state); err != nil {
+            fmt.Printf("state save failed for %s: %v\n", symbol, err)
+        }
+        for _, fn := range s.listeners {
+            snapshot := *state
+            fn(ctx, symbol, &snapshot, up.candles, up.indicators)
+        }
     }
+    return up, nil
+}
 
-    // Always persist
-    if err := s.stateRepo.Save(ctx, req.Symbol, state); err != nil {
-        fmt.Printf("state save failed for %s: %v\n", req.Symbol, err)
+// upstreamError maps a feed failure to the APIError clients see.
+func upstreamError(symbol string, err error) error {
+    switch {
+    case errors.Is(err, feed.ErrUnknownSymbol):
+        return entity.NewError(http.StatusNotFound, entity.CodeSymbolNotFound, fmt.Sprintf("symbol %s not found upstream", symbol))
+    case errors.Is(err, feed.ErrRateLimited):
+        return entity.NewError(http.StatusServiceUnavailable, entity.CodeUpstreamRateLimit, "upstream rate limit reached, retry shortly")
+    case errors.Is(err, context.Canceled):
+        return entity.NewError(entity.StatusClientClosedRequest, entity.CodeClientClosed, "request canceled")
+    case errors.Is(err, context.DeadlineExceeded):
+        return entity.NewError(http.StatusServiceUnavailable, entity.CodeUpstreamTimeout, fmt.Sprintf("fetch %s: upstream timed out", symbol))
     }
+    return entity.NewError(http.StatusBadGateway, entity.CodeUpstreamError, fmt.Sprintf("fetch %s: %v", symbol, err))
+}
 
-    // Tail trim
-    if req.Tail != nil && *req.Tail > 0 && len(candles) > *req.Tail {
-        candles = candles[:*req.Tail]
-    }
+func (s *IntradayService) lastFetched(symbol string) time.Time {
+    s.fetchedMu.Lock()
+    defer s.fetchedMu.Unlock()
+    return s.fetched[symbol]
+}
 
+func buildResponse(req entity.IntradayRequest, state *rsi.CompactRSI, candles []entity.Candle, seeded int, changePct float64, now time.Time) *entity.IntradayResponse {
     low, high := 30.0, 70.0
     if req.RSILow != nil {
         low = *req.RSILow
@@ -117,13 +339,26 @@ func (s *IntradayService) GetIntraday(ctx context.Context, req entity.IntradayRe
         Alert:        alert,
         IsValidRSI:   state.IsValid(),
         WarmupStatus: string(state.WarmupStatus()),
-        SeededCandles: seededCandles,
-        LastFetch:    time.Now(),
+        SeededCandles: seeded,
+        LastFetch:    now,
         RSICount:     state.Count,
-    }, nil
+    }
 }
 
 // Helpers
+// seedIndicators seeds state from candles (sorting them oldest first) and
+// returns the indicators after each candle.
+func seedIndicators(state *rsi.CompactRSI, candles []feed.Candle) []entity.BarIndicators {
+    indicators := make([]entity.BarIndicators, len(candles))
+    for i := range indicators {
+        indicators[i].WarmupStatus = string(rsi.Processing)
+    }
+    state.SeedFromHistoryFunc(candles, func(i int, st *rsi.CompactRSI) {
+        indicators[i] = entity.IndicatorsFromState(st)
+    })
+    return indicators
+}
+
 func makeRecentCandles(all []feed.Candle, tail *int) []entity.Candle {
     n := len(all)
     if tail != nil && *tail > 0 && *tail < n {
@@ -136,6 +371,14 @@ func makeRecentCandles(all []feed.Candle, tail *int) []entity.Candle {
     return out
 }
 
+// tailCandles returns the last tail candles of a chronological slice.
+func tailCandles(candles []entity.Candle, tail *int) []entity.Candle {
+    if tail != nil && *tail > 0 && *tail < len(candles) {
+        return candles[len(candles)-*tail:]
+    }
+    return candles
+}
+
 func lastKnownCandle(state *rsi.CompactRSI) entity.Candle {
     return entity.Candle{
         Timestamp: state.LastTs,
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+// memStateRepo is an in-memory StateRepository that hands out copies.
+type memStateRepo struct {
+	mu     sync.Mutex
+	states map[string]rsi.CompactRSI
+}
+
+func newMemStateRepo() *memStateRepo {
+	return &memStateRepo{states: make(map[string]rsi.CompactRSI)}
+}
+
+func (m *memStateRepo) GetOrUpdate(_ context.Context, symbol string) (*rsi.CompactRSI, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.states[symbol]
+	return &st, nil
+}
+
+func (m *memStateRepo) Save(_ context.Context, symbol string, st *rsi.CompactRSI) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[symbol] = *st
+	return nil
+}
+
+func (m *memStateRepo) All(ctx context.Context) (map[string]*rsi.CompactRSI, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]*rsi.CompactRSI, len(m.states))
+	for sym, st := range m.states {
+		out[sym] = &st
+	}
+	return out, nil
+}
+
+func (m *memStateRepo) GetMany(ctx context.Context, symbols []string) (map[string]*rsi.CompactRSI, error) {
+	all, _ := m.All(ctx)
+	out := make(map[string]*rsi.CompactRSI, len(symbols))
+	for _, sym := range symbols {
+		if st, ok := all[sym]; ok {
+			out[sym] = st
+		}
+	}
+	return out, nil
+}
+
+func (m *memStateRepo) SaveMany(ctx context.Context, states map[string]*rsi.CompactRSI) error {
+	for sym, st := range states {
+		m.Save(ctx, sym, st)
+	}
+	return nil
+}
+
+// stubFeed serves candles newer than since, newest first, and counts
+// full-history fetches.
+type stubFeed struct {
+	mu       sync.Mutex
+	candles  []feed.Candle // chronological
+	delay    time.Duration
+	seedHits atomic.Int32
+}
+
+func newStubFeed(closes ...float64) *stubFeed {
+	f := &stubFeed{}
+	f.add(closes...)
+	return f
+}
+
+func (f *stubFeed) add(closes ...float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := time.Date(2024, 3, 6, 14, 30, 0, 0, time.UTC)
+	for _, c := range closes {
+		ts := start.Add(time.Duration(len(f.candles)) * 5 * time.Minute)
+		f.candles = append(f.candles, feed.Candle{Timestamp: ts, Open: c, High: c, Low: c, Close: c, Volume: 100})
+	}
+}
+
+func (f *stubFeed) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]feed.Candle, error) {
+	if since.IsZero() {
+		f.seedHits.Add(1)
+	}
+	time.Sleep(f.delay)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []feed.Candle
+	for i := len(f.candles) - 1; i >= 0; i-- {
+		if f.candles[i].Timestamp.After(since) {
+			out = append(out, f.candles[i])
+		}
+	}
+	return out, nil
+}
+
+func TestSetMarketInfoUsesClock(t *testing.T) {
+	cal, err := NewCalendar(CalendarConfig{
+		Location: "America/New_York", PreOpen: "04:00", Open: "09:30", Close: "16:00", PostClose: "20:00",
+		StaleAfter: 10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A replayed Wednesday morning, years before the wall clock
+	lastTs := time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		now    time.Time
+		status string
+		stale  bool
+		sec    int64
+	}{
+		{"fresh", lastTs.Add(90 * time.Second), MarketOpen, false, 90},
+		{"stale", lastTs.Add(15 * time.Minute), MarketOpen, true, 900},
+		{"after close", time.Date(2024, 3, 6, 22, 0, 0, 0, time.UTC), MarketPostMarket, false, 25200},
+	}
+	for _, c := range cases {
+		s := NewIntradayService(nil, nil, nil, cal)
+		s.UseClock(func() time.Time { return c.now })
+		resp := &entity.IntradayResponse{}
+		s.setMarketInfo(resp, &rsi.CompactRSI{LastTs: lastTs})
+		if resp.MarketStatus != c.status || resp.Stale != c.stale || resp.StalenessSec != c.sec {
+			t.Errorf("%s: got %s stale=%v %ds, want %s stale=%v %ds", c.name,
+				resp.MarketStatus, resp.Stale, resp.StalenessSec, c.status, c.stale, c.sec)
+		}
+	}
+}
+
+func newTestIntradayService(t *testing.T, fd CandleFeed) *IntradayService {
+	t.Helper()
+	policy, err := NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIntradayService(newMemStateRepo(), fd, policy, nil)
+}
+
+func TestAdvanceSerializedPerSymbol(t *testing.T) {
+	fd := newStubFeed(ramp(100, 1, 30)...)
+	fd.delay = 20 * time.Millisecond
+	s := newTestIntradayService(t, fd)
+	var notified atomic.Int32
+	s.OnUpdate(func(_ context.Context, _ string, _ *rsi.CompactRSI, candles []entity.Candle, _ []entity.BarIndicators) {
+		notified.Add(int32(len(candles)))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.GetIntraday(context.Background(), entity.IntradayRequest{Symbol: "IBM"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fd.seedHits.Load(); n != 1 {
+		t.Errorf("history fetched %d times, want 1", n)
+	}
+	if n := notified.Load(); n != 30 {
+		t.Errorf("listeners saw %d candles, want each of 30 once", n)
+	}
+}
+
+func TestChangePctPolledAndOnDemand(t *testing.T) {
+	fd := newStubFeed(ramp(100, 1, 20)...)
+	s := newTestIntradayService(t, fd)
+	ctx := context.Background()
+	req := entity.IntradayRequest{Symbol: "IBM"}
+
+	if _, err := s.GetIntraday(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	fd.add(121)
+	first, err := s.GetIntraday(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := (121.0/119 - 1) * 100
+	if diff := first.ChangePct - want; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("change %v after a new bar, want %v", first.ChangePct, want)
+	}
+
+	// No new candles: the last bar's change, not 0
+	again, err := s.GetIntraday(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ChangePct != first.ChangePct {
+		t.Errorf("on-demand change %v without new bars, want %v", again.ChangePct, first.ChangePct)
+	}
+
+	if err := s.Refresh(ctx, "IBM", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	polled, err := s.GetIntraday(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polled.ChangePct != first.ChangePct {
+		t.Errorf("polled change %v, want %v", polled.ChangePct, first.ChangePct)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+// IntradayCache is a short-lived cache of single-symbol intraday responses
+// in front of IntradayService. Repeated polls with the same parameters are
+// answered from memory, without touching the state store or upstream,
+// until the TTL expires or the symbol's state advances. Concurrent misses
+// for the same key share one call.
+type IntradayCache struct {
+	svc *IntradayService
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	sf      singleflight.Group
+}
+
+type cacheEntry struct {
+	symbol  string
+	resp    *entity.IntradayResponse
+	expires time.Time
+}
+
+// cacheSweepSize is the entry count above which expired entries are
+// dropped on insert.
+const cacheSweepSize = 1024
+
+// NewIntradayCache wraps svc with a micro-cache holding responses for ttl.
+// A ttl of 0 disables caching. Entries of a symbol are dropped on every
+// state update, so the cache must be created before serving.
+func NewIntradayCache(svc *IntradayService, ttl time.Duration) *IntradayCache {
+	c := &IntradayCache{
+		svc:     svc,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+	if ttl > 0 {
+		svc.OnUpdate(c.invalidate)
+	}
+	return c
+}
+
+// TTL returns how long responses are reused; 0 when caching is off.
+func (c *IntradayCache) TTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.ttl
+}
+
+// GetIntraday returns the cached response for req, or fetches and caches
+// it. The returned response is a copy whose slices may be resliced, but
+// not modified in place.
+func (c *IntradayCache) GetIntraday(ctx context.Context, req entity.IntradayRequest) (*entity.IntradayResponse, error) {
+	if c == nil || c.ttl <= 0 {
+		return c.svc.GetIntraday(ctx, req)
+	}
+	symbol, err := c.svc.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	req.Symbol = symbol
+	key := cacheKey(req)
+
+	if resp, ok := c.lookup(key); ok {
+		return resp, nil
+	}
+
+	// Shared by every caller waiting on key: detach it from the first
+	fctx := context.WithoutCancel(ctx)
+	v, err, _ := c.sf.Do(key, func() (any, error) {
+		resp, err := c.svc.GetIntraday(fctx, req)
+		if err != nil {
+			return nil, err
+		}
+		c.store(key, symbol, resp)
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := *v.(*entity.IntradayResponse)
+	return &resp, nil
+}
+
+func (c *IntradayCache) lookup(key string) (*entity.IntradayResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	resp := *e.resp
+	return &resp, true
+}
+
+func (c *IntradayCache) store(key, symbol string, resp *entity.IntradayResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= cacheSweepSize {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry{symbol: symbol, resp: resp, expires: now.Add(c.ttl)}
+}
+
+// invalidate drops every entry of symbol; it is an UpdateListener.
+func (c *IntradayCache) invalidate(_ context.Context, symbol string, _ *rsi.CompactRSI, _ []entity.Candle, _ []entity.BarIndicators) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if e.symbol == symbol {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// cacheKey identifies the response for req; requests differing only in
+// omitted versus default parameters get separate entries.
+func cacheKey(req entity.IntradayRequest) string {
+	var b strings.Builder
+	b.WriteString(req.Symbol)
+	for _, p := range []*float64{req.RSILow, req.RSIHigh} {
+		b.WriteByte('|')
+		if p != nil {
+			fmt.Fprintf(&b, "%g", *p)
+		}
+	}
+	b.WriteByte('|')
+	if req.Tail != nil {
+		fmt.Fprintf(&b, "%d", *req.Tail)
+	}
+	return b.String()
+}
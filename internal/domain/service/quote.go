@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+)
+
+// QuoteSource fetches latest quotes upstream. Implemented by the live feed
+// and the replay feed.
+type QuoteSource interface {
+	Quote(ctx context.Context, symbol string) (*feed.Quote, error)
+}
+
+// QuoteService serves latest quotes, cached for ttl and coalesced so a
+// burst of callers costs one upstream call per symbol.
+type QuoteService struct {
+	src     QuoteSource
+	cache   Cache
+	symbols *SymbolPolicy
+	ttl     time.Duration
+	sf      singleflight.Group
+	now     Clock
+}
+
+func NewQuoteService(src QuoteSource, cache Cache, symbols *SymbolPolicy, ttl time.Duration) *QuoteService {
+	return &QuoteService{src: src, cache: cache, symbols: symbols, ttl: ttl, now: time.Now}
+}
+
+// UseClock sets the clock for FetchedAt.
+func (s *QuoteService) UseClock(now Clock) {
+	s.now = now
+}
+
+// TTL returns how long quotes are reused.
+func (s *QuoteService) TTL() time.Duration {
+	return s.ttl
+}
+
+func quoteCacheKey(symbol string) string {
+	return "quote:{" + symbol + "}"
+}
+
+// GetQuote returns the latest quote of symbol. Upstream failures map as
+// for intraday requests, so unknown symbols are 404 SYMBOL_NOT_FOUND.
+func (s *QuoteService) GetQuote(ctx context.Context, symbol string) (*entity.Quote, error) {
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	key := quoteCacheKey(symbol)
+
+	if b, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+		var q entity.Quote
+		if json.Unmarshal(b, &q) == nil {
+			return &q, nil
+		}
+	}
+
+	// The fetch is shared by every caller waiting on key, so it must not
+	// fail because the first one went away
+	fctx := context.WithoutCancel(ctx)
+	v, err, _ := s.sf.Do(key, func() (any, error) {
+		fq, err := s.src.Quote(fctx, symbol)
+		if err != nil {
+			return nil, upstreamError(symbol, err)
+		}
+		q := &entity.Quote{
+			Symbol:        symbol,
+			Price:         fq.Price,
+			Open:          fq.Open,
+			High:          fq.High,
+			Low:           fq.Low,
+			PreviousClose: fq.PreviousClose,
+			Change:        fq.Change,
+			ChangePct:     fq.ChangePct,
+			Volume:        fq.Volume,
+			TradingDay:    fq.TradingDay.Format(time.DateOnly),
+			FetchedAt:     s.now().UTC(),
+		}
+		if b, err := json.Marshal(q); err == nil {
+			if err := s.cache.Set(fctx, key, b, s.ttl); err != nil {
+				fmt.Printf("quote cache write failed for %s: %v\n", symbol, err)
+			}
+		}
+		return q, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	q := *v.(*entity.Quote)
+	return &q, nil
+}
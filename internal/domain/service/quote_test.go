@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+)
+
+// gatedQuotes answers once release is closed, failing with the context's
+// error if it is canceled first.
+type gatedQuotes struct {
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedQuotes) Quote(ctx context.Context, symbol string) (*feed.Quote, error) {
+	g.once.Do(func() { close(g.started) })
+	select {
+	case <-g.release:
+		return &feed.Quote{Symbol: symbol, Price: 101}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestGetQuoteOutlivesFirstCaller(t *testing.T) {
+	policy, err := NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &gatedQuotes{started: make(chan struct{}), release: make(chan struct{})}
+	s := NewQuoteService(src, NewMemoryCache(), policy, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.GetQuote(ctx, "IBM")
+		first <- err
+	}()
+	<-src.started
+
+	second := make(chan error, 1)
+	go func() {
+		q, err := s.GetQuote(context.Background(), "IBM")
+		if err == nil && q.Price != 101 {
+			err = errors.New("wrong quote")
+		}
+		second <- err
+	}()
+	// Let the second caller join the flight before the first leaves
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(src.release)
+
+	if err := <-second; err != nil {
+		t.Errorf("second caller: %v", err)
+	}
+	<-first
+}
+
+func TestUpstreamErrorContext(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{context.Canceled, entity.StatusClientClosedRequest, entity.CodeClientClosed},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, entity.CodeUpstreamTimeout},
+		{errors.New("boom"), http.StatusBadGateway, entity.CodeUpstreamError},
+	}
+	for _, c := range cases {
+		ae := entity.AsAPIError(upstreamError("IBM", c.err))
+		if ae.Status != c.status || ae.Code != c.code {
+			t.Errorf("%v: got %d %s, want %d %s", c.err, ae.Status, ae.Code, c.status, c.code)
+		}
+	}
+}
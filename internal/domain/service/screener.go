@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+)
+
+const defaultScreenerLimit = 100
+
+// screenerFields maps filter and sort names to row values. Numeric fields
+// compare as float64; the rest compare as strings.
+var screenerFields = map[string]func(*entity.SymbolSnapshot) any{
+	"symbol":        func(r *entity.SymbolSnapshot) any { return r.Symbol },
+	"rsi":           func(r *entity.SymbolSnapshot) any { return r.RSI },
+	"change_pct":    func(r *entity.SymbolSnapshot) any { return r.ChangePct },
+	"last_close":    func(r *entity.SymbolSnapshot) any { return r.LastClose },
+	"rsi_count":     func(r *entity.SymbolSnapshot) any { return float64(r.RSICount) },
+	"warmup_status": func(r *entity.SymbolSnapshot) any { return r.WarmupStatus },
+	"alert":         func(r *entity.SymbolSnapshot) any { return r.Alert },
+}
+
+// Longest operators first so "<=" isn't read as "<".
+var screenerOps = []string{"<=", ">=", "!=", "<", ">", "="}
+
+type screenerFilter struct {
+	field string
+	op    string
+	num   float64
+	str   string
+}
+
+// ScreenerService filters and ranks every symbol with stored state. It
+// reads state in bulk and never calls upstream, so results are as fresh
+// as the last poll or request for each symbol.
+type ScreenerService struct {
+	stateRepo StateRepository
+}
+
+func NewScreenerService(repo StateRepository) *ScreenerService {
+	return &ScreenerService{stateRepo: repo}
+}
+
+func (s *ScreenerService) Screen(ctx context.Context, req entity.ScreenerRequest) (*entity.ScreenerResponse, error) {
+	filters := make([]screenerFilter, 0, len(req.Filters))
+	for _, raw := range req.Filters {
+		f, err := parseScreenerFilter(raw)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	sortField, desc := strings.TrimPrefix(req.Sort, "-"), strings.HasPrefix(req.Sort, "-")
+	if sortField == "" {
+		sortField = "symbol"
+	}
+	key, ok := screenerFields[sortField]
+	if !ok {
+		return nil, entity.ErrBadRequest(fmt.Sprintf("unknown sort field %q", sortField))
+	}
+
+	low, high := 30.0, 70.0
+	if req.RSILow != nil {
+		low = *req.RSILow
+	}
+	if req.RSIHigh != nil {
+		high = *req.RSIHigh
+	}
+
+	states, err := s.stateRepo.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load states: %w", err)
+	}
+
+	rows := make([]entity.SymbolSnapshot, 0, len(states))
+	for sym, st := range states {
+		if st == nil || st.Count == 0 {
+			continue
+		}
+		row := symbolSnapshot(sym, st, low, high)
+		if matchScreener(&row, filters) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		c := compareScreener(key(&rows[i]), key(&rows[j]))
+		if c == 0 {
+			return rows[i].Symbol < rows[j].Symbol
+		}
+		return (c < 0) != desc
+	})
+
+	matched := len(rows)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultScreenerLimit
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	return &entity.ScreenerResponse{
+		Matched: matched,
+		Scanned: len(states),
+		Rows:    rows,
+		AsOf:    time.Now().UTC(),
+	}, nil
+}
+
+func parseScreenerFilter(raw string) (screenerFilter, error) {
+	raw = strings.TrimSpace(raw)
+	for _, op := range screenerOps {
+		i := strings.Index(raw, op)
+		if i <= 0 {
+			continue
+		}
+		f := screenerFilter{
+			field: strings.TrimSpace(raw[:i]),
+			op:    op,
+			str:   strings.TrimSpace(raw[i+len(op):]),
+		}
+		get, ok := screenerFields[f.field]
+		if !ok {
+			return f, entity.ErrBadRequest(fmt.Sprintf("unknown filter field %q", f.field))
+		}
+		if _, numeric := get(&entity.SymbolSnapshot{}).(float64); numeric {
+			n, err := strconv.ParseFloat(f.str, 64)
+			if err != nil {
+				return f, entity.ErrBadRequest(fmt.Sprintf("filter %q: %s needs a number", raw, f.field))
+			}
+			f.num = n
+		} else {
+			if f.op != "=" && f.op != "!=" {
+				return f, entity.ErrBadRequest(fmt.Sprintf("filter %q: %s supports only = and !=", raw, f.field))
+			}
+			if f.field == "symbol" {
+				f.str = strings.ToUpper(f.str)
+			}
+		}
+		return f, nil
+	}
+	return screenerFilter{}, entity.ErrBadRequest(fmt.Sprintf("filter %q: expected <field><op><value>", raw))
+}
+
+func matchScreener(row *entity.SymbolSnapshot, filters []screenerFilter) bool {
+	for _, f := range filters {
+		var c int
+		switch v := screenerFields[f.field](row).(type) {
+		case float64:
+			c = compareScreener(v, f.num)
+		case string:
+			c = compareScreener(v, f.str)
+		}
+		var ok bool
+		switch f.op {
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		case "=":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compareScreener(a, b any) int {
+	switch av := a.(type) {
+	case float64:
+		bv := b.(float64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+// newTestScreener stores a small universe: AAA oversold and stable, BBB
+// sitting exactly on rsi 30 and warming, CCC overbought, DDD still
+// processing and EEE with no candles yet.
+func newTestScreener(t *testing.T) *ScreenerService {
+	t.Helper()
+	repo := newMemStateRepo()
+	ctx := context.Background()
+	for sym, st := range map[string]rsi.CompactRSI{
+		"AAA": {Count: 60, RSI: 25, AvgLoss: 1, LastClose: 10, ChangePct: -2},
+		"BBB": {Count: 20, RSI: 30, AvgLoss: 1, LastClose: 50, ChangePct: 1},
+		"CCC": {Count: 60, RSI: 75, AvgLoss: 1, LastClose: 30, ChangePct: 3},
+		"DDD": {Count: 5, RSI: 50, AvgLoss: 1, LastClose: 20},
+		"EEE": {},
+	} {
+		repo.Save(ctx, sym, &st)
+	}
+	return NewScreenerService(repo)
+}
+
+func symbolsOf(rows []entity.SymbolSnapshot) string {
+	syms := make([]string, len(rows))
+	for i, r := range rows {
+		syms[i] = r.Symbol
+	}
+	return strings.Join(syms, ",")
+}
+
+func TestScreenFilters(t *testing.T) {
+	s := newTestScreener(t)
+	cases := []struct {
+		filters []string
+		want    string
+	}{
+		{nil, "AAA,BBB,CCC,DDD"},
+		{[]string{"rsi<30"}, "AAA"},
+		{[]string{"rsi<=30"}, "AAA,BBB"},
+		{[]string{"rsi>=75"}, "CCC"},
+		{[]string{"rsi>50"}, "CCC"},
+		{[]string{"rsi=50"}, "DDD"},
+		{[]string{"rsi!=30"}, "AAA,CCC,DDD"},
+		{[]string{" change_pct >= 1 ", "last_close<40"}, "CCC"},
+		{[]string{"rsi_count>=20", "rsi<70"}, "AAA,BBB"},
+		{[]string{"warmup_status=stable"}, "AAA,CCC"},
+		{[]string{"warmup_status!=stable"}, "BBB,DDD"},
+		{[]string{"alert=OVERBOUGHT"}, "CCC"},
+		{[]string{"symbol=bbb"}, "BBB"},
+		{[]string{"rsi<0"}, ""},
+	}
+	for _, c := range cases {
+		resp, err := s.Screen(context.Background(), entity.ScreenerRequest{Filters: c.filters})
+		if err != nil {
+			t.Errorf("%q: %v", c.filters, err)
+			continue
+		}
+		if got := symbolsOf(resp.Rows); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.filters, got, c.want)
+		}
+		if resp.Scanned != 5 {
+			t.Errorf("%q: scanned %d, want 5", c.filters, resp.Scanned)
+		}
+	}
+}
+
+func TestScreenRejects(t *testing.T) {
+	s := newTestScreener(t)
+	cases := []struct {
+		req  entity.ScreenerRequest
+		want string
+	}{
+		{entity.ScreenerRequest{Filters: []string{"volume>10"}}, `unknown filter field "volume"`},
+		{entity.ScreenerRequest{Filters: []string{"rsi<abc"}}, "needs a number"},
+		{entity.ScreenerRequest{Filters: []string{"rsi<"}}, "needs a number"},
+		{entity.ScreenerRequest{Filters: []string{"warmup_status<stable"}}, "supports only = and !="},
+		{entity.ScreenerRequest{Filters: []string{"rsi"}}, "expected <field><op><value>"},
+		{entity.ScreenerRequest{Filters: []string{"<30"}}, "expected <field><op><value>"},
+		{entity.ScreenerRequest{Sort: "-volume"}, `unknown sort field "volume"`},
+	}
+	for _, c := range cases {
+		_, err := s.Screen(context.Background(), c.req)
+		if !isStatus(err, http.StatusBadRequest) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%+v: err = %v, want 400 containing %q", c.req, err, c.want)
+		}
+	}
+}
+
+func TestScreenSortAndLimit(t *testing.T) {
+	s := newTestScreener(t)
+	cases := []struct {
+		sort  string
+		limit int
+		want  string
+	}{
+		{"", 0, "AAA,BBB,CCC,DDD"},
+		{"-symbol", 0, "DDD,CCC,BBB,AAA"},
+		{"rsi", 0, "AAA,BBB,DDD,CCC"},
+		{"-rsi", 2, "CCC,DDD"},
+		{"last_close", 3, "AAA,DDD,CCC"},
+		{"-change_pct", 1, "CCC"},
+		// Ties break on symbol, ascending either way
+		{"rsi_count", 0, "DDD,BBB,AAA,CCC"},
+		{"-rsi_count", 0, "AAA,CCC,BBB,DDD"},
+		{"warmup_status", 0, "DDD,AAA,CCC,BBB"},
+	}
+	for _, c := range cases {
+		resp, err := s.Screen(context.Background(), entity.ScreenerRequest{Sort: c.sort, Limit: c.limit})
+		if err != nil {
+			t.Errorf("sort %q: %v", c.sort, err)
+			continue
+		}
+		if got := symbolsOf(resp.Rows); got != c.want {
+			t.Errorf("sort %q limit %d: got %q, want %q", c.sort, c.limit, got, c.want)
+		}
+		if resp.Matched != 4 {
+			t.Errorf("sort %q limit %d: matched %d, want 4 before the limit", c.sort, c.limit, resp.Matched)
+		}
+	}
+}
+
+func TestScreenThresholds(t *testing.T) {
+	s := newTestScreener(t)
+	low, high := 20.0, 80.0
+	resp, err := s.Screen(context.Background(), entity.ScreenerRequest{
+		Filters: []string{"alert!="},
+		RSILow:  &low,
+		RSIHigh: &high,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Rows) != 0 {
+		t.Errorf("alerts with 20/80 thresholds: %q, want none", symbolsOf(resp.Rows))
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+// Export formats understood by StateService.
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// StateService dumps and restores per-symbol RSI state so warmup survives
+// Redis migrations and flushes without reseeding from upstream.
+type StateService struct {
+	stateRepo StateRepository
+	symbols   *SymbolPolicy
+}
+
+func NewStateService(repo StateRepository, symbols *SymbolPolicy) *StateService {
+	return &StateService{stateRepo: repo, symbols: symbols}
+}
+
+// Export writes every stored state to w, sorted by symbol. JSON produces a
+// single array; NDJSON writes one entry per line.
+func (s *StateService) Export(ctx context.Context, w io.Writer, format string) (int, error) {
+	states, err := s.stateRepo.All(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list states: %w", err)
+	}
+
+	entries := make([]entity.StateEntry, 0, len(states))
+	for sym, st := range states {
+		entries = append(entries, entity.StateEntry{Symbol: sym, State: st})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Symbol < entries[j].Symbol })
+
+	switch format {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return 0, fmt.Errorf("encode states: %w", err)
+		}
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return 0, fmt.Errorf("encode %s: %w", e.Symbol, err)
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+	return len(entries), nil
+}
+
+// importBatch is the number of entries saved per SaveMany call.
+const importBatch = 500
+
+// Import reads a JSON array or NDJSON stream produced by Export and saves
+// every entry, overwriting existing state for those symbols. Symbols go
+// through the symbol policy and states must be well formed. Bad input is
+// reported as an APIError; it returns how many entries were saved before
+// any error.
+func (s *StateService) Import(ctx context.Context, r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read states: %w", err)
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		var entries []entity.StateEntry
+		if err := dec.Decode(&entries); err != nil {
+			return 0, entity.NewError(http.StatusBadRequest, entity.CodeInvalidJSON, "states must be a JSON array of {symbol, state} entries")
+		}
+		for start := 0; start < len(entries); start += importBatch {
+			end := min(start+importBatch, len(entries))
+			if err := s.saveBatch(ctx, entries[start:end]); err != nil {
+				return start, err
+			}
+		}
+		return len(entries), nil
+	}
+
+	n := 0
+	batch := make([]entity.StateEntry, 0, importBatch)
+	for {
+		var e entity.StateEntry
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, entity.NewError(http.StatusBadRequest, entity.CodeInvalidJSON, fmt.Sprintf("entry %d is not a valid {symbol, state} object", n+len(batch)+1))
+		}
+		batch = append(batch, e)
+		if len(batch) == importBatch {
+			if err := s.saveBatch(ctx, batch); err != nil {
+				return n, err
+			}
+			n += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := s.saveBatch(ctx, batch); err != nil {
+		return n, err
+	}
+	return n + len(batch), nil
+}
+
+func (s *StateService) saveBatch(ctx context.Context, entries []entity.StateEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	states := make(map[string]*rsi.CompactRSI, len(entries))
+	for _, e := range entries {
+		if e.Symbol == "" || e.State == nil {
+			return entity.ErrBadRequest("invalid entry: symbol and state required")
+		}
+		sym, err := s.symbols.Normalize(e.Symbol)
+		if err != nil {
+			return err
+		}
+		if err := validateState(e.State); err != nil {
+			return entity.ErrBadRequest(fmt.Sprintf("invalid state for %s: %v", sym, err))
+		}
+		states[sym] = e.State
+	}
+	if err := s.stateRepo.SaveMany(ctx, states); err != nil {
+		return fmt.Errorf("save states: %w", err)
+	}
+	return nil
+}
+
+// validateState rejects states the RSI code could not have produced.
+func validateState(st *rsi.CompactRSI) error {
+	if st.Count < 0 {
+		return fmt.Errorf("rsi_count %d is negative", st.Count)
+	}
+	fields := []struct {
+		name string
+		v    float64
+	}{
+		{"avg_gain", st.AvgGain},
+		{"avg_loss", st.AvgLoss},
+		{"last_close", st.LastClose},
+		{"prev_close", st.PrevClose},
+		{"rsi", st.RSI},
+		{"change_pct", st.ChangePct},
+	}
+	for _, f := range fields {
+		if math.IsNaN(f.v) || math.IsInf(f.v, 0) {
+			return fmt.Errorf("%s is not a finite number", f.name)
+		}
+	}
+	return nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		if _, err := br.Discard(1); err != nil {
+			return 0, err
+		}
+	}
+}
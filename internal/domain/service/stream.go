@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	// streamBacklog is how many recent updates are kept for resuming.
+	streamBacklog = 1024
+	// subscriptionBuffer is how far a subscriber may fall behind before it
+	// is dropped; it can resume from the backlog.
+	subscriptionBuffer = 64
+)
+
+// StreamHub fans state updates out to live subscribers (SSE, WebSocket)
+// and keeps a short backlog so clients can resume after reconnecting.
+type StreamHub struct {
+	symbols *SymbolPolicy
+
+	mu      sync.Mutex
+	seq     uint64
+	backlog []entity.StreamUpdate // ring, oldest at head
+	head    int
+	subs    map[*Subscription]struct{}
+
+	now Clock
+}
+
+// Subscription receives updates for a set of symbols on C. C is closed
+// when the subscriber falls too far behind or unsubscribes.
+type Subscription struct {
+	C chan entity.StreamUpdate
+	// Seq is the ID of the last update published before subscribing, to
+	// label a snapshot taken after subscribing; 0 if there was none.
+	Seq uint64
+	// Symbols are the normalized subscribed symbols.
+	Symbols []string
+	// Resumed is set when every update after the requested last ID is
+	// still in the backlog; those for Symbols are in Missed. Otherwise the
+	// subscriber needs a fresh snapshot.
+	Resumed bool
+	Missed  []entity.StreamUpdate
+
+	symbols map[string]bool
+	closed  bool
+}
+
+func NewStreamHub(symbols *SymbolPolicy) *StreamHub {
+	return &StreamHub{
+		symbols: symbols,
+		// IDs start at the clock so they keep increasing across restarts;
+		// a pre-restart ID then reads as too old and triggers a snapshot.
+		seq:  uint64(time.Now().UnixMicro()),
+		subs: make(map[*Subscription]struct{}),
+		now:  time.Now,
+	}
+}
+
+// UseClock sets the clock for update timestamps.
+func (h *StreamHub) UseClock(now Clock) {
+	h.now = now
+}
+
+// Publish records one update and delivers it to matching subscribers.
+// It matches UpdateListener so it can be registered with
+// IntradayService.OnUpdate.
+func (h *StreamHub) Publish(ctx context.Context, symbol string, st *rsi.CompactRSI, candles []entity.Candle, _ []entity.BarIndicators) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	u := entity.StreamUpdate{
+		ID:           h.seq,
+		Symbol:       symbol,
+		Candles:      candles,
+		RSI:          st.RSI,
+		ChangePct:    st.ChangePct,
+		IsValidRSI:   st.IsValid(),
+		WarmupStatus: string(st.WarmupStatus()),
+		RSICount:     st.Count,
+		UpdatedAt:    h.now().UTC(),
+	}
+
+	if len(h.backlog) < streamBacklog {
+		h.backlog = append(h.backlog, u)
+	} else {
+		h.backlog[h.head] = u
+		h.head = (h.head + 1) % streamBacklog
+	}
+
+	for sub := range h.subs {
+		if !sub.symbols[symbol] {
+			continue
+		}
+		select {
+		case sub.C <- u:
+		default:
+			h.dropLocked(sub)
+		}
+	}
+}
+
+// Subscribe registers interest in symbols (possibly none yet), resuming after lastID when it
+// is non-zero. Updates published from now on are queued on the returned
+// subscription's C.
+func (h *StreamHub) Subscribe(symbols []string, lastID uint64) (*Subscription, error) {
+	sub := &Subscription{
+		C:       make(chan entity.StreamUpdate, subscriptionBuffer),
+		symbols: make(map[string]bool, len(symbols)),
+	}
+	normalized, err := h.normalize(symbols)
+	if err != nil {
+		return nil, err
+	}
+	for _, sym := range normalized {
+		sub.symbols[sym] = true
+	}
+	sub.Symbols = normalized
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[sub] = struct{}{}
+	if len(h.backlog) > 0 {
+		sub.Seq = h.seq
+	}
+
+	if lastID == 0 || lastID > h.seq {
+		return sub, nil
+	}
+	n := len(h.backlog)
+	if n > 0 && lastID < h.backlog[h.head].ID-1 {
+		return sub, nil
+	}
+	if n == 0 && lastID != h.seq {
+		return sub, nil
+	}
+	sub.Resumed = true
+	for i := 0; i < n; i++ {
+		u := h.backlog[(h.head+i)%n]
+		if u.ID > lastID && sub.symbols[u.Symbol] {
+			sub.Missed = append(sub.Missed, u)
+		}
+	}
+	return sub, nil
+}
+
+// Add subscribes sub to more symbols. It returns them normalized, and
+// which of those were not already subscribed. Nothing is added if any
+// symbol is invalid.
+func (h *StreamHub) Add(sub *Subscription, symbols []string) (normalized, added []string, err error) {
+	normalized, err = h.normalize(symbols)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, sym := range normalized {
+		if !sub.symbols[sym] {
+			sub.symbols[sym] = true
+			sub.Symbols = append(sub.Symbols, sym)
+			added = append(added, sym)
+		}
+	}
+	return normalized, added, nil
+}
+
+// Remove unsubscribes sub from symbols and returns the normalized ones
+// that were subscribed.
+func (h *StreamHub) Remove(sub *Subscription, symbols []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var removed []string
+	for _, raw := range symbols {
+		sym, err := h.symbols.Normalize(raw)
+		if err != nil || !sub.symbols[sym] {
+			continue
+		}
+		delete(sub.symbols, sym)
+		removed = append(removed, sym)
+	}
+	if len(removed) > 0 {
+		kept := sub.Symbols[:0]
+		for _, sym := range sub.Symbols {
+			if sub.symbols[sym] {
+				kept = append(kept, sym)
+			}
+		}
+		sub.Symbols = kept
+	}
+	return removed
+}
+
+// Unsubscribe removes sub and closes its channel. Safe to call twice.
+func (h *StreamHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropLocked(sub)
+}
+
+// Close ends every subscription, letting long-lived stream handlers
+// return on server shutdown.
+func (h *StreamHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		h.dropLocked(sub)
+	}
+}
+
+func (h *StreamHub) dropLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subs, sub)
+	close(sub.C)
+}
+
+// normalize validates symbols and drops duplicates, keeping order.
+func (h *StreamHub) normalize(symbols []string) ([]string, error) {
+	seen := make(map[string]bool, len(symbols))
+	out := make([]string, 0, len(symbols))
+	for _, raw := range symbols {
+		sym, err := h.symbols.Normalize(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[sym] {
+			seen[sym] = true
+			out = append(out, sym)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"marketpulse/pkg/rsi"
+)
+
+func newTestHub(t *testing.T) *StreamHub {
+	t.Helper()
+	policy, err := NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStreamHub(policy)
+}
+
+// publish publishes one update per symbol and returns their IDs.
+func publish(h *StreamHub, symbols ...string) []uint64 {
+	ids := make([]uint64, len(symbols))
+	for i, sym := range symbols {
+		h.Publish(context.Background(), sym, &rsi.CompactRSI{Count: 20}, nil, nil)
+		ids[i] = h.seq
+	}
+	return ids
+}
+
+func TestSubscribeResume(t *testing.T) {
+	h := newTestHub(t)
+	ids := publish(h, "IBM", "AAPL", "IBM", "MSFT", "IBM")
+	last := ids[len(ids)-1]
+
+	cases := []struct {
+		name    string
+		lastID  uint64
+		resumed bool
+		missed  []uint64
+	}{
+		{"no last ID", 0, false, nil},
+		{"mid backlog", ids[1], true, []uint64{ids[2], ids[4]}},
+		{"up to date", last, true, nil},
+		{"just before the backlog", ids[0] - 1, true, []uint64{ids[0], ids[2], ids[4]}},
+		{"older than the backlog", ids[0] - 2, false, nil},
+		{"from the future", last + 1, false, nil},
+	}
+	for _, c := range cases {
+		sub, err := h.Subscribe([]string{"ibm"}, c.lastID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.Seq != last {
+			t.Errorf("%s: seq = %d, want %d", c.name, sub.Seq, last)
+		}
+		if sub.Resumed != c.resumed {
+			t.Errorf("%s: resumed = %v, want %v", c.name, sub.Resumed, c.resumed)
+		}
+		var missed []uint64
+		for _, u := range sub.Missed {
+			if u.Symbol != "IBM" {
+				t.Errorf("%s: missed an update for %s", c.name, u.Symbol)
+			}
+			missed = append(missed, u.ID)
+		}
+		if !slices.Equal(missed, c.missed) {
+			t.Errorf("%s: missed %v, want %v", c.name, missed, c.missed)
+		}
+		h.Unsubscribe(sub)
+	}
+}
+
+func TestSubscribeResumeAfterWrap(t *testing.T) {
+	h := newTestHub(t)
+	first := publish(h, "IBM")[0]
+	for i := 0; i < streamBacklog+9; i++ {
+		publish(h, "AAPL")
+	}
+	ids := publish(h, "IBM")
+	oldest := h.backlog[h.head].ID
+
+	// The first IBM update has been overwritten
+	sub, _ := h.Subscribe([]string{"IBM"}, first)
+	if sub.Resumed {
+		t.Error("resumed past an overwritten update")
+	}
+	sub, _ = h.Subscribe([]string{"IBM"}, oldest-1)
+	if !sub.Resumed || len(sub.Missed) != 1 || sub.Missed[0].ID != ids[0] {
+		t.Errorf("resumed = %v, missed = %+v, want the last IBM update", sub.Resumed, sub.Missed)
+	}
+}
+
+// Before anything is published the backlog is empty; only the hub's
+// starting ID resumes (nothing was missed), anything else needs a snapshot.
+func TestSubscribeResumeEmptyBacklog(t *testing.T) {
+	h := newTestHub(t)
+	if sub, _ := h.Subscribe([]string{"IBM"}, h.seq); !sub.Resumed || sub.Seq != 0 {
+		t.Errorf("resumed = %v seq = %d, want resumed with seq 0", sub.Resumed, sub.Seq)
+	}
+	if sub, _ := h.Subscribe([]string{"IBM"}, h.seq-1); sub.Resumed {
+		t.Error("resumed from before the hub started")
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	h := newTestHub(t)
+	sub, _ := h.Subscribe([]string{"IBM"}, 0)
+	other, _ := h.Subscribe([]string{"AAPL"}, 0)
+	for i := 0; i <= subscriptionBuffer; i++ {
+		publish(h, "IBM")
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriptionBuffer {
+		t.Errorf("received %d updates before the close, want %d", n, subscriptionBuffer)
+	}
+	select {
+	case _, ok := <-other.C:
+		t.Errorf("other subscriber got an update or was closed (open=%v)", ok)
+	default:
+	}
+	// Unsubscribing twice is safe
+	h.Unsubscribe(other)
+	h.Unsubscribe(other)
+	h.Unsubscribe(sub)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"marketpulse/internal/domain/entity"
+)
+
+// SymbolPolicy normalizes and vets symbols before they reach the upstream
+// or the state store, so typos and junk input don't leave keys behind.
+type SymbolPolicy struct {
+	pattern *regexp.Regexp
+	allow   map[string]bool
+	deny    map[string]bool
+}
+
+// NewSymbolPolicy compiles pattern and builds the allow/deny sets. An
+// empty allowlist allows every symbol that matches the pattern and is
+// not denied.
+func NewSymbolPolicy(pattern string, allowlist, denylist []string) (*SymbolPolicy, error) {
+	p := &SymbolPolicy{
+		allow: toSymbolSet(allowlist),
+		deny:  toSymbolSet(denylist),
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("symbol pattern: %w", err)
+		}
+		p.pattern = re
+	}
+	return p, nil
+}
+
+// Normalize trims and upper-cases symbol, then checks it against the
+// policy. Rejections are entity.APIError values (400 INVALID_SYMBOL for
+// malformed, 403 SYMBOL_NOT_ALLOWED for denied).
+func (p *SymbolPolicy) Normalize(symbol string) (string, error) {
+	sym := strings.ToUpper(strings.TrimSpace(symbol))
+	if sym == "" {
+		return "", entity.NewError(http.StatusBadRequest, entity.CodeInvalidSymbol, "symbol required")
+	}
+	if p == nil {
+		return sym, nil
+	}
+	if p.pattern != nil && !p.pattern.MatchString(sym) {
+		return "", entity.NewError(http.StatusBadRequest, entity.CodeInvalidSymbol, fmt.Sprintf("invalid symbol %q", symbol))
+	}
+	if p.deny[sym] {
+		return "", entity.NewError(http.StatusForbidden, entity.CodeSymbolNotAllowed, fmt.Sprintf("symbol %s is not allowed", sym))
+	}
+	if len(p.allow) > 0 && !p.allow[sym] {
+		return "", entity.NewError(http.StatusForbidden, entity.CodeSymbolNotAllowed, fmt.Sprintf("symbol %s is not allowed", sym))
+	}
+	return sym, nil
+}
+
+func toSymbolSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
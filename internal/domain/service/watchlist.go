@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	maxWatchlists       = 50
+	maxWatchlistSymbols = 200
+	snapshotSeedWorkers = 4
+)
+
+// HashStore is the key/field store used for small per-user documents.
+// Implemented by Redis hashes and by the file store.
+type HashStore interface {
+	HGet(ctx context.Context, key, field string) ([]byte, bool, error)
+	HGetAll(ctx context.Context, key string) (map[string][]byte, error)
+	HSet(ctx context.Context, key, field string, value []byte) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+// WatchlistService manages per-user watchlists, stored as one hash per
+// user keyed by watchlist ID.
+type WatchlistService struct {
+	store    HashStore
+	states   StateRepository
+	intraday *IntradayService
+	symbols  *SymbolPolicy
+
+	// Serializes read-modify-write cycles on a user's lists, per user.
+	locks keyedMutex
+}
+
+func NewWatchlistService(store HashStore, states StateRepository, intraday *IntradayService, symbols *SymbolPolicy) *WatchlistService {
+	return &WatchlistService{store: store, states: states, intraday: intraday, symbols: symbols}
+}
+
+func watchlistKey(userID string) string {
+	return "user:{" + userID + "}:watchlists"
+}
+
+// List returns the user's watchlists ordered by creation time.
+func (s *WatchlistService) List(ctx context.Context, userID string) ([]entity.Watchlist, error) {
+	data, err := s.store.HGetAll(ctx, watchlistKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load watchlists: %w", err)
+	}
+
+	out := make([]entity.Watchlist, 0, len(data))
+	for id, b := range data {
+		var wl entity.Watchlist
+		if err := json.Unmarshal(b, &wl); err != nil {
+			return nil, fmt.Errorf("decode watchlist %s: %w", id, err)
+		}
+		out = append(out, wl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *WatchlistService) Get(ctx context.Context, userID, id string) (*entity.Watchlist, error) {
+	b, ok, err := s.store.HGet(ctx, watchlistKey(userID), id)
+	if err != nil {
+		return nil, fmt.Errorf("load watchlist: %w", err)
+	}
+	if !ok {
+		return nil, entity.ErrNotFound("watchlist not found")
+	}
+	var wl entity.Watchlist
+	if err := json.Unmarshal(b, &wl); err != nil {
+		return nil, fmt.Errorf("decode watchlist %s: %w", id, err)
+	}
+	return &wl, nil
+}
+
+func (s *WatchlistService) Create(ctx context.Context, userID string, req entity.WatchlistRequest) (*entity.Watchlist, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, entity.ErrBadRequest("name required")
+	}
+	symbols, err := s.normalizeSymbols(req.Symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.locks.lock(watchlistKey(userID))()
+
+	existing, err := s.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWatchlists {
+		return nil, entity.ErrLimitExceeded(fmt.Sprintf("at most %d watchlists per user", maxWatchlists))
+	}
+
+	now := time.Now().UTC()
+	wl := &entity.Watchlist{
+		ID:        newID(),
+		Name:      name,
+		Symbols:   symbols,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.put(ctx, userID, wl); err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+func (s *WatchlistService) Rename(ctx context.Context, userID, id, name string) (*entity.Watchlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, entity.ErrBadRequest("name required")
+	}
+	return s.update(ctx, userID, id, func(wl *entity.Watchlist) error {
+		wl.Name = name
+		return nil
+	})
+}
+
+func (s *WatchlistService) Delete(ctx context.Context, userID, id string) error {
+	defer s.locks.lock(watchlistKey(userID))()
+
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.store.HDel(ctx, watchlistKey(userID), id)
+}
+
+// AddSymbol appends symbol to the list; adding a present symbol is a no-op.
+func (s *WatchlistService) AddSymbol(ctx context.Context, userID, id, symbol string) (*entity.Watchlist, error) {
+	sym, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, userID, id, func(wl *entity.Watchlist) error {
+		for _, existing := range wl.Symbols {
+			if existing == sym {
+				return nil
+			}
+		}
+		if len(wl.Symbols) >= maxWatchlistSymbols {
+			return entity.ErrLimitExceeded(fmt.Sprintf("at most %d symbols per watchlist", maxWatchlistSymbols))
+		}
+		wl.Symbols = append(wl.Symbols, sym)
+		return nil
+	})
+}
+
+func (s *WatchlistService) RemoveSymbol(ctx context.Context, userID, id, symbol string) (*entity.Watchlist, error) {
+	sym := strings.ToUpper(strings.TrimSpace(symbol))
+	return s.update(ctx, userID, id, func(wl *entity.Watchlist) error {
+		for i, existing := range wl.Symbols {
+			if existing == sym {
+				wl.Symbols = append(wl.Symbols[:i], wl.Symbols[i+1:]...)
+				return nil
+			}
+		}
+		return entity.ErrNotFound(fmt.Sprintf("symbol %s not in watchlist", sym))
+	})
+}
+
+// Reorder replaces the symbol order. symbols must be a permutation of the
+// current list.
+func (s *WatchlistService) Reorder(ctx context.Context, userID, id string, symbols []string) (*entity.Watchlist, error) {
+	return s.update(ctx, userID, id, func(wl *entity.Watchlist) error {
+		if len(symbols) != len(wl.Symbols) {
+			return entity.ErrBadRequest("order must list every symbol exactly once")
+		}
+		current := make(map[string]bool, len(wl.Symbols))
+		for _, sym := range wl.Symbols {
+			current[sym] = true
+		}
+		ordered := make([]string, 0, len(symbols))
+		for _, sym := range symbols {
+			sym = strings.ToUpper(strings.TrimSpace(sym))
+			if !current[sym] {
+				return entity.ErrBadRequest("order must list every symbol exactly once")
+			}
+			delete(current, sym)
+			ordered = append(ordered, sym)
+		}
+		wl.Symbols = ordered
+		return nil
+	})
+}
+
+// Snapshot returns RSI, change%, warmup status and alert for every symbol
+// of a watchlist. State is read in bulk; only symbols with no state yet
+// are seeded from upstream.
+func (s *WatchlistService) Snapshot(ctx context.Context, userID, id string, low, high float64) (*entity.WatchlistSnapshot, error) {
+	wl, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.states.GetMany(ctx, wl.Symbols)
+	if err != nil {
+		return nil, fmt.Errorf("load states: %w", err)
+	}
+
+	rows := make([]entity.SymbolSnapshot, len(wl.Symbols))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(snapshotSeedWorkers)
+	for i, sym := range wl.Symbols {
+		if st, ok := states[sym]; ok && st.Count > 0 {
+			rows[i] = symbolSnapshot(sym, st, low, high)
+			continue
+		}
+		g.Go(func() error {
+			resp, err := s.intraday.GetIntraday(gctx, entity.IntradayRequest{Symbol: sym, RSILow: &low, RSIHigh: &high})
+			if err != nil {
+				ae := entity.AsAPIError(err)
+				rows[i] = entity.SymbolSnapshot{Symbol: sym, WarmupStatus: string(rsi.Processing), Error: &ae}
+				return nil
+			}
+			rows[i] = snapshotFromResponse(resp)
+			return nil
+		})
+	}
+	g.Wait()
+
+	return &entity.WatchlistSnapshot{
+		ID:      wl.ID,
+		Name:    wl.Name,
+		Symbols: rows,
+		AsOf:    time.Now().UTC(),
+	}, nil
+}
+
+// update applies fn to a stored watchlist and writes it back.
+func (s *WatchlistService) update(ctx context.Context, userID, id string, fn func(*entity.Watchlist) error) (*entity.Watchlist, error) {
+	defer s.locks.lock(watchlistKey(userID))()
+
+	wl, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(wl); err != nil {
+		return nil, err
+	}
+	wl.UpdatedAt = time.Now().UTC()
+	if err := s.put(ctx, userID, wl); err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+func (s *WatchlistService) put(ctx context.Context, userID string, wl *entity.Watchlist) error {
+	b, err := json.Marshal(wl)
+	if err != nil {
+		return fmt.Errorf("encode watchlist: %w", err)
+	}
+	if err := s.store.HSet(ctx, watchlistKey(userID), wl.ID, b); err != nil {
+		return fmt.Errorf("save watchlist: %w", err)
+	}
+	return nil
+}
+
+func (s *WatchlistService) normalizeSymbols(in []string) ([]string, error) {
+	if len(in) > maxWatchlistSymbols {
+		return nil, entity.ErrLimitExceeded(fmt.Sprintf("at most %d symbols per watchlist", maxWatchlistSymbols))
+	}
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, raw := range in {
+		sym, err := s.symbols.Normalize(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[sym] {
+			seen[sym] = true
+			out = append(out, sym)
+		}
+	}
+	return out, nil
+}
+
+// symbolSnapshot summarizes stored state without touching upstream.
+func symbolSnapshot(symbol string, st *rsi.CompactRSI, low, high float64) entity.SymbolSnapshot {
+	return entity.SymbolSnapshot{
+		Symbol:       symbol,
+		RSI:          st.RSI,
+		ChangePct:    st.ChangePct,
+		LastClose:    st.LastClose,
+		LastTs:       st.LastTs,
+		Alert:        rsi.CheckAlert(st.RSI, low, high),
+		IsValidRSI:   st.IsValid(),
+		WarmupStatus: string(st.WarmupStatus()),
+		RSICount:     st.Count,
+	}
+}
+
+func snapshotFromResponse(resp *entity.IntradayResponse) entity.SymbolSnapshot {
+	row := entity.SymbolSnapshot{
+		Symbol:       resp.Symbol,
+		RSI:          resp.RSI,
+		ChangePct:    resp.ChangePct,
+		Alert:        resp.Alert,
+		IsValidRSI:   resp.IsValidRSI,
+		WarmupStatus: resp.WarmupStatus,
+		RSICount:     resp.RSICount,
+	}
+	if n := len(resp.Candles); n > 0 {
+		last := resp.Candles[n-1]
+		row.LastClose = last.Close
+		row.LastTs = last.Timestamp
+	}
+	return row
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
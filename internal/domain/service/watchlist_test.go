@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+func newTestWatchlists(t *testing.T, store HashStore, fd CandleFeed) (*WatchlistService, *memStateRepo) {
+	t.Helper()
+	policy, err := NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := newMemStateRepo()
+	intraday := NewIntradayService(states, fd, policy, nil)
+	return NewWatchlistService(store, states, intraday, policy), states
+}
+
+func createList(t *testing.T, s *WatchlistService, user, name string, symbols ...string) *entity.Watchlist {
+	t.Helper()
+	wl, err := s.Create(context.Background(), user, entity.WatchlistRequest{Name: name, Symbols: symbols})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wl
+}
+
+func TestWatchlistCRUD(t *testing.T) {
+	s, _ := newTestWatchlists(t, newMemHashStore(), nil)
+	ctx := context.Background()
+
+	wl := createList(t, s, "u1", " tech ", "ibm", "AAPL", "IBM")
+	if wl.Name != "tech" || fmt.Sprint(wl.Symbols) != "[IBM AAPL]" {
+		t.Fatalf("created %+v, want trimmed name and deduped upper-case symbols", wl)
+	}
+	createList(t, s, "u1", "banks", "JPM")
+
+	lists, err := s.List(ctx, "u1")
+	if err != nil || len(lists) != 2 || lists[0].ID != wl.ID {
+		t.Fatalf("list = %+v, %v; want both, oldest first", lists, err)
+	}
+	if other, _ := s.List(ctx, "u2"); len(other) != 0 {
+		t.Errorf("u2 sees %d of u1's lists", len(other))
+	}
+
+	if got, err := s.Rename(ctx, "u1", wl.ID, "megacaps"); err != nil || got.Name != "megacaps" {
+		t.Errorf("rename = %+v, %v", got, err)
+	}
+	if got, err := s.AddSymbol(ctx, "u1", wl.ID, "msft"); err != nil || fmt.Sprint(got.Symbols) != "[IBM AAPL MSFT]" {
+		t.Errorf("add = %+v, %v", got, err)
+	}
+	if got, err := s.AddSymbol(ctx, "u1", wl.ID, "MSFT"); err != nil || len(got.Symbols) != 3 {
+		t.Errorf("adding a present symbol = %+v, %v; want a no-op", got, err)
+	}
+	if got, err := s.RemoveSymbol(ctx, "u1", wl.ID, "aapl"); err != nil || fmt.Sprint(got.Symbols) != "[IBM MSFT]" {
+		t.Errorf("remove = %+v, %v", got, err)
+	}
+	if _, err := s.RemoveSymbol(ctx, "u1", wl.ID, "AAPL"); !isStatus(err, http.StatusNotFound) {
+		t.Errorf("removing a missing symbol err = %v, want 404", err)
+	}
+
+	if _, err := s.Get(ctx, "u2", wl.ID); !isStatus(err, http.StatusNotFound) {
+		t.Errorf("u2 reading u1's list err = %v, want 404", err)
+	}
+	if err := s.Delete(ctx, "u1", wl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "u1", wl.ID); !isStatus(err, http.StatusNotFound) {
+		t.Errorf("get after delete err = %v, want 404", err)
+	}
+	if _, err := s.Create(ctx, "u1", entity.WatchlistRequest{Name: "  "}); !isStatus(err, http.StatusBadRequest) {
+		t.Errorf("blank name err = %v, want 400", err)
+	}
+}
+
+func TestWatchlistReorder(t *testing.T) {
+	s, _ := newTestWatchlists(t, newMemHashStore(), nil)
+	wl := createList(t, s, "u1", "tech", "IBM", "AAPL", "MSFT")
+
+	cases := []struct {
+		name  string
+		order []string
+		want  string
+	}{
+		{"permutation", []string{"msft", "IBM", "aapl"}, "[MSFT IBM AAPL]"},
+		{"missing one", []string{"MSFT", "IBM"}, ""},
+		{"duplicate", []string{"MSFT", "IBM", "IBM"}, ""},
+		{"unknown", []string{"MSFT", "IBM", "TSLA"}, ""},
+	}
+	for _, c := range cases {
+		got, err := s.Reorder(context.Background(), "u1", wl.ID, c.order)
+		if c.want == "" {
+			if !isStatus(err, http.StatusBadRequest) {
+				t.Errorf("%s: err = %v, want 400", c.name, err)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got.Symbols) != c.want {
+			t.Errorf("%s: got %v, %v; want %s", c.name, got.Symbols, err, c.want)
+		}
+	}
+	// Rejected orders leave the list alone
+	if got, _ := s.Get(context.Background(), "u1", wl.ID); fmt.Sprint(got.Symbols) != "[MSFT IBM AAPL]" {
+		t.Errorf("stored order %v", got.Symbols)
+	}
+}
+
+func TestWatchlistLimits(t *testing.T) {
+	s, _ := newTestWatchlists(t, newMemHashStore(), nil)
+	ctx := context.Background()
+
+	for i := 0; i < maxWatchlists; i++ {
+		createList(t, s, "u1", fmt.Sprintf("list %d", i))
+	}
+	if _, err := s.Create(ctx, "u1", entity.WatchlistRequest{Name: "one too many"}); !isStatus(err, http.StatusBadRequest) {
+		t.Errorf("list %d err = %v, want limit exceeded", maxWatchlists+1, err)
+	}
+
+	symbols := make([]string, maxWatchlistSymbols+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%d", i)
+	}
+	if _, err := s.Create(ctx, "u2", entity.WatchlistRequest{Name: "big", Symbols: symbols}); !isStatus(err, http.StatusBadRequest) {
+		t.Errorf("create with %d symbols err = %v, want limit exceeded", len(symbols), err)
+	}
+	wl := createList(t, s, "u2", "full", symbols[:maxWatchlistSymbols]...)
+	if _, err := s.AddSymbol(ctx, "u2", wl.ID, "ONEMORE"); !isStatus(err, http.StatusBadRequest) {
+		t.Errorf("add past the limit err = %v, want limit exceeded", err)
+	}
+}
+
+func TestWatchlistConcurrentAdds(t *testing.T) {
+	s, _ := newTestWatchlists(t, newMemHashStore(), nil)
+	wl := createList(t, s, "u1", "tech")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.AddSymbol(context.Background(), "u1", wl.ID, fmt.Sprintf("S%d", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := s.Get(context.Background(), "u1", wl.ID); len(got.Symbols) != 20 {
+		t.Errorf("%d symbols after 20 concurrent adds", len(got.Symbols))
+	}
+}
+
+func TestWatchlistLocksPerUser(t *testing.T) {
+	store := &blockingStore{
+		memHashStore: newMemHashStore(),
+		key:          watchlistKey("u1"),
+		entered:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	s, _ := newTestWatchlists(t, store, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Create(context.Background(), "u1", entity.WatchlistRequest{Name: "stuck"})
+	}()
+	<-store.entered
+
+	// u1's create is stuck in store I/O; u2 must not wait for it
+	done := make(chan struct{})
+	go func() {
+		createList(t, s, "u2", "free")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("u2 blocked behind u1")
+	}
+	close(store.release)
+	wg.Wait()
+}
+
+// failingFeed fails every fetch of one symbol.
+type failingFeed struct {
+	CandleFeed
+	symbol string
+}
+
+func (f failingFeed) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]feed.Candle, error) {
+	if symbol == f.symbol {
+		return nil, errors.New("upstream exploded")
+	}
+	return f.CandleFeed.FetchIntraday(ctx, symbol, since)
+}
+
+func TestWatchlistSnapshot(t *testing.T) {
+	fd := newStubFeed(ramp(100, 1, 20)...)
+	s, states := newTestWatchlists(t, newMemHashStore(), failingFeed{fd, "BAD"})
+	ctx := context.Background()
+	// IBM is warm and oversold; AAPL is cold and seeded on demand
+	states.Save(ctx, "IBM", &rsi.CompactRSI{Count: 60, RSI: 25, AvgLoss: 1, LastClose: 90, LastTs: time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC)})
+	wl := createList(t, s, "u1", "mixed", "IBM", "AAPL", "BAD")
+
+	snap, err := s.Snapshot(ctx, "u1", wl.ID, 30, 70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Symbols) != 3 {
+		t.Fatalf("got %d rows, want 3 in list order", len(snap.Symbols))
+	}
+	ibm, aapl, bad := snap.Symbols[0], snap.Symbols[1], snap.Symbols[2]
+	if ibm.Symbol != "IBM" || ibm.Alert != "OVERSOLD" || ibm.LastClose != 90 || ibm.Error != nil {
+		t.Errorf("IBM row %+v", ibm)
+	}
+	if n := fd.seedHits.Load(); n != 1 {
+		t.Errorf("%d history fetches, want only AAPL's", n)
+	}
+	if aapl.Symbol != "AAPL" || aapl.RSICount == 0 || aapl.LastClose != 119 || aapl.Error != nil {
+		t.Errorf("AAPL row %+v", aapl)
+	}
+	if bad.Symbol != "BAD" || bad.Error == nil || bad.Error.Code != entity.CodeUpstreamError || bad.Error.Status != http.StatusBadGateway {
+		t.Errorf("BAD row %+v, want an upstream error envelope", bad)
+	}
+}
@@ -0,0 +1,569 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/webhook"
+)
+
+const (
+	maxWebhooks          = 10
+	maxWebhookDeliveries = 500
+	maxDeadLetters       = 500
+
+	webhookEventAlert = "alert.fired"
+
+	webhookMaxBackoff = 5 * time.Minute
+)
+
+// WebhookSender POSTs one signed delivery. Returns the HTTP status (0 if
+// no response) and an error for transport failures and non-2xx replies.
+type WebhookSender interface {
+	Send(ctx context.Context, url, secret, deliveryID, event string, body []byte) (int, error)
+}
+
+// WebhookOptions tunes delivery. Zero values pick defaults.
+type WebhookOptions struct {
+	Workers     int
+	MaxAttempts int
+	QueueSize   int
+	// BaseBackoff is the wait before the first retry; it doubles per
+	// attempt up to 5m.
+	BaseBackoff time.Duration
+	// AllowPrivate accepts URLs on loopback, private and link-local
+	// addresses. For tests and local development only.
+	AllowPrivate bool
+}
+
+// WebhookService delivers fired alerts to user-registered URLs. Each
+// delivery is retried with exponential backoff up to MaxAttempts; the
+// final failure moves it to the user's dead-letter list, from which it
+// can be replayed. Every attempt updates the user's delivery log.
+type WebhookService struct {
+	store  HashStore
+	sender WebhookSender
+	opts   WebhookOptions
+
+	// Fired alerts waiting to be fanned out to webhooks, and deliveries
+	// waiting for an attempt. Both are drained by the workers.
+	events chan entity.AlertEvent
+	queue  chan *entity.WebhookDelivery
+
+	// Trims the delivery logs and dead-letter lists.
+	logs pruner
+
+	// Serializes webhook registration read-modify-write.
+	mu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// Deliveries waiting for their next attempt, keyed by delivery ID.
+	retryMu  sync.Mutex
+	retrying map[string]*pendingRetry
+}
+
+type pendingRetry struct {
+	timer *time.Timer
+	d     *entity.WebhookDelivery
+}
+
+func NewWebhookService(store HashStore, sender WebhookSender, opts WebhookOptions) *WebhookService {
+	if opts.Workers < 1 {
+		opts.Workers = 2
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 5
+	}
+	if opts.QueueSize < 1 {
+		opts.QueueSize = 1000
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 2 * time.Second
+	}
+	return &WebhookService{
+		store:    store,
+		sender:   sender,
+		opts:     opts,
+		events:   make(chan entity.AlertEvent, opts.QueueSize),
+		queue:    make(chan *entity.WebhookDelivery, opts.QueueSize),
+		retrying: make(map[string]*pendingRetry),
+	}
+}
+
+func webhooksKey(userID string) string {
+	return "user:{" + userID + "}:webhooks"
+}
+
+func deliveriesKey(userID string) string {
+	return "user:{" + userID + "}:webhook_deliveries"
+}
+
+func deadLettersKey(userID string) string {
+	return "user:{" + userID + "}:webhook_dead"
+}
+
+// Create registers a webhook. A secret is generated when none is given;
+// this is the only response that includes it.
+func (s *WebhookService) Create(ctx context.Context, userID string, req entity.WebhookRequest) (*entity.Webhook, error) {
+	target := strings.TrimSpace(req.URL)
+	if err := s.validateURL(ctx, target); err != nil {
+		return nil, err
+	}
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		secret = newID() + newID()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.store.HGetAll(ctx, webhooksKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load webhooks: %w", err)
+	}
+	if len(existing) >= maxWebhooks {
+		return nil, entity.ErrLimitExceeded(fmt.Sprintf("at most %d webhooks per user", maxWebhooks))
+	}
+
+	wh := &entity.Webhook{
+		ID:        newID(),
+		URL:       target,
+		Secret:    secret,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.put(ctx, userID, wh); err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+// List returns the user's webhooks without secrets, oldest first.
+func (s *WebhookService) List(ctx context.Context, userID string) ([]entity.Webhook, error) {
+	hooks, err := s.list(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+func (s *WebhookService) SetEnabled(ctx context.Context, userID, id string, enabled bool) (*entity.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wh, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wh.Enabled = enabled
+	if err := s.put(ctx, userID, wh); err != nil {
+		return nil, err
+	}
+	wh.Secret = ""
+	return wh, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.store.HDel(ctx, webhooksKey(userID), id)
+}
+
+// Deliveries returns the user's delivery log, newest first.
+func (s *WebhookService) Deliveries(ctx context.Context, userID string) ([]entity.WebhookDelivery, error) {
+	return s.loadDeliveries(ctx, deliveriesKey(userID))
+}
+
+// DeadLetters returns deliveries that exhausted their retries, newest first.
+func (s *WebhookService) DeadLetters(ctx context.Context, userID string) ([]entity.WebhookDelivery, error) {
+	return s.loadDeliveries(ctx, deadLettersKey(userID))
+}
+
+// Replay removes a dead letter and queues it again with a fresh attempt
+// budget, sent to the webhook's current URL and secret.
+func (s *WebhookService) Replay(ctx context.Context, userID, id string) (*entity.WebhookDelivery, error) {
+	b, ok, err := s.store.HGet(ctx, deadLettersKey(userID), id)
+	if err != nil {
+		return nil, fmt.Errorf("load dead letter: %w", err)
+	}
+	if !ok {
+		return nil, entity.ErrNotFound("dead letter not found")
+	}
+	var d entity.WebhookDelivery
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("decode dead letter %s: %w", id, err)
+	}
+	if err := s.store.HDel(ctx, deadLettersKey(userID), id); err != nil {
+		return nil, fmt.Errorf("remove dead letter: %w", err)
+	}
+
+	d.Status = entity.DeliveryPending
+	d.Attempts = 0
+	d.ResponseCode = 0
+	d.LastError = ""
+	d.UpdatedAt = time.Now().UTC()
+	s.logDelivery(ctx, &d)
+	s.submit(&d)
+	return &d, nil
+}
+
+// Enqueue schedules delivery of ev to every enabled webhook of its owner.
+// Registered as an AlertService fire listener, so it only queues ev: the
+// workers look up the webhooks and log the deliveries. A full queue
+// drops ev.
+func (s *WebhookService) Enqueue(_ context.Context, ev entity.AlertEvent) {
+	if s.ctx != nil && s.ctx.Err() != nil {
+		// Stopped: nobody drains the queue, dead-letter right away
+		s.fanOut(context.Background(), ev)
+		return
+	}
+	select {
+	case s.events <- ev:
+	default:
+		fmt.Printf("webhook event queue full, dropped %s for %s\n", ev.ID, ev.UserID)
+	}
+}
+
+// fanOut logs and submits one delivery of ev per enabled webhook.
+func (s *WebhookService) fanOut(ctx context.Context, ev entity.AlertEvent) {
+	hooks, err := s.list(ctx, ev.UserID)
+	if err != nil {
+		fmt.Printf("webhooks for %s: %v\n", ev.UserID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, wh := range hooks {
+		if !wh.Enabled {
+			continue
+		}
+		id := timeID(now)
+		payload, err := json.Marshal(entity.WebhookPayload{
+			ID:        id,
+			Type:      webhookEventAlert,
+			CreatedAt: now,
+			Data:      ev,
+		})
+		if err != nil {
+			fmt.Printf("encode webhook payload for %s: %v\n", wh.ID, err)
+			continue
+		}
+		d := &entity.WebhookDelivery{
+			ID:        id,
+			UserID:    ev.UserID,
+			WebhookID: wh.ID,
+			EventID:   ev.ID,
+			URL:       wh.URL,
+			Payload:   payload,
+			Status:    entity.DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		s.logDelivery(ctx, d)
+		s.submit(d)
+	}
+}
+
+// Start launches the delivery workers. Deliveries enqueued before Start
+// wait in the queue.
+func (s *WebhookService) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for range s.opts.Workers {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for {
+				select {
+				case <-s.ctx.Done():
+					return
+				case ev := <-s.events:
+					s.fanOut(s.ctx, ev)
+				case d := <-s.queue:
+					s.attempt(s.ctx, d)
+				}
+			}
+		}()
+	}
+}
+
+// Stop cancels in-flight attempts and moves every undelivered delivery
+// (queued, awaiting retry or of a queued event) to its dead-letter list
+// so it can be replayed after restart.
+func (s *WebhookService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.retryMu.Lock()
+	pending := make([]*entity.WebhookDelivery, 0, len(s.retrying))
+	for id, r := range s.retrying {
+		if r.timer.Stop() {
+			pending = append(pending, r.d)
+		}
+		delete(s.retrying, id)
+	}
+	s.retryMu.Unlock()
+
+	// Events fan out straight to dead letters now that s.ctx is done
+drainEvents:
+	for {
+		select {
+		case ev := <-s.events:
+			s.fanOut(ctx, ev)
+		default:
+			break drainEvents
+		}
+	}
+drain:
+	for {
+		select {
+		case d := <-s.queue:
+			pending = append(pending, d)
+		default:
+			break drain
+		}
+	}
+	for _, d := range pending {
+		s.deadLetter(ctx, d, "shutdown before delivery")
+	}
+}
+
+// submit queues d without blocking. A full queue or a stopped service
+// dead-letters it instead.
+func (s *WebhookService) submit(d *entity.WebhookDelivery) {
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.deadLetter(context.Background(), d, "shutdown before delivery")
+		return
+	}
+	select {
+	case s.queue <- d:
+	default:
+		s.deadLetter(context.Background(), d, "delivery queue full")
+	}
+}
+
+// attempt makes one delivery attempt and schedules the next on failure.
+// A webhook that can't be loaded counts as a failed attempt, unless it
+// was deleted.
+func (s *WebhookService) attempt(ctx context.Context, d *entity.WebhookDelivery) {
+	wh, err := s.get(ctx, d.UserID, d.WebhookID)
+	var apiErr entity.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound:
+		s.deadLetter(ctx, d, "webhook removed")
+		return
+	case err != nil && ctx.Err() != nil:
+		s.deadLetter(context.Background(), d, "shutdown before delivery")
+		return
+	case err != nil:
+		d.Attempts++
+		d.UpdatedAt = time.Now().UTC()
+		s.retryOrDeadLetter(ctx, d, err)
+		return
+	case !wh.Enabled:
+		s.deadLetter(ctx, d, "webhook disabled")
+		return
+	}
+
+	d.Attempts++
+	d.URL = wh.URL
+	code, err := s.sender.Send(ctx, wh.URL, wh.Secret, d.ID, webhookEventAlert, d.Payload)
+	if ctx.Err() != nil {
+		// Shutting down: don't count an attempt that was cut short.
+		d.Attempts--
+		s.deadLetter(context.Background(), d, "shutdown before delivery")
+		return
+	}
+	d.ResponseCode = code
+	d.UpdatedAt = time.Now().UTC()
+
+	if err == nil {
+		d.Status = entity.DeliveryDelivered
+		d.LastError = ""
+		s.logDelivery(ctx, d)
+		return
+	}
+	s.retryOrDeadLetter(ctx, d, err)
+}
+
+// retryOrDeadLetter records a failed attempt and schedules the next one,
+// or dead-letters d once its attempts are used up.
+func (s *WebhookService) retryOrDeadLetter(ctx context.Context, d *entity.WebhookDelivery, err error) {
+	d.LastError = err.Error()
+	if d.Attempts >= s.opts.MaxAttempts {
+		s.deadLetter(ctx, d, d.LastError)
+		return
+	}
+	s.logDelivery(ctx, d)
+	s.scheduleRetry(d)
+}
+
+func (s *WebhookService) scheduleRetry(d *entity.WebhookDelivery) {
+	s.retryMu.Lock()
+	defer s.retryMu.Unlock()
+
+	s.retrying[d.ID] = &pendingRetry{
+		d: d,
+		timer: time.AfterFunc(retryBackoff(s.opts.BaseBackoff, d.Attempts), func() {
+			s.retryMu.Lock()
+			delete(s.retrying, d.ID)
+			s.retryMu.Unlock()
+			s.submit(d)
+		}),
+	}
+}
+
+// retryBackoff returns the wait after the given number of failed attempts:
+// base, 2*base, 4*base, ... capped at webhookMaxBackoff.
+func retryBackoff(base time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < webhookMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, webhookMaxBackoff)
+}
+
+func (s *WebhookService) deadLetter(ctx context.Context, d *entity.WebhookDelivery, reason string) {
+	d.Status = entity.DeliveryFailed
+	d.LastError = reason
+	d.UpdatedAt = time.Now().UTC()
+	s.logDelivery(ctx, d)
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		fmt.Printf("encode dead letter %s: %v\n", d.ID, err)
+		return
+	}
+	key := deadLettersKey(d.UserID)
+	if err := s.store.HSet(ctx, key, d.ID, b); err != nil {
+		fmt.Printf("dead letter %s: %v\n", d.ID, err)
+		return
+	}
+	if err := s.logs.wrote(ctx, s.store, key, maxDeadLetters); err != nil {
+		fmt.Printf("prune dead letters for %s: %v\n", d.UserID, err)
+	}
+}
+
+// logDelivery upserts d in the user's delivery log.
+func (s *WebhookService) logDelivery(ctx context.Context, d *entity.WebhookDelivery) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		fmt.Printf("encode delivery %s: %v\n", d.ID, err)
+		return
+	}
+	key := deliveriesKey(d.UserID)
+	if err := s.store.HSet(ctx, key, d.ID, b); err != nil {
+		fmt.Printf("log delivery %s: %v\n", d.ID, err)
+		return
+	}
+	if err := s.logs.wrote(ctx, s.store, key, maxWebhookDeliveries); err != nil {
+		fmt.Printf("prune deliveries for %s: %v\n", d.UserID, err)
+	}
+}
+
+func (s *WebhookService) loadDeliveries(ctx context.Context, key string) ([]entity.WebhookDelivery, error) {
+	all, err := s.store.HGetAll(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("load deliveries: %w", err)
+	}
+	out := make([]entity.WebhookDelivery, 0, len(all))
+	for id, b := range all {
+		var d entity.WebhookDelivery
+		if err := json.Unmarshal(b, &d); err != nil {
+			return nil, fmt.Errorf("decode delivery %s: %w", id, err)
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+func (s *WebhookService) list(ctx context.Context, userID string) ([]entity.Webhook, error) {
+	all, err := s.store.HGetAll(ctx, webhooksKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("load webhooks: %w", err)
+	}
+	out := make([]entity.Webhook, 0, len(all))
+	for id, b := range all {
+		var wh entity.Webhook
+		if err := json.Unmarshal(b, &wh); err != nil {
+			return nil, fmt.Errorf("decode webhook %s: %w", id, err)
+		}
+		out = append(out, wh)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *WebhookService) get(ctx context.Context, userID, id string) (*entity.Webhook, error) {
+	b, ok, err := s.store.HGet(ctx, webhooksKey(userID), id)
+	if err != nil {
+		return nil, fmt.Errorf("load webhook: %w", err)
+	}
+	if !ok {
+		return nil, entity.ErrNotFound("webhook not found")
+	}
+	var wh entity.Webhook
+	if err := json.Unmarshal(b, &wh); err != nil {
+		return nil, fmt.Errorf("decode webhook %s: %w", id, err)
+	}
+	return &wh, nil
+}
+
+func (s *WebhookService) put(ctx context.Context, userID string, wh *entity.Webhook) error {
+	b, err := json.Marshal(wh)
+	if err != nil {
+		return fmt.Errorf("encode webhook: %w", err)
+	}
+	if err := s.store.HSet(ctx, webhooksKey(userID), wh.ID, b); err != nil {
+		return fmt.Errorf("save webhook: %w", err)
+	}
+	return nil
+}
+
+// validateURL accepts absolute http(s) URLs whose host resolves only to
+// public addresses. The sender checks again at dial time, since DNS may
+// change after registration.
+func (s *WebhookService) validateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return entity.ErrBadRequest("url must be an absolute http(s) URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return entity.ErrBadRequest("url must be an absolute http(s) URL")
+	}
+	if s.opts.AllowPrivate {
+		return nil
+	}
+	if err := webhook.CheckHost(ctx, u.Hostname()); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			return entity.ErrBadRequest("url must not point at a private, loopback or link-local address")
+		}
+		return entity.ErrBadRequest("url host does not resolve")
+	}
+	return nil
+}
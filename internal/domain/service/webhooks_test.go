@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/infra/webhook"
+)
+
+// receiver is an httptest webhook endpoint that verifies signatures,
+// answers the first failures deliveries with 500 and later ones with 204.
+type receiver struct {
+	srv      *httptest.Server
+	secret   string
+	calls    atomic.Int32
+	badSigs  atomic.Int32
+	failures atomic.Int32
+}
+
+func newReceiver(t *testing.T, secret string, failures int) *receiver {
+	rc := &receiver{secret: secret}
+	rc.failures.Store(int32(failures))
+	rc.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get(webhook.TimestampHeader)
+		want := "sha256=" + webhook.Sign(rc.secret, ts, body)
+		if r.Header.Get(webhook.SignatureHeader) != want || r.Header.Get(webhook.DeliveryHeader) == "" {
+			rc.badSigs.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if rc.failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(rc.srv.Close)
+	return rc
+}
+
+func newTestWebhookService(t *testing.T, maxAttempts int) (*WebhookService, *memHashStore) {
+	store := newMemHashStore()
+	return startWebhookService(t, store, maxAttempts), store
+}
+
+func startWebhookService(t *testing.T, store HashStore, maxAttempts int) *WebhookService {
+	svc := NewWebhookService(store, webhook.NewSender(2*time.Second, true), WebhookOptions{
+		Workers:      1,
+		MaxAttempts:  maxAttempts,
+		BaseBackoff:  10 * time.Millisecond,
+		AllowPrivate: true,
+	})
+	svc.Start(context.Background())
+	t.Cleanup(svc.Stop)
+	return svc
+}
+
+func register(t *testing.T, svc *WebhookService, url, secret string) *entity.Webhook {
+	t.Helper()
+	wh, err := svc.Create(context.Background(), "u1", entity.WebhookRequest{URL: url, Secret: secret})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	return wh
+}
+
+// waitDelivery polls the delivery log until its only entry satisfies done.
+func waitDelivery(t *testing.T, svc *WebhookService, done func(entity.WebhookDelivery) bool) entity.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		ds, err := svc.Deliveries(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("deliveries: %v", err)
+		}
+		if len(ds) == 1 && done(ds[0]) {
+			return ds[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for delivery")
+	return entity.WebhookDelivery{}
+}
+
+func fire(svc *WebhookService) {
+	svc.Enqueue(context.Background(), entity.AlertEvent{ID: "ev1", UserID: "u1", Symbol: "IBM", Type: "rsi_above"})
+}
+
+func TestWebhookDeliverySigned(t *testing.T) {
+	rc := newReceiver(t, "s3cret", 0)
+	svc, _ := newTestWebhookService(t, 3)
+	register(t, svc, rc.srv.URL, "s3cret")
+
+	fire(svc)
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryDelivered })
+	if d.Attempts != 1 || d.ResponseCode != http.StatusNoContent {
+		t.Errorf("attempts=%d code=%d, want 1 and 204", d.Attempts, d.ResponseCode)
+	}
+	if n := rc.badSigs.Load(); n != 0 {
+		t.Errorf("receiver rejected %d signatures", n)
+	}
+}
+
+func TestWebhookWrongSecretFails(t *testing.T) {
+	rc := newReceiver(t, "receiver-secret", 0)
+	svc, _ := newTestWebhookService(t, 1)
+	register(t, svc, rc.srv.URL, "other-secret")
+
+	fire(svc)
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryFailed })
+	if d.ResponseCode != http.StatusUnauthorized || rc.badSigs.Load() != 1 {
+		t.Errorf("code=%d badSigs=%d, want 401 and 1", d.ResponseCode, rc.badSigs.Load())
+	}
+}
+
+func TestWebhookRetriesThenDelivers(t *testing.T) {
+	rc := newReceiver(t, "s", 2)
+	svc, _ := newTestWebhookService(t, 5)
+	register(t, svc, rc.srv.URL, "s")
+
+	fire(svc)
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryDelivered })
+	if d.Attempts != 3 || rc.calls.Load() != 3 {
+		t.Errorf("attempts=%d calls=%d, want 3 and 3", d.Attempts, rc.calls.Load())
+	}
+	if dead, _ := svc.DeadLetters(context.Background(), "u1"); len(dead) != 0 {
+		t.Errorf("dead letters = %d, want 0", len(dead))
+	}
+}
+
+func TestWebhookDeadLetterAndReplay(t *testing.T) {
+	rc := newReceiver(t, "s", 2)
+	svc, _ := newTestWebhookService(t, 2)
+	register(t, svc, rc.srv.URL, "s")
+
+	fire(svc)
+	waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryFailed })
+	dead, err := svc.DeadLetters(context.Background(), "u1")
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %d, %v; want 1", len(dead), err)
+	}
+	if dead[0].Attempts != 2 || dead[0].ResponseCode != http.StatusInternalServerError {
+		t.Errorf("dead letter attempts=%d code=%d, want 2 and 500", dead[0].Attempts, dead[0].ResponseCode)
+	}
+
+	// The receiver has used up its failures; the replay succeeds.
+	if _, err := svc.Replay(context.Background(), "u1", dead[0].ID); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryDelivered })
+	if d.ID != dead[0].ID || d.Attempts != 1 {
+		t.Errorf("replayed id=%s attempts=%d, want %s and 1", d.ID, d.Attempts, dead[0].ID)
+	}
+	if dead, _ := svc.DeadLetters(context.Background(), "u1"); len(dead) != 0 {
+		t.Errorf("dead letters after replay = %d, want 0", len(dead))
+	}
+	if _, err := svc.Replay(context.Background(), "u1", d.ID); !isStatus(err, http.StatusNotFound) {
+		t.Errorf("second replay err = %v, want 404", err)
+	}
+}
+
+// flakyWebhooks fails webhook lookups until failures runs out.
+type flakyWebhooks struct {
+	*memHashStore
+	failures atomic.Int32
+}
+
+func (f *flakyWebhooks) HGet(ctx context.Context, key, field string) ([]byte, bool, error) {
+	if key == webhooksKey("u1") && f.failures.Add(-1) >= 0 {
+		return nil, false, errors.New("connection reset")
+	}
+	return f.memHashStore.HGet(ctx, key, field)
+}
+
+func TestWebhookRetriesStoreErrors(t *testing.T) {
+	rc := newReceiver(t, "s", 0)
+	store := &flakyWebhooks{memHashStore: newMemHashStore()}
+	store.failures.Store(2)
+	svc := startWebhookService(t, store, 5)
+	register(t, svc, rc.srv.URL, "s")
+
+	// Two failed lookups are retried with backoff, not dead-lettered
+	fire(svc)
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryDelivered })
+	if d.Attempts != 3 || rc.calls.Load() != 1 {
+		t.Errorf("attempts=%d calls=%d, want 3 and 1", d.Attempts, rc.calls.Load())
+	}
+	if dead, _ := svc.DeadLetters(context.Background(), "u1"); len(dead) != 0 {
+		t.Errorf("dead letters = %d, want 0", len(dead))
+	}
+}
+
+func TestWebhookDeletedIsDeadLettered(t *testing.T) {
+	rc := newReceiver(t, "s", 100)
+	svc, _ := newTestWebhookService(t, 5)
+	wh := register(t, svc, rc.srv.URL, "s")
+
+	fire(svc)
+	waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Attempts == 1 })
+	if err := svc.Delete(context.Background(), "u1", wh.ID); err != nil {
+		t.Fatal(err)
+	}
+	d := waitDelivery(t, svc, func(d entity.WebhookDelivery) bool { return d.Status == entity.DeliveryFailed })
+	if d.LastError != "webhook removed" || d.Attempts >= 5 {
+		t.Errorf("error=%q after %d attempts, want webhook removed", d.LastError, d.Attempts)
+	}
+}
+
+func TestWebhookEnqueueDoesNoStoreIO(t *testing.T) {
+	store := &blockingStore{
+		memHashStore: newMemHashStore(),
+		key:          webhooksKey("u1"),
+		entered:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	svc := startWebhookService(t, store, 1)
+	defer close(store.release)
+
+	// The worker blocks listing webhooks; the alert path must not
+	done := make(chan struct{})
+	go func() {
+		fire(svc)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Enqueue blocked on the store")
+	}
+	<-store.entered
+}
+
+func TestRetryBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{8, 256 * time.Second},
+		{9, webhookMaxBackoff},
+		{50, webhookMaxBackoff},
+	}
+	for _, c := range cases {
+		if got := retryBackoff(2*time.Second, c.attempts); got != c.want {
+			t.Errorf("retryBackoff(2s, %d) = %v, want %v", c.attempts, got, c.want)
+		}
+	}
+}
+
+func TestWebhookCreateRejectsInternalURLs(t *testing.T) {
+	svc := NewWebhookService(newMemHashStore(), webhook.NewSender(time.Second, false), WebhookOptions{})
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"ftp://example.com/hook",
+	} {
+		_, err := svc.Create(context.Background(), "u1", entity.WebhookRequest{URL: u})
+		if !isStatus(err, http.StatusBadRequest) {
+			t.Errorf("Create(%s) err = %v, want 400", u, err)
+		}
+	}
+	if _, err := svc.Create(context.Background(), "u1", entity.WebhookRequest{URL: "https://93.184.215.14/hook"}); err != nil {
+		t.Errorf("Create(public IP) err = %v", err)
+	}
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr entity.APIError
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
@@ -1,9 +1,12 @@
 package feed
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
@@ -19,6 +22,16 @@ const (
 	timeout     = 8 * time.Second
 )
 
+// Interval is the upstream candle interval.
+const Interval = "5min"
+
+// Upstream failures callers may want to tell apart. Errors returned by the
+// feeds wrap one of these when they apply.
+var (
+	ErrRateLimited   = errors.New("upstream rate limited")
+	ErrUnknownSymbol = errors.New("unknown symbol")
+)
+
 type Candle struct {
 	Timestamp time.Time
 	Open      float64
@@ -43,6 +56,16 @@ type avResponse struct {
 	Series   map[string]map[string]avCandle `json:"-"`
 	Error    string                      `json:"error,omitempty"`
 	Note     string                      `json:"Note,omitempty"`
+	// Invalid is AV's "Error Message", sent for unknown symbols.
+	Invalid string `json:"Error Message,omitempty"`
+}
+
+// avStatus holds the error fields AV may put in any response.
+type avStatus struct {
+	Error       string `json:"error"`
+	Note        string `json:"Note"`
+	Information string `json:"Information"`
+	Invalid     string `json:"Error Message"`
 }
 
 func (r *avResponse) UnmarshalJSON(b []byte) error {
@@ -61,6 +84,12 @@ func (r *avResponse) UnmarshalJSON(b []byte) error {
 	if v, ok := raw["Note"]; ok {
 		_ = json.Unmarshal(v, &r.Note)
 	}
+	if v, ok := raw["Information"]; ok && r.Note == "" {
+		_ = json.Unmarshal(v, &r.Note)
+	}
+	if v, ok := raw["Error Message"]; ok {
+		_ = json.Unmarshal(v, &r.Invalid)
+	}
 
 	// Find the "Time Series (...)" key dynamically (e.g., "Time Series (5min)")
 	for k, v := range raw {
@@ -96,40 +125,20 @@ func NewClient(cfg *config.Config) *Client {
 }
 
 func (f *Client) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]Candle, error) {
-	if f == nil || f.client == nil {
-		return nil, fmt.Errorf("feed client not initialized")
-	}
-
-	<-f.limiter.C
-
-	u := fmt.Sprintf(
-		"%s/query?function=TIME_SERIES_INTRADAY&symbol=%s&interval=5min&apikey=demo&tail=%d",
-		f.baseURL,
-		url.QueryEscape(symbol),
-		tailCandles,
-	)
-
-	resp, err := f.client.R().
-		SetContext(ctx).
-		SetHeader("Accept", "application/json").
-		Get(u)
+	body, err := f.query(ctx, symbol, url.Values{
+		"function": {"TIME_SERIES_INTRADAY"},
+		"symbol":   {symbol},
+		"interval": {Interval},
+		"tail":     {strconv.Itoa(tailCandles)},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("feed request: %w", err)
-	}
-	if resp.StatusCode() >= 400 {
-		return nil, fmt.Errorf("upstream %d: %s", resp.StatusCode(), resp.String())
+		return nil, err
 	}
 
 	var data avResponse
-	if err := json.Unmarshal(resp.Body(), &data); err != nil {
+	if err := json.Unmarshal(body, &data); err != nil {
 		return nil, fmt.Errorf("json decode: %w", err)
 	}
-	if data.Error != "" {
-		return nil, fmt.Errorf("upstream error: %s", data.Error)
-	}
-	if data.Note != "" {
-		return nil, fmt.Errorf("upstream note: %s", data.Note)
-	}
 	if len(data.Series) == 0 {
 		return []Candle{}, nil
 	}
@@ -178,3 +187,47 @@ func (f *Client) FetchIntraday(ctx context.Context, symbol string, since time.Ti
 
 	return out, nil
 }
+
+// query calls upstream with params, pacing calls through the shared
+// limiter, and maps HTTP and AV error payloads to the package errors.
+// symbol only labels errors.
+func (f *Client) query(ctx context.Context, symbol string, params url.Values) ([]byte, error) {
+	if f == nil || f.client == nil {
+		return nil, fmt.Errorf("feed client not initialized")
+	}
+
+	<-f.limiter.C
+
+	params.Set("apikey", "demo")
+	resp, err := f.client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		Get(f.baseURL + "/query?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("feed request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: upstream %d", ErrRateLimited, resp.StatusCode())
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, fmt.Errorf("%w %s: upstream %d", ErrUnknownSymbol, symbol, resp.StatusCode())
+	case resp.StatusCode() >= 400:
+		return nil, fmt.Errorf("upstream %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	var status avStatus
+	if err := json.Unmarshal(resp.Body(), &status); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+	if status.Invalid != "" {
+		return nil, fmt.Errorf("%w %s: %s", ErrUnknownSymbol, symbol, status.Invalid)
+	}
+	if status.Error != "" {
+		return nil, fmt.Errorf("upstream error: %s", status.Error)
+	}
+	if note := cmp.Or(status.Note, status.Information); note != "" {
+		// AV reports throttling as a 200 with a Note.
+		return nil, fmt.Errorf("%w: %s", ErrRateLimited, note)
+	}
+	return resp.Body(), nil
+}
@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quote is the latest trade summary of a symbol for its trading day.
+type Quote struct {
+	Symbol        string
+	Price         float64
+	Open          float64
+	High          float64
+	Low           float64
+	PreviousClose float64
+	Change        float64
+	ChangePct     float64
+	Volume        int64
+	TradingDay    time.Time
+}
+
+// AlphaVantage-like GLOBAL_QUOTE payload (strings); unknown symbols get
+// an empty object.
+type avQuote struct {
+	Quote struct {
+		Symbol        string `json:"01. symbol"`
+		Open          string `json:"02. open"`
+		High          string `json:"03. high"`
+		Low           string `json:"04. low"`
+		Price         string `json:"05. price"`
+		Volume        string `json:"06. volume"`
+		TradingDay    string `json:"07. latest trading day"`
+		PreviousClose string `json:"08. previous close"`
+		Change        string `json:"09. change"`
+		ChangePct     string `json:"10. change percent"`
+	} `json:"Global Quote"`
+}
+
+// Quote returns the latest quote of symbol, or an error wrapping
+// ErrUnknownSymbol when upstream has none.
+func (f *Client) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	body, err := f.query(ctx, symbol, url.Values{
+		"function": {"GLOBAL_QUOTE"},
+		"symbol":   {symbol},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data avQuote
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+	q := data.Quote
+	if q.Symbol == "" {
+		return nil, fmt.Errorf("%w %s: no quote", ErrUnknownSymbol, symbol)
+	}
+
+	var errs []error
+	num := func(s string) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return v
+	}
+	out := &Quote{
+		Symbol:        q.Symbol,
+		Price:         num(q.Price),
+		Open:          num(q.Open),
+		High:          num(q.High),
+		Low:           num(q.Low),
+		PreviousClose: num(q.PreviousClose),
+		Change:        num(q.Change),
+		ChangePct:     num(q.ChangePct),
+	}
+	vol, err := strconv.ParseInt(q.Volume, 10, 64)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	out.Volume = vol
+	day, err := time.ParseInLocation(time.DateOnly, q.TradingDay, time.UTC)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	out.TradingDay = day
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("quote %s: malformed field: %w", symbol, errs[0])
+	}
+	return out, nil
+}
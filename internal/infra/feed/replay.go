@@ -0,0 +1,244 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// replayCandle is the on-disk candle format, the same keys the API uses.
+type replayCandle struct {
+	Timestamp time.Time `json:"ts"`
+	Open      float64   `json:"o"`
+	High      float64   `json:"h"`
+	Low       float64   `json:"l"`
+	Close     float64   `json:"c"`
+	Volume    int64     `json:"v"`
+}
+
+// Replay serves a recorded dataset as if it were upstream, on a virtual
+// clock that runs speed times faster than wall time. Only candles at or
+// before the virtual now are visible, so the rest of the service sees
+// bars "close" one after another.
+type Replay struct {
+	series map[string][]Candle // chronological
+	first  time.Time           // earliest candle in the dataset
+	start  time.Time           // virtual time at wallStart
+	cycle  time.Duration       // dataset length plus one bar; 0 = no loop
+	speed  float64
+	wall   time.Time
+	now    func() time.Time
+}
+
+// NewReplay loads a dataset file: a JSON object mapping symbol to an array
+// of {"ts","o","h","l","c","v"} candles. The virtual clock starts warmup
+// bars into the dataset so RSI is already warm on first request. With loop
+// set, the dataset repeats end to end with timestamps shifted forward.
+func NewReplay(path string, speed float64, warmup int, loop bool) (*Replay, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be > 0")
+	}
+	series, err := LoadDataset(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replay{series: series, speed: speed, now: time.Now}
+	var last time.Time
+	var gaps []time.Duration
+	for _, candles := range series {
+		if r.first.IsZero() || candles[0].Timestamp.Before(r.first) {
+			r.first = candles[0].Timestamp
+		}
+		if end := candles[len(candles)-1].Timestamp; end.After(last) {
+			last = end
+		}
+		for i := 1; i < len(candles); i++ {
+			gaps = append(gaps, candles[i].Timestamp.Sub(candles[i-1].Timestamp))
+		}
+	}
+	if len(r.series) == 0 {
+		return nil, fmt.Errorf("replay dataset %s has no candles", path)
+	}
+
+	var interval time.Duration
+	if len(gaps) > 0 {
+		sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+		interval = gaps[len(gaps)/2]
+	}
+	r.start = r.first.Add(time.Duration(warmup) * interval)
+	if loop {
+		r.cycle = last.Sub(r.first) + max(interval, time.Second)
+	}
+	r.wall = r.now()
+	return r, nil
+}
+
+// LoadDataset reads a replay dataset, dropping empty series. Series are
+// returned chronological, keyed by upper-case symbol.
+func LoadDataset(path string) (map[string][]Candle, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read replay dataset: %w", err)
+	}
+	var raw map[string][]replayCandle
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("decode replay dataset: %w", err)
+	}
+
+	series := make(map[string][]Candle, len(raw))
+	for sym, rows := range raw {
+		if len(rows) == 0 {
+			continue
+		}
+		candles := make([]Candle, len(rows))
+		for i, c := range rows {
+			candles[i] = Candle{Timestamp: c.Timestamp.UTC(), Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+		}
+		sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp.Before(candles[j].Timestamp) })
+		series[strings.ToUpper(sym)] = candles
+	}
+	return series, nil
+}
+
+// WriteDataset writes series in the format LoadDataset reads, each series
+// chronological with duplicate timestamps removed.
+func WriteDataset(w io.Writer, series map[string][]Candle) error {
+	raw := make(map[string][]replayCandle, len(series))
+	for sym, candles := range series {
+		sorted := append([]Candle(nil), candles...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+		rows := make([]replayCandle, 0, len(sorted))
+		for i, c := range sorted {
+			if i > 0 && c.Timestamp.Equal(sorted[i-1].Timestamp) {
+				continue
+			}
+			rows = append(rows, replayCandle{Timestamp: c.Timestamp, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume})
+		}
+		raw[sym] = rows
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(raw)
+}
+
+// Now returns the current virtual time.
+func (r *Replay) Now() time.Time {
+	elapsed := r.now().Sub(r.wall)
+	return r.start.Add(time.Duration(float64(elapsed) * r.speed))
+}
+
+// FetchIntraday mirrors Client.FetchIntraday: candles newer than since and
+// not after the virtual now, newest first, at most tailCandles.
+func (r *Replay) FetchIntraday(ctx context.Context, symbol string, since time.Time) ([]Candle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	candles, ok := r.series[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("%w %s: not in replay dataset", ErrUnknownSymbol, symbol)
+	}
+
+	now := r.Now()
+	top := 0
+	if r.cycle > 0 && now.After(r.first) {
+		top = int(now.Sub(r.first) / r.cycle)
+	}
+
+	out := make([]Candle, 0, tailCandles)
+	for k := top; k >= 0; k-- {
+		shift := time.Duration(k) * r.cycle
+		for i := len(candles) - 1; i >= 0; i-- {
+			c := candles[i]
+			c.Timestamp = c.Timestamp.Add(shift)
+			if c.Timestamp.After(now) {
+				continue
+			}
+			if !since.IsZero() && !c.Timestamp.After(since) || len(out) == tailCandles {
+				return out, nil
+			}
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// SearchSymbols matches keywords against the dataset's symbols: exact
+// matches first, then prefixes. Replays carry no reference data beyond
+// the symbol itself.
+func (r *Replay) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	q := strings.ToUpper(strings.TrimSpace(keywords))
+	var out []SymbolMatch
+	for sym := range r.series {
+		if strings.HasPrefix(sym, q) {
+			out = append(out, SymbolMatch{Symbol: sym, Name: sym, MatchScore: float64(len(q)) / float64(len(sym))})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].MatchScore != out[j].MatchScore {
+			return out[i].MatchScore > out[j].MatchScore
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out, nil
+}
+
+// Overview reports symbols of the dataset as known, with the symbol as
+// their name.
+func (r *Replay) Overview(ctx context.Context, symbol string) (*Overview, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sym := strings.ToUpper(symbol)
+	if _, ok := r.series[sym]; !ok {
+		return nil, fmt.Errorf("%w %s: not in replay dataset", ErrUnknownSymbol, symbol)
+	}
+	return &Overview{Symbol: sym, Name: sym}, nil
+}
+
+// Quote summarizes the visible candles of the virtual now's last trading
+// day (UTC), like Client.Quote. The previous close is the last close of
+// the day before, or 0 when the visible data starts that day.
+func (r *Replay) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	candles, err := r.FetchIntraday(ctx, symbol, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("%w %s: no bars yet", ErrUnknownSymbol, symbol)
+	}
+
+	// candles are newest first
+	last := candles[0]
+	day := last.Timestamp.UTC().Truncate(24 * time.Hour)
+	q := &Quote{
+		Symbol:     strings.ToUpper(symbol),
+		Price:      last.Close,
+		High:       last.High,
+		Low:        last.Low,
+		TradingDay: day,
+	}
+	for _, c := range candles {
+		if c.Timestamp.Before(day) {
+			q.PreviousClose = c.Close
+			break
+		}
+		q.Open = c.Open
+		q.High = max(q.High, c.High)
+		q.Low = min(q.Low, c.Low)
+		q.Volume += c.Volume
+	}
+	if q.PreviousClose != 0 {
+		q.Change = q.Price - q.PreviousClose
+		q.ChangePct = q.Change / q.PreviousClose * 100
+	}
+	return q, nil
+}
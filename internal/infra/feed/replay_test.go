@@ -0,0 +1,159 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The fixture has IBM every 5 minutes from 14:30 to 14:55 with closes
+// 100 to 105 (one row out of order), AAPL at 14:35 and 14:40, and an
+// empty series.
+const replayFixture = "testdata/replay.json"
+
+var replayT0 = time.Date(2024, 3, 6, 14, 30, 0, 0, time.UTC)
+
+// newTestReplay opens the fixture on a wall clock the test moves with
+// advance.
+func newTestReplay(t *testing.T, speed float64, warmup int, loop bool) (*Replay, func(time.Duration)) {
+	t.Helper()
+	r, err := NewReplay(replayFixture, speed, warmup, loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wall := r.wall
+	r.now = func() time.Time { return wall }
+	return r, func(d time.Duration) { wall = wall.Add(d) }
+}
+
+func closes(candles []Candle) []float64 {
+	out := make([]float64, len(candles))
+	for i, c := range candles {
+		out[i] = c.Close
+	}
+	return out
+}
+
+func TestReplayClock(t *testing.T) {
+	r, advance := newTestReplay(t, 60, 2, false)
+	// Two 5-minute bars of warmup
+	if got, want := r.Now(), replayT0.Add(10*time.Minute); !got.Equal(want) {
+		t.Fatalf("start = %v, want %v", got, want)
+	}
+	// 60x: five wall seconds are five virtual minutes
+	advance(5 * time.Second)
+	if got, want := r.Now(), replayT0.Add(15*time.Minute); !got.Equal(want) {
+		t.Errorf("after 5s = %v, want %v", got, want)
+	}
+
+	slow, advance := newTestReplay(t, 0.5, 0, false)
+	advance(time.Minute)
+	if got, want := slow.Now(), replayT0.Add(30*time.Second); !got.Equal(want) {
+		t.Errorf("half speed after 1m = %v, want %v", got, want)
+	}
+}
+
+func TestReplayFetchIntraday(t *testing.T) {
+	r, advance := newTestReplay(t, 60, 2, false)
+	ctx := context.Background()
+
+	// Warmup bars and the one closing at the virtual now are visible,
+	// newest first
+	got, err := r.FetchIntraday(ctx, "ibm", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := closes(got); len(s) != 3 || s[0] != 102 || s[2] != 100 {
+		t.Errorf("closes at start = %v, want [102 101 100]", s)
+	}
+
+	advance(10 * time.Second)
+	got, _ = r.FetchIntraday(ctx, "IBM", replayT0.Add(10*time.Minute))
+	if s := closes(got); len(s) != 2 || s[0] != 104 || s[1] != 103 {
+		t.Errorf("closes since 14:40 = %v, want [104 103]", s)
+	}
+
+	// Past the end the series stops without a loop
+	advance(time.Hour)
+	got, _ = r.FetchIntraday(ctx, "IBM", time.Time{})
+	if len(got) != 6 || !got[0].Timestamp.Equal(replayT0.Add(25*time.Minute)) {
+		t.Errorf("got %d candles ending %v, want all 6 ending 14:55", len(got), got[0].Timestamp)
+	}
+
+	if _, err := r.FetchIntraday(ctx, "MSFT", time.Time{}); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("unknown symbol err = %v", err)
+	}
+	if _, err := r.FetchIntraday(ctx, "EMPTY", time.Time{}); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("empty series err = %v, want it dropped as unknown", err)
+	}
+}
+
+func TestReplayLoop(t *testing.T) {
+	r, advance := newTestReplay(t, 60, 0, true)
+	// The cycle is the dataset's 25 minutes plus one 5-minute bar
+	if r.cycle != 30*time.Minute {
+		t.Fatalf("cycle = %v, want 30m", r.cycle)
+	}
+
+	advance(35 * time.Second) // virtual 15:05, second pass at its 14:35 bar
+	got, err := r.FetchIntraday(context.Background(), "IBM", replayT0.Add(20*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{replayT0.Add(35 * time.Minute), replayT0.Add(30 * time.Minute), replayT0.Add(25 * time.Minute)}
+	if len(got) != len(want) {
+		t.Fatalf("got %d candles, want %d", len(got), len(want))
+	}
+	for i, c := range got {
+		if !c.Timestamp.Equal(want[i]) {
+			t.Errorf("candle %d at %v, want %v", i, c.Timestamp, want[i])
+		}
+	}
+	if s := closes(got); s[0] != 101 || s[1] != 100 || s[2] != 105 {
+		t.Errorf("closes = %v, want the second pass [101 100] after the first's 105", s)
+	}
+}
+
+func TestNewReplayRejects(t *testing.T) {
+	if _, err := NewReplay(replayFixture, 0, 0, false); err == nil {
+		t.Error("speed 0 accepted")
+	}
+	path := filepath.Join(t.TempDir(), "empty.json")
+	os.WriteFile(path, []byte(`{"IBM": []}`), 0o644)
+	if _, err := NewReplay(path, 1, 0, false); err == nil {
+		t.Error("dataset without candles accepted")
+	}
+}
+
+func TestWriteDataset(t *testing.T) {
+	at := func(min int, c float64) Candle {
+		return Candle{Timestamp: replayT0.Add(time.Duration(min) * time.Minute), Close: c, Volume: 1}
+	}
+	in := map[string][]Candle{
+		// Out of order, with a bar recorded twice across fetches
+		"IBM": {at(10, 102), at(0, 100), at(5, 101), at(10, 102), at(5, 101)},
+	}
+	var buf bytes.Buffer
+	if err := WriteDataset(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ds.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := closes(out["IBM"]); len(s) != 3 || s[0] != 100 || s[1] != 101 || s[2] != 102 {
+		t.Errorf("round trip closes = %v, want [100 101 102]", s)
+	}
+	if len(in["IBM"]) != 5 || in["IBM"][0].Close != 102 {
+		t.Error("WriteDataset modified its input")
+	}
+}
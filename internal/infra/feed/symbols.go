@@ -0,0 +1,118 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// SymbolMatch is one symbol search result.
+type SymbolMatch struct {
+	Symbol     string
+	Name       string
+	Type       string
+	Region     string
+	Timezone   string
+	Currency   string
+	MatchScore float64
+}
+
+// Overview is upstream reference data about one symbol.
+type Overview struct {
+	Symbol    string
+	Name      string
+	AssetType string
+	Exchange  string
+	Currency  string
+	Country   string
+}
+
+// AlphaVantage-like SYMBOL_SEARCH payload (strings)
+type avSearch struct {
+	BestMatches []struct {
+		Symbol     string `json:"1. symbol"`
+		Name       string `json:"2. name"`
+		Type       string `json:"3. type"`
+		Region     string `json:"4. region"`
+		Timezone   string `json:"7. timezone"`
+		Currency   string `json:"8. currency"`
+		MatchScore string `json:"9. matchScore"`
+	} `json:"bestMatches"`
+}
+
+// AlphaVantage-like OVERVIEW payload; unknown symbols get {}.
+type avOverview struct {
+	Symbol    string `json:"Symbol"`
+	Name      string `json:"Name"`
+	AssetType string `json:"AssetType"`
+	Exchange  string `json:"Exchange"`
+	Currency  string `json:"Currency"`
+	Country   string `json:"Country"`
+}
+
+// SearchSymbols returns upstream matches for keywords, best first.
+func (f *Client) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	body, err := f.query(ctx, keywords, url.Values{
+		"function": {"SYMBOL_SEARCH"},
+		"keywords": {keywords},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data avSearch
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+	out := make([]SymbolMatch, 0, len(data.BestMatches))
+	for _, m := range data.BestMatches {
+		if m.Symbol == "" {
+			continue
+		}
+		score, _ := strconv.ParseFloat(m.MatchScore, 64)
+		out = append(out, SymbolMatch{
+			Symbol:     m.Symbol,
+			Name:       m.Name,
+			Type:       m.Type,
+			Region:     m.Region,
+			Timezone:   m.Timezone,
+			Currency:   m.Currency,
+			MatchScore: score,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].MatchScore > out[j].MatchScore
+	})
+	return out, nil
+}
+
+// Overview returns upstream reference data for symbol, or an error
+// wrapping ErrUnknownSymbol when upstream has none.
+func (f *Client) Overview(ctx context.Context, symbol string) (*Overview, error) {
+	body, err := f.query(ctx, symbol, url.Values{
+		"function": {"OVERVIEW"},
+		"symbol":   {symbol},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data avOverview
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+	if data.Symbol == "" {
+		return nil, fmt.Errorf("%w %s: no overview", ErrUnknownSymbol, symbol)
+	}
+	return &Overview{
+		Symbol:    data.Symbol,
+		Name:      data.Name,
+		AssetType: data.AssetType,
+		Exchange:  data.Exchange,
+		Currency:  data.Currency,
+		Country:   data.Country,
+	}, nil
+}
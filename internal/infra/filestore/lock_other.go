@@ -0,0 +1,14 @@
+//go:build !unix
+
+package filestore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockDir only creates the lock file: without flock the directory is not
+// protected against a second process.
+func lockDir(dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, lockFile), os.O_CREATE|os.O_RDWR, 0o644)
+}
@@ -0,0 +1,445 @@
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"marketpulse/pkg/rsi"
+)
+
+const (
+	logFile      = "state.log"
+	snapshotFile = "state.snapshot.json"
+	lockFile     = "state.lock"
+)
+
+// ErrLocked is returned by NewStore when another process (usually the
+// server) has the state directory open. Writing state.log from two
+// processes would interleave records and neither would see the other's.
+var ErrLocked = errors.New("state dir is in use by another process")
+
+// record is one line of the append-only log and one entry of the snapshot.
+// New indicator states get their own optional field so old files keep loading.
+type record struct {
+	Symbol string          `json:"symbol,omitempty"`
+	RSI    *rsi.CompactRSI `json:"rsi,omitempty"`
+	Hash   *hashRecord     `json:"hash,omitempty"`
+}
+
+// hashRecord sets (or, when Deleted, removes) one field of a hash.
+type hashRecord struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Value   []byte `json:"value,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Store is a file-backed StateRepository (and hash store for per-user
+// documents) for deployments without Redis. Every write is appended to state.log and fsynced; a periodic snapshot
+// compacts the log into state.snapshot.json.
+type Store struct {
+	dir    string
+	mu     sync.Mutex
+	states map[string]*rsi.CompactRSI
+	hashes map[string]map[string][]byte
+	log    *os.File
+	lock   *os.File
+	dirty  bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewStore opens (or creates) the state directory, recovers the last
+// snapshot plus any log entries written after it, and starts the
+// background snapshot loop when snapshotEvery > 0. The directory stays
+// locked until Close; opening it again fails with ErrLocked.
+func NewStore(dir string, snapshotEvery time.Duration) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("state dir required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("state dir: %w", err)
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:    dir,
+		states: make(map[string]*rsi.CompactRSI),
+		hashes: make(map[string]map[string][]byte),
+		lock:   lock,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := s.loadSnapshot(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	if err := s.replayLog(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("open state log: %w", err)
+	}
+	s.log = f
+
+	if snapshotEvery > 0 {
+		go s.snapshotLoop(snapshotEvery)
+	} else {
+		close(s.done)
+	}
+	return s, nil
+}
+
+// GetOrUpdate returns a copy of the stored state, or an empty state for
+// unknown symbols.
+func (s *Store) GetOrUpdate(ctx context.Context, symbol string) (*rsi.CompactRSI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.states[symbol]; ok {
+		cp := *st
+		return &cp, nil
+	}
+	return &rsi.CompactRSI{}, nil
+}
+
+// All returns a copy of every stored state keyed by symbol.
+func (s *Store) All(ctx context.Context) (map[string]*rsi.CompactRSI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]*rsi.CompactRSI, len(s.states))
+	for sym, st := range s.states {
+		cp := *st
+		out[sym] = &cp
+	}
+	return out, nil
+}
+
+// GetMany returns copies of the stored states for the given symbols.
+// Symbols without state are absent from the result.
+func (s *Store) GetMany(ctx context.Context, symbols []string) (map[string]*rsi.CompactRSI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]*rsi.CompactRSI, len(symbols))
+	for _, sym := range symbols {
+		if st, ok := s.states[sym]; ok {
+			cp := *st
+			out[sym] = &cp
+		}
+	}
+	return out, nil
+}
+
+// Save appends the state to the log before updating the in-memory map,
+// so a crash never loses an acknowledged write.
+func (s *Store) Save(ctx context.Context, symbol string, st *rsi.CompactRSI) error {
+	return s.SaveMany(ctx, map[string]*rsi.CompactRSI{symbol: st})
+}
+
+// SaveMany appends all states with a single write and fsync.
+func (s *Store) SaveMany(ctx context.Context, states map[string]*rsi.CompactRSI) error {
+	recs := make([]record, 0, len(states))
+	for sym, st := range states {
+		if st == nil {
+			continue
+		}
+		cp := *st
+		recs = append(recs, record{Symbol: sym, RSI: &cp})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked(recs)
+}
+
+// HGet returns one field of a hash.
+func (s *Store) HGet(ctx context.Context, key, field string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.hashes[key][field]
+	return append([]byte(nil), v...), ok, nil
+}
+
+// HGetAll returns a copy of every field of a hash.
+func (s *Store) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string][]byte, len(s.hashes[key]))
+	for f, v := range s.hashes[key] {
+		out[f] = append([]byte(nil), v...)
+	}
+	return out, nil
+}
+
+// HSet durably sets one field of a hash.
+func (s *Store) HSet(ctx context.Context, key, field string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := append([]byte(nil), value...)
+	return s.appendLocked([]record{{Hash: &hashRecord{Key: key, Field: field, Value: v}}})
+}
+
+// HDel durably removes fields of a hash.
+func (s *Store) HDel(ctx context.Context, key string, fields ...string) error {
+	recs := make([]record, 0, len(fields))
+	for _, f := range fields {
+		recs = append(recs, record{Hash: &hashRecord{Key: key, Field: f, Deleted: true}})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked(recs)
+}
+
+// appendLocked logs recs with a single write and fsync, then applies them.
+func (s *Store) appendLocked(recs []record) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	if s.log == nil {
+		return fmt.Errorf("state store closed")
+	}
+
+	var buf bytes.Buffer
+	for _, r := range recs {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encode record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if _, err := s.log.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("append state log: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("sync state log: %w", err)
+	}
+	for _, r := range recs {
+		s.apply(r)
+	}
+	s.dirty = true
+	return nil
+}
+
+// apply updates the in-memory view with one record.
+func (s *Store) apply(r record) {
+	if r.RSI != nil {
+		s.states[r.Symbol] = r.RSI
+	}
+	if h := r.Hash; h != nil {
+		if h.Deleted {
+			delete(s.hashes[h.Key], h.Field)
+			if len(s.hashes[h.Key]) == 0 {
+				delete(s.hashes, h.Key)
+			}
+			return
+		}
+		if s.hashes[h.Key] == nil {
+			s.hashes[h.Key] = make(map[string][]byte)
+		}
+		s.hashes[h.Key][h.Field] = h.Value
+	}
+}
+
+// Snapshot writes all states to the snapshot file and truncates the log.
+// The snapshot is written to a temp file and renamed into place, so either
+// the old or the new snapshot survives a crash.
+func (s *Store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+// Close writes a final snapshot and releases the log file.
+func (s *Store) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		close(s.stop)
+	}
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.snapshotLocked()
+	if cerr := s.log.Close(); err == nil {
+		err = cerr
+	}
+	s.log = nil
+	s.lock.Close()
+	return err
+}
+
+func (s *Store) snapshotLoop(every time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				fmt.Printf("state snapshot failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func (s *Store) snapshotLocked() error {
+	if !s.dirty || s.log == nil {
+		return nil
+	}
+
+	recs := make([]record, 0, len(s.states))
+	for sym, st := range s.states {
+		recs = append(recs, record{Symbol: sym, RSI: st})
+	}
+	for key, fields := range s.hashes {
+		for f, v := range fields {
+			recs = append(recs, record{Hash: &hashRecord{Key: key, Field: f, Value: v}})
+		}
+	}
+	b, err := json.Marshal(recs)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	path := filepath.Join(s.dir, snapshotFile)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, b); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	syncDir(s.dir)
+
+	// Every logged record is now covered by the snapshot.
+	if err := s.log.Truncate(0); err != nil {
+		return fmt.Errorf("truncate state log: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("sync state log: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *Store) loadSnapshot() error {
+	b, err := os.ReadFile(filepath.Join(s.dir, snapshotFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var recs []record
+	if err := json.Unmarshal(b, &recs); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	for _, r := range recs {
+		s.apply(r)
+	}
+	return nil
+}
+
+// replayLog applies log records on top of the snapshot. A torn final line
+// (no trailing newline) from a crash mid-append is cut off so later
+// appends start clean. Any other unreadable line means the log is
+// damaged, and startup fails rather than silently dropping what follows.
+func (s *Store) replayLog() error {
+	path := filepath.Join(s.dir, logFile)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open state log: %w", err)
+	}
+	defer f.Close()
+
+	var good int64
+	rd := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := rd.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				fmt.Printf("state log: dropping torn final line of %d bytes\n", len(line))
+				if err := os.Truncate(path, good); err != nil {
+					return fmt.Errorf("truncate state log: %w", err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read state log: %w", err)
+		}
+		good += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("state log %s line %d: %w", path, n, err)
+		}
+		s.apply(r)
+		s.dirty = true
+	}
+}
+
+func writeFileSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes a rename durable; best effort since not all platforms
+// support fsync on directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,233 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"marketpulse/pkg/rsi"
+)
+
+func TestStoreLocksDir(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(dir, 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second open err = %v, want ErrLocked", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = NewStore(dir, 0)
+	if err != nil {
+		t.Fatalf("reopen after Close: %v", err)
+	}
+	s.Close()
+}
+
+func TestStoreReadsAreCopies(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	in := &rsi.CompactRSI{Count: 20, RSI: 55}
+	if err := s.Save(ctx, "IBM", in); err != nil {
+		t.Fatal(err)
+	}
+	in.Count = 99
+	got, _ := s.GetOrUpdate(ctx, "IBM")
+	got.RSI = 1
+	all, _ := s.All(ctx)
+	all["IBM"].RSI = 2
+	if st, _ := s.GetOrUpdate(ctx, "IBM"); st.Count != 20 || st.RSI != 55 {
+		t.Errorf("stored state changed through a caller's pointer: %+v", st)
+	}
+	if st, _ := s.GetOrUpdate(ctx, "MSFT"); *st != (rsi.CompactRSI{}) {
+		t.Errorf("unknown symbol = %+v, want an empty state", st)
+	}
+
+	if err := s.HSet(ctx, "w:u1", "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.HSet(ctx, "w:u1", "b", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.HDel(ctx, "w:u1", "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	h, _ := s.HGetAll(ctx, "w:u1")
+	if len(h) != 1 || string(h["b"]) != "2" {
+		t.Errorf("hash = %q", h)
+	}
+	if _, ok, _ := s.HGet(ctx, "w:u1", "a"); ok {
+		t.Error("deleted field still present")
+	}
+}
+
+func TestSnapshotCompactsLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Save(ctx, "IBM", &rsi.CompactRSI{Count: 20})
+	s.HSet(ctx, "w:u1", "a", []byte("1"))
+	s.HSet(ctx, "w:u1", "b", []byte("2"))
+	if err := s.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(filepath.Join(dir, logFile)); fi.Size() != 0 {
+		t.Errorf("log is %d bytes after a snapshot, want 0", fi.Size())
+	}
+
+	// Writes after the snapshot live only in the log
+	s.Save(ctx, "IBM", &rsi.CompactRSI{Count: 21})
+	s.HDel(ctx, "w:u1", "a")
+	crash(t, s)
+
+	s, err = NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if st, _ := s.GetOrUpdate(ctx, "IBM"); st.Count != 21 {
+		t.Errorf("IBM count = %d, want the logged 21 over the snapshot's 20", st.Count)
+	}
+	if h, _ := s.HGetAll(ctx, "w:u1"); len(h) != 1 || string(h["b"]) != "2" {
+		t.Errorf("hash = %q, want the logged delete applied over the snapshot", h)
+	}
+}
+
+// crash releases s without the final snapshot Close writes, leaving
+// everything since the last snapshot in the log only.
+func crash(t *testing.T, s *Store) {
+	t.Helper()
+	close(s.stop)
+	<-s.done
+	s.log.Close()
+	s.lock.Close()
+}
+
+// seedLog writes two states and a hash field to the log of a new store
+// in dir, and returns the log's path.
+func seedLog(t *testing.T, dir string) string {
+	t.Helper()
+	ctx := context.Background()
+	s, err := NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sym, count := range map[string]int{"IBM": 20, "AAPL": 30} {
+		if err := s.Save(ctx, sym, &rsi.CompactRSI{Count: count, RSI: 55}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.HSet(ctx, "alerts:u1", "r1", []byte(`{"id":"r1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	crash(t, s)
+	return filepath.Join(dir, logFile)
+}
+
+func appendTo(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func checkSeeded(t *testing.T, s *Store) {
+	t.Helper()
+	ctx := context.Background()
+	states, err := s.All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states["IBM"].Count != 20 || states["AAPL"].Count != 30 {
+		t.Errorf("states = %+v", states)
+	}
+	if b, ok, _ := s.HGet(ctx, "alerts:u1", "r1"); !ok || string(b) != `{"id":"r1"}` {
+		t.Errorf("hash field = %q, %v", b, ok)
+	}
+}
+
+func TestReplayLog(t *testing.T) {
+	dir := t.TempDir()
+	seedLog(t, dir)
+
+	s, err := NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	checkSeeded(t, s)
+}
+
+func TestReplayLogTornFinalLine(t *testing.T) {
+	dir := t.TempDir()
+	path := seedLog(t, dir)
+	before, _ := os.Stat(path)
+	appendTo(t, path, `{"symbol":"MSFT","rsi":{"rsi_co`)
+
+	s, err := NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeeded(t, s)
+	if after, _ := os.Stat(path); after.Size() != before.Size() {
+		t.Errorf("log is %d bytes, want the torn line cut back to %d", after.Size(), before.Size())
+	}
+
+	// Appends after the cut replay cleanly
+	if err := s.Save(context.Background(), "MSFT", &rsi.CompactRSI{Count: 40}); err != nil {
+		t.Fatal(err)
+	}
+	crash(t, s)
+	s, err = NewStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if st, _ := s.GetMany(context.Background(), []string{"MSFT"}); st["MSFT"] == nil || st["MSFT"].Count != 40 {
+		t.Errorf("MSFT after reopen = %+v", st["MSFT"])
+	}
+}
+
+func TestReplayLogCorruptLine(t *testing.T) {
+	dir := t.TempDir()
+	path := seedLog(t, dir)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Damage the second of three complete lines
+	lines := strings.SplitAfter(string(b), "\n")
+	lines[1] = "not json\n"
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := NewStore(dir, 0); err == nil {
+		s.Close()
+		t.Fatal("opened a store over a corrupt log")
+	} else if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want it to name line 2", err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != strings.Join(lines, "") {
+		t.Error("corrupt log was modified")
+	}
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const cachePrefix = "cache:"
+
+// Cache keeps opaque values, such as upstream reference data, under
+// expiring keys.
+type Cache struct {
+	cli *Client
+}
+
+func NewCache(cli *Client) *Cache {
+	return &Cache{cli: cli}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := c.cli.RDB().Get(ctx, cachePrefix+key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.cli.RDB().Set(ctx, cachePrefix+key, value, ttl).Err()
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 
 	"github.com/redis/go-redis/v9"
@@ -9,18 +10,40 @@ import (
 )
 
 type Client struct {
-	rdb *redis.Client
-	cfg *config.Config
+	rdb  redis.UniversalClient
+	cfg  *config.Config
 	down bool
 }
 
+// NewClient builds a single-node, Sentinel failover or Cluster client
+// depending on cfg (see redis.NewUniversalClient for the selection rules).
 func NewClient(cfg *config.Config) *Client {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPass,
-		DB:       cfg.RedisDB,
-	})
-	return &Client{rdb: rdb, cfg: cfg}
+	return &Client{rdb: redis.NewUniversalClient(universalOptions(cfg)), cfg: cfg}
+}
+
+func universalOptions(cfg *config.Config) *redis.UniversalOptions {
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.RedisAddrs,
+		Username:         cfg.RedisUsername,
+		Password:         cfg.RedisPass,
+		DB:               cfg.RedisDB,
+		MasterName:       cfg.RedisMasterName,
+		SentinelPassword: cfg.RedisSentinelPass,
+		IsClusterMode:    cfg.RedisCluster,
+		PoolSize:         cfg.RedisPoolSize,
+		MinIdleConns:     cfg.RedisMinIdleConns,
+		DialTimeout:      cfg.RedisDialTimeout,
+		ReadTimeout:      cfg.RedisReadTimeout,
+		WriteTimeout:     cfg.RedisWriteTimeout,
+	}
+	if cfg.RedisTLS {
+		opts.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         cfg.RedisTLSServerName,
+			InsecureSkipVerify: cfg.RedisTLSInsecure,
+		}
+	}
+	return opts
 }
 
 func (c *Client) Ping(ctx context.Context) error {
@@ -35,5 +58,18 @@ func (c *Client) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (c *Client) RDB() *redis.Client { return c.rdb }
-func (c *Client) IsDown() bool       { return c.down }
+// ForEachNode runs fn against every node holding keys: each master in
+// Cluster mode, or the single (failover) client otherwise. Used for SCAN,
+// which only walks the node it is sent to. In Cluster mode fn runs
+// concurrently, one goroutine per master, so it must guard shared state.
+func (c *Client) ForEachNode(ctx context.Context, fn func(ctx context.Context, rdb redis.UniversalClient) error) error {
+	if cc, ok := c.rdb.(*redis.ClusterClient); ok {
+		return cc.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	}
+	return fn(ctx, c.rdb)
+}
+
+func (c *Client) RDB() redis.UniversalClient { return c.rdb }
+func (c *Client) IsDown() bool               { return c.down }
@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"marketpulse/internal/config"
+	"marketpulse/pkg/rsi"
+)
+
+func TestUniversalOptions(t *testing.T) {
+	t.Run("single", func(t *testing.T) {
+		o := universalOptions(&config.Config{RedisAddrs: []string{"r1:6379"}, RedisDB: 2, RedisPass: "pw"}).Simple()
+		if o.Addr != "r1:6379" || o.DB != 2 || o.Password != "pw" || o.TLSConfig != nil {
+			t.Errorf("options = %+v", o)
+		}
+	})
+	t.Run("sentinel", func(t *testing.T) {
+		o := universalOptions(&config.Config{
+			RedisAddrs:      []string{"s1:26379", "s2:26379"},
+			RedisMasterName: "mymaster", RedisPass: "pw", RedisSentinelPass: "spw",
+		}).Failover()
+		if o.MasterName != "mymaster" || len(o.SentinelAddrs) != 2 || o.SentinelPassword != "spw" || o.Password != "pw" {
+			t.Errorf("options = %+v", o)
+		}
+	})
+	t.Run("cluster", func(t *testing.T) {
+		o := universalOptions(&config.Config{RedisAddrs: []string{"c1:7000"}, RedisCluster: true, RedisPoolSize: 20})
+		if !o.IsClusterMode || o.Cluster().PoolSize != 20 || len(o.Cluster().Addrs) != 1 {
+			t.Errorf("options = %+v", o)
+		}
+	})
+	t.Run("tls", func(t *testing.T) {
+		o := universalOptions(&config.Config{
+			RedisAddrs: []string{"r1:6380"}, RedisTLS: true, RedisTLSServerName: "redis.internal",
+		})
+		c := o.TLSConfig
+		if c == nil || c.ServerName != "redis.internal" || c.MinVersion != tls.VersionTLS12 || c.InsecureSkipVerify {
+			t.Errorf("tls config = %+v", c)
+		}
+	})
+}
+
+func TestNewClientSelectsClusterClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := NewClient(&config.Config{RedisAddrs: []string{mr.Addr()}, RedisCluster: true})
+	t.Cleanup(func() { cli.RDB().Close() })
+	if _, ok := cli.RDB().(*goredis.ClusterClient); !ok {
+		t.Fatalf("client is %T, want a cluster client", cli.RDB())
+	}
+	ctx := context.Background()
+	if err := cli.RDB().Set(ctx, "k", "v", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.Keys(); len(got) != 1 || got[0] != "k" {
+		t.Errorf("keys = %v", got)
+	}
+}
+
+// newTestCluster splits the slots between two miniredis masters, so
+// cluster-wide operations run against both concurrently.
+func newTestCluster(t *testing.T) (*Client, [2]*miniredis.Miniredis) {
+	a, b := miniredis.RunT(t), miniredis.RunT(t)
+	cc := goredis.NewClusterClient(&goredis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]goredis.ClusterSlot, error) {
+			return []goredis.ClusterSlot{
+				{Start: 0, End: 8191, Nodes: []goredis.ClusterNode{{Addr: a.Addr()}}},
+				{Start: 8192, End: 16383, Nodes: []goredis.ClusterNode{{Addr: b.Addr()}}},
+			}, nil
+		},
+	})
+	t.Cleanup(func() { cc.Close() })
+	return &Client{rdb: cc, cfg: &config.Config{}}, [2]*miniredis.Miniredis{a, b}
+}
+
+func TestStateRouterAllCluster(t *testing.T) {
+	ctx := context.Background()
+	cli, nodes := newTestCluster(t)
+	s := NewStateRouter(cli, 1000, Retention{})
+
+	states := make(map[string]*rsi.CompactRSI)
+	for i := 0; i < 200; i++ {
+		states[fmt.Sprintf("S%03d", i)] = &rsi.CompactRSI{Count: i + 1}
+	}
+	if err := s.SaveMany(ctx, states); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range nodes {
+		if len(n.Keys()) == 0 {
+			t.Fatalf("node %d holds no keys; the test needs both shards", i)
+		}
+	}
+
+	// Read from Redis only: a fresh router has no memory copies
+	all, err := NewStateRouter(cli, 1000, Retention{}).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(states) {
+		t.Fatalf("All returned %d states, want %d", len(all), len(states))
+	}
+	for sym, st := range states {
+		if all[sym] == nil || all[sym].Count != st.Count {
+			t.Errorf("%s = %+v, want count %d", sym, all[sym], st.Count)
+		}
+	}
+}
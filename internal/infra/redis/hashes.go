@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// historySuffixes mark the per-user event logs (alert events, webhook
+// deliveries and dead letters). They expire after Retention.HistoryTTL
+// without access; rules, watchlists and webhooks never expire.
+var historySuffixes = []string{":alert_events", ":webhook_deliveries", ":webhook_dead"}
+
+// HashStore keeps small per-user documents (watchlists, alert rules, ...)
+// as fields of Redis hashes.
+type HashStore struct {
+	cli *Client
+	ret Retention
+}
+
+func NewHashStore(cli *Client, ret Retention) *HashStore {
+	return &HashStore{cli: cli, ret: ret}
+}
+
+// ttl returns the expiry of key, refreshed on every read and write; 0
+// means persist.
+func (h *HashStore) ttl(key string) time.Duration {
+	for _, suffix := range historySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return h.ret.historyTTL()
+		}
+	}
+	return 0
+}
+
+func (h *HashStore) HGet(ctx context.Context, key, field string) ([]byte, bool, error) {
+	var cmd *goredis.StringCmd
+	// The pipeline error repeats cmd's, including redis.Nil for a missing
+	// field, so only cmd is checked.
+	h.pipelined(ctx, key, func(p goredis.Pipeliner) {
+		cmd = p.HGet(ctx, key, field)
+	})
+	b, err := cmd.Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (h *HashStore) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	var cmd *goredis.MapStringStringCmd
+	if _, err := h.pipelined(ctx, key, func(p goredis.Pipeliner) {
+		cmd = p.HGetAll(ctx, key)
+	}); err != nil {
+		return nil, err
+	}
+	data := cmd.Val()
+	out := make(map[string][]byte, len(data))
+	for f, v := range data {
+		out[f] = []byte(v)
+	}
+	return out, nil
+}
+
+func (h *HashStore) HSet(ctx context.Context, key, field string, value []byte) error {
+	_, err := h.pipelined(ctx, key, func(p goredis.Pipeliner) {
+		p.HSet(ctx, key, field, value)
+	})
+	return err
+}
+
+func (h *HashStore) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return h.cli.RDB().HDel(ctx, key, fields...).Err()
+}
+
+// pipelined runs fn's command on key followed by EXPIRE when key has a
+// TTL, in one round trip. EXPIRE on a missing key is a no-op.
+func (h *HashStore) pipelined(ctx context.Context, key string, fn func(goredis.Pipeliner)) ([]goredis.Cmder, error) {
+	ttl := h.ttl(key)
+	return h.cli.RDB().Pipelined(ctx, func(p goredis.Pipeliner) error {
+		fn(p)
+		if ttl > 0 {
+			p.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"marketpulse/internal/config"
+)
+
+func newTestHashStore(t *testing.T, ret Retention) (*HashStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	cli := NewClient(&config.Config{RedisAddrs: []string{mr.Addr()}})
+	t.Cleanup(func() { cli.RDB().Close() })
+	return NewHashStore(cli, ret), mr
+}
+
+func TestHashStoreHistoryTTL(t *testing.T) {
+	ctx := context.Background()
+	h, mr := newTestHashStore(t, Retention{HistoryTTL: time.Hour})
+
+	events := "user:{u1}:alert_events"
+	rules := "user:{u1}:alert_rules"
+	for _, key := range []string{events, rules, "user:{u1}:webhook_deliveries", "user:{u1}:webhook_dead"} {
+		if err := h.HSet(ctx, key, "f", []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, want := range map[string]time.Duration{
+		events:                         time.Hour,
+		"user:{u1}:webhook_deliveries": time.Hour,
+		"user:{u1}:webhook_dead":       time.Hour,
+		rules:                          0,
+	} {
+		if got := mr.TTL(key); got != want {
+			t.Errorf("TTL(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	// Reads refresh the TTL
+	mr.FastForward(50 * time.Minute)
+	if _, err := h.HGetAll(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL(events); got != time.Hour {
+		t.Errorf("TTL after HGetAll = %v, want 1h", got)
+	}
+	mr.FastForward(50 * time.Minute)
+	if _, ok, err := h.HGet(ctx, events, "f"); err != nil || !ok {
+		t.Fatalf("HGet = %v, %v", ok, err)
+	}
+
+	// Untouched logs expire
+	mr.FastForward(2 * time.Hour)
+	if all, err := h.HGetAll(ctx, events); err != nil || len(all) != 0 {
+		t.Errorf("HGetAll after expiry = %v, %v; want empty", all, err)
+	}
+	if _, ok, _ := h.HGet(ctx, rules, "f"); !ok {
+		t.Error("rules expired; they must persist")
+	}
+}
+
+func TestHashStoreHistoryTTLDisabled(t *testing.T) {
+	ctx := context.Background()
+	h, mr := newTestHashStore(t, Retention{HistoryTTL: -1})
+
+	if err := h.HSet(ctx, "user:{u1}:alert_events", "f", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL("user:{u1}:alert_events"); got != 0 {
+		t.Errorf("TTL = %v, want none", got)
+	}
+	if _, ok, err := h.HGet(ctx, "user:{u1}:alert_events", "missing"); ok || err != nil {
+		t.Errorf("HGet(missing) = %v, %v; want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,57 @@
+package redis
+
+import "strings"
+
+// Keys wrap the symbol in a hash tag ({IBM}) so every key belonging to one
+// symbol hashes to the same Cluster slot and can share pipelines and
+// transactions.
+const (
+	keyPrefix     = "symbol:"
+	stateSuffix   = ":state"
+	compactSuffix = ":compact"
+
+	// batchSize bounds the number of commands per pipeline.
+	batchSize = 500
+)
+
+func symbolTag(symbol string) string {
+	return "{" + symbol + "}"
+}
+
+// stateKey holds a symbol's CompactRSI in the binary encoding.
+func stateKey(symbol string) string {
+	return keyPrefix + symbolTag(symbol) + stateSuffix
+}
+
+// compactKey is the hash of decimal strings used before the binary
+// encoding; read only for migration.
+func compactKey(symbol string) string {
+	return keyPrefix + symbolTag(symbol) + compactSuffix
+}
+
+// legacyCompactKey is the pre-hash-tag key, still read so existing
+// deployments keep their warmup state after upgrading.
+func legacyCompactKey(symbol string) string {
+	return keyPrefix + symbol + compactSuffix
+}
+
+// symbolFromKey extracts the symbol from a state or compact key in any of
+// its forms; ok is false for other keys.
+func symbolFromKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	sym := strings.TrimPrefix(key, keyPrefix)
+	switch {
+	case strings.HasSuffix(sym, stateSuffix):
+		sym = strings.TrimSuffix(sym, stateSuffix)
+	case strings.HasSuffix(sym, compactSuffix):
+		sym = strings.TrimSuffix(sym, compactSuffix)
+	default:
+		return "", false
+	}
+	if strings.HasPrefix(sym, "{") && strings.HasSuffix(sym, "}") {
+		sym = sym[1 : len(sym)-1]
+	}
+	return sym, true
+}
@@ -2,6 +2,8 @@ package redis
 
 import (
 	"context"
+	"time"
+
 	"marketpulse/pkg/rsi"
 )
 
@@ -10,11 +12,31 @@ import (
 type StateRepository interface {
 	GetOrUpdate(ctx context.Context, symbol string) (*rsi.CompactRSI, error)
 	Save(ctx context.Context, symbol string, st *rsi.CompactRSI) error
+	All(ctx context.Context) (map[string]*rsi.CompactRSI, error)
+	GetMany(ctx context.Context, symbols []string) (map[string]*rsi.CompactRSI, error)
+	SaveMany(ctx context.Context, states map[string]*rsi.CompactRSI) error
 }
 
 // NewStateRepository returns an implementation of StateRepository.
 // It returns a StateRouter which handles the transition between 
 // Redis storage and the memory fallback.
-func NewStateRepository(cli *Client, maxSymbols int) StateRepository {
-	return NewStateRouter(cli, maxSymbols)
+func NewStateRepository(cli *Client, maxSymbols int, ret Retention) StateRepository {
+	return NewStateRouter(cli, maxSymbols, ret)
+}
+
+// Retention controls how long per-symbol keys live without access.
+// Zero or negative durations disable expiry for that key kind.
+type Retention struct {
+	StateTTL   time.Duration
+	HistoryTTL time.Duration
+}
+
+// stateTTL returns the expiry to pass to SET/GETEX; 0 means persist.
+func (r Retention) stateTTL() time.Duration {
+	return max(r.StateTTL, 0)
+}
+
+// historyTTL returns the expiry of per-user event logs; 0 means persist.
+func (r Retention) historyTTL() time.Duration {
+	return max(r.HistoryTTL, 0)
 }
@@ -2,10 +2,12 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
 
+	goredis "github.com/redis/go-redis/v9"
 	"golang.org/x/sync/singleflight"
 	"marketpulse/pkg/rsi"
 )
@@ -17,26 +19,24 @@ type StateRouter struct {
 	memory map[string]*rsi.CompactRSI
 	sf     singleflight.Group
 	maxSym int
+	ret    Retention
 }
 
 // NewStateRouter initializes a new StateRouter.
-func NewStateRouter(cli *Client, maxSymbols int) *StateRouter {
+func NewStateRouter(cli *Client, maxSymbols int, ret Retention) *StateRouter {
 	return &StateRouter{
 		cli:    cli,
 		memory: make(map[string]*rsi.CompactRSI),
 		maxSym: maxSymbols,
+		ret:    ret,
 	}
 }
 
-// GetOrUpdate retrieves state from Redis or falls back to memory.
-// Auto-saves after reading if Redis is available.
+// GetOrUpdate retrieves state from Redis or falls back to memory. Reads
+// don't write: GETEX already refreshes the key's TTL.
 func (s *StateRouter) GetOrUpdate(ctx context.Context, symbol string) (*rsi.CompactRSI, error) {
 	if !s.cli.IsDown() {
 		if state, err := s.redisGet(ctx, symbol); err == nil && state != nil {
-			// Auto-save: ensure Redis has the latest copy
-			if err := s.redisSave(ctx, symbol, state); err != nil {
-				fmt.Printf("redis save failed: %v\n", err)
-			}
 			return state, nil
 		}
 	}
@@ -55,6 +55,7 @@ func (s *StateRouter) GetOrUpdate(ctx context.Context, symbol string) (*rsi.Comp
 	}
 	return st, nil
 }
+
 // Save persists RSI state to Redis (no-op if down) and memory.
 func (s *StateRouter) Save(ctx context.Context, symbol string, st *rsi.CompactRSI) error {
 	if !s.cli.IsDown() {
@@ -63,29 +64,211 @@ func (s *StateRouter) Save(ctx context.Context, symbol string, st *rsi.CompactRS
 		}
 	}
 	// Sync to memory (double-write for consistency)
-	s.memMu.Lock()
-	s.memory[symbol] = st
-	s.memMu.Unlock()
-	if len(s.memory) > s.maxSym {
-		s.memMu.Lock()
-		for k := range s.memory {
-			if k != symbol {
-				delete(s.memory, k)
+	s.memoryPut(symbol, st)
+	return nil
+}
+
+// GetMany returns stored states for the given symbols in one pipelined
+// round trip, plus one for any still in the pre-binary hash form.
+// Symbols without state are absent from the result. Bulk reads write
+// nothing and do not refresh key TTLs, so scanning views keep abandoned
+// symbols expiring.
+func (s *StateRouter) GetMany(ctx context.Context, symbols []string) (map[string]*rsi.CompactRSI, error) {
+	out := make(map[string]*rsi.CompactRSI, len(symbols))
+	if len(symbols) == 0 {
+		return out, nil
+	}
+
+	if s.cli.IsDown() {
+		s.memMu.RLock()
+		for _, sym := range symbols {
+			if st, ok := s.memory[sym]; ok {
+				cp := *st
+				out[sym] = &cp
+			}
+		}
+		s.memMu.RUnlock()
+		return out, nil
+	}
+
+	pipe := s.cli.RDB().Pipeline()
+	cmds := make([]*goredis.StringCmd, len(symbols))
+	for i, sym := range symbols {
+		cmds[i] = pipe.Get(ctx, stateKey(sym))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("redis pipeline: %w", err)
+	}
+
+	var legacy []string
+	for i, sym := range symbols {
+		b, err := cmds[i].Bytes()
+		if errors.Is(err, goredis.Nil) {
+			legacy = append(legacy, sym)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis get %s: %w", sym, err)
+		}
+		st := &rsi.CompactRSI{}
+		if err := st.UnmarshalBinary(b); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", sym, err)
+		}
+		out[sym] = st
+	}
+	if len(legacy) > 0 {
+		if err := s.getManyHash(ctx, legacy, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// getManyHash reads the symbols' pre-binary hashes, under either key
+// form, in one more round trip and adds those found to out. Unlike
+// single reads it leaves migration to GetOrUpdate, so bulk reads stay
+// write-free.
+func (s *StateRouter) getManyHash(ctx context.Context, symbols []string, out map[string]*rsi.CompactRSI) error {
+	pipe := s.cli.RDB().Pipeline()
+	cmds := make([][2]*goredis.MapStringStringCmd, len(symbols))
+	for i, sym := range symbols {
+		cmds[i] = [2]*goredis.MapStringStringCmd{
+			pipe.HGetAll(ctx, compactKey(sym)),
+			pipe.HGetAll(ctx, legacyCompactKey(sym)),
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis pipeline: %w", err)
+	}
+	for i, sym := range symbols {
+		for _, cmd := range cmds[i] {
+			if data := cmd.Val(); len(data) > 0 {
+				out[sym] = stateFromHash(data)
 				break
 			}
 		}
-		s.memMu.Unlock()
 	}
 	return nil
 }
 
-// redisGet fetches RSI state from Redis.
+// SaveMany persists many states in one pipelined round trip.
+func (s *StateRouter) SaveMany(ctx context.Context, states map[string]*rsi.CompactRSI) error {
+	if !s.cli.IsDown() && len(states) > 0 {
+		pipe := s.cli.RDB().Pipeline()
+		for sym, st := range states {
+			if st == nil {
+				continue
+			}
+			b, err := st.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("encode %s: %w", sym, err)
+			}
+			pipe.Set(ctx, stateKey(sym), b, s.ret.stateTTL())
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("redis pipeline: %w", err)
+		}
+	}
+	for sym, st := range states {
+		if st != nil {
+			s.memoryPut(sym, st)
+		}
+	}
+	return nil
+}
+
+// All returns every stored state keyed by symbol. Memory entries are
+// overlaid by Redis when it is available.
+func (s *StateRouter) All(ctx context.Context) (map[string]*rsi.CompactRSI, error) {
+	out := make(map[string]*rsi.CompactRSI)
+
+	s.memMu.RLock()
+	for sym, st := range s.memory {
+		cp := *st
+		out[sym] = &cp
+	}
+	s.memMu.RUnlock()
+
+	if s.cli.IsDown() {
+		return out, nil
+	}
+
+	// Masters are scanned concurrently in Cluster mode
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var symbols []string
+	err := s.cli.ForEachNode(ctx, func(ctx context.Context, rdb goredis.UniversalClient) error {
+		iter := rdb.Scan(ctx, 0, keyPrefix+"*", 500).Iterator()
+		for iter.Next(ctx) {
+			symbol, ok := symbolFromKey(iter.Val())
+			if !ok {
+				continue
+			}
+			mu.Lock()
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+			mu.Unlock()
+		}
+		return iter.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis scan: %w", err)
+	}
+
+	for start := 0; start < len(symbols); start += batchSize {
+		end := min(start+batchSize, len(symbols))
+		states, err := s.GetMany(ctx, symbols[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for sym, st := range states {
+			out[sym] = st
+		}
+	}
+	return out, nil
+}
+
+// redisGet fetches RSI state from Redis, refreshing its TTL.
 func (s *StateRouter) redisGet(ctx context.Context, symbol string) (*rsi.CompactRSI, error) {
-	data, err := s.cli.RDB().HGetAll(ctx, fmt.Sprintf("symbol:%s:compact", symbol)).Result()
+	b, err := s.cli.RDB().GetEx(ctx, stateKey(symbol), s.ret.stateTTL()).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return s.redisGetHash(ctx, symbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &rsi.CompactRSI{}
+	if err := state.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// redisGetHash reads state written by older versions as a hash of decimal
+// strings, and migrates it to the binary key.
+func (s *StateRouter) redisGetHash(ctx context.Context, symbol string) (*rsi.CompactRSI, error) {
+	key := compactKey(symbol)
+	data, err := s.cli.RDB().HGetAll(ctx, key).Result()
+	if err == nil && len(data) == 0 {
+		key = legacyCompactKey(symbol)
+		data, err = s.cli.RDB().HGetAll(ctx, key).Result()
+	}
 	if err != nil || len(data) == 0 {
 		return nil, err
 	}
 
+	state := stateFromHash(data)
+	if err := s.redisSave(ctx, symbol, state); err == nil {
+		s.cli.RDB().Del(ctx, key)
+	}
+	return state, nil
+}
+
+// stateFromHash decodes a pre-binary state hash of decimal strings.
+func stateFromHash(data map[string]string) *rsi.CompactRSI {
 	state := &rsi.CompactRSI{}
 	if ag, ok := data["avg_gain"]; ok {
 		state.AvgGain, _ = strconv.ParseFloat(ag, 64)
@@ -97,9 +280,7 @@ func (s *StateRouter) redisGet(ctx context.Context, symbol string) (*rsi.Compact
 		state.Count, _ = strconv.Atoi(cnt)
 	}
 	if tsData, ok := data["last_ts"]; ok {
-		if err := state.LastTs.UnmarshalJSON([]byte(tsData)); err == nil {
-			// success
-		}
+		_ = state.LastTs.UnmarshalJSON([]byte(tsData))
 	}
 	if lc, ok := data["last_close"]; ok {
 		state.LastClose, _ = strconv.ParseFloat(lc, 64)
@@ -107,28 +288,37 @@ func (s *StateRouter) redisGet(ctx context.Context, symbol string) (*rsi.Compact
 	if r, ok := data["rsi"]; ok {
 		state.RSI, _ = strconv.ParseFloat(r, 64)
 	}
-	return state, nil
+	return state
 }
 
-// redisSave writes updated state back to Redis.
+// redisSave writes updated state back to Redis using the lossless
+// binary encoding.
 func (s *StateRouter) redisSave(ctx context.Context, symbol string, st *rsi.CompactRSI) error {
 	if s.cli.IsDown() || st == nil {
 		return nil
 	}
-	key := fmt.Sprintf("symbol:%s:compact", symbol)
-	data := map[string]interface{}{
-		"avg_gain":   fmt.Sprintf("%.8f", st.AvgGain),
-		"avg_loss":   fmt.Sprintf("%.8f", st.AvgLoss),
-		"rsi_count":  st.Count,
-		"last_close": fmt.Sprintf("%.4f", st.LastClose),
-		"rsi":        fmt.Sprintf("%.8f", st.RSI),
+	b, err := st.MarshalBinary()
+	if err != nil {
+		return err
 	}
-	if !st.LastTs.IsZero() {
-		if b, err := st.LastTs.MarshalJSON(); err == nil {
-			data["last_ts"] = string(b)
+	return s.cli.RDB().Set(ctx, stateKey(symbol), b, s.ret.stateTTL()).Err()
+}
+
+// memoryPut stores st in the fallback map, evicting an arbitrary other
+// symbol when over capacity.
+func (s *StateRouter) memoryPut(symbol string, st *rsi.CompactRSI) {
+	s.memMu.Lock()
+	defer s.memMu.Unlock()
+
+	s.memory[symbol] = st
+	if len(s.memory) > s.maxSym {
+		for k := range s.memory {
+			if k != symbol {
+				delete(s.memory, k)
+				break
+			}
 		}
 	}
-	return s.cli.RDB().HSet(ctx, key, data).Err()
 }
 
 // memoryGetOrInit manages the in-memory cache fallback.
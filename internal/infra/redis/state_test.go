@@ -0,0 +1,195 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"marketpulse/internal/config"
+	"marketpulse/pkg/rsi"
+)
+
+func newTestStateRouter(t *testing.T) (*StateRouter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	cli := NewClient(&config.Config{RedisAddrs: []string{mr.Addr()}})
+	t.Cleanup(func() { cli.RDB().Close() })
+	return NewStateRouter(cli, 100, Retention{StateTTL: time.Hour}), mr
+}
+
+func TestStateRouterBinaryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStateRouter(t)
+
+	want := map[string]*rsi.CompactRSI{
+		"IBM":  {AvgGain: 0.30000000000000004, AvgLoss: 1.0 / 3, Count: 57, LastTs: time.Date(2026, 10, 16, 15, 55, 0, 0, time.UTC), LastClose: 187.42, PrevClose: 187.01, RSI: 47.5, ChangePct: 0.22},
+		"AAPL": {Count: 3, LastClose: 230.1},
+	}
+	if err := s.SaveMany(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL(stateKey("IBM")); got != time.Hour {
+		t.Errorf("state TTL = %v, want 1h", got)
+	}
+
+	// Read back from Redis, not the memory copy
+	fresh := NewStateRouter(s.cli, 100, s.ret)
+	got, err := fresh.GetMany(ctx, []string{"IBM", "AAPL", "MSFT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d states, want 2", len(got))
+	}
+	for sym, w := range want {
+		if *got[sym] != *w {
+			t.Errorf("%s = %+v, want %+v", sym, got[sym], w)
+		}
+	}
+}
+
+// States written by older versions as hashes of decimal strings, under the
+// hash-tagged or the pre-hash-tag key, are read and moved to the binary key.
+func TestStateRouterMigratesLegacyHash(t *testing.T) {
+	ctx := context.Background()
+	for _, key := range []string{compactKey("IBM"), legacyCompactKey("IBM")} {
+		t.Run(key, func(t *testing.T) {
+			s, mr := newTestStateRouter(t)
+			mr.HSet(key,
+				"avg_gain", "0.30000000000000004",
+				"avg_loss", "0.3333333333333333",
+				"rsi_count", "57",
+				"last_ts", `"2026-10-16T15:55:00Z"`,
+				"last_close", "187.42",
+				"rsi", "47.36842105263158",
+			)
+
+			st, err := s.GetOrUpdate(ctx, "IBM")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := rsi.CompactRSI{
+				AvgGain: 0.30000000000000004, AvgLoss: 1.0 / 3, Count: 57,
+				LastTs: time.Date(2026, 10, 16, 15, 55, 0, 0, time.UTC), LastClose: 187.42, RSI: 47.36842105263158,
+			}
+			if !st.LastTs.Equal(want.LastTs) {
+				t.Errorf("last ts = %v, want %v", st.LastTs, want.LastTs)
+			}
+			st.LastTs, want.LastTs = time.Time{}, time.Time{}
+			if *st != want {
+				t.Errorf("state = %+v, want %+v", *st, want)
+			}
+
+			if mr.Exists(key) {
+				t.Error("legacy hash was not removed")
+			}
+			if !mr.Exists(stateKey("IBM")) {
+				t.Fatal("binary key was not written")
+			}
+			all, err := s.All(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 1 || all["IBM"].Count != 57 {
+				t.Errorf("All = %+v", all)
+			}
+		})
+	}
+}
+
+func TestStateRouterRejectsCorruptState(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStateRouter(t)
+	mr.Set(stateKey("IBM"), "garbage")
+
+	if _, err := s.GetMany(ctx, []string{"IBM"}); err == nil {
+		t.Error("GetMany decoded a corrupt state")
+	}
+}
+
+// cmdCounter counts commands by name, and pipelines under "(pipeline)".
+type cmdCounter map[string]int
+
+func (c cmdCounter) DialHook(next goredis.DialHook) goredis.DialHook { return next }
+
+func (c cmdCounter) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		c[cmd.Name()]++
+		return next(ctx, cmd)
+	}
+}
+
+func (c cmdCounter) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		c["(pipeline)"]++
+		for _, cmd := range cmds {
+			c[cmd.Name()]++
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// Repeated reads refresh the TTL without writing the state back.
+func TestStateRouterGetDoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStateRouter(t)
+	if err := s.Save(ctx, "IBM", &rsi.CompactRSI{Count: 20}); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(30 * time.Minute)
+
+	counts := cmdCounter{}
+	s.cli.RDB().AddHook(counts)
+	for i := 0; i < 3; i++ {
+		if st, err := s.GetOrUpdate(ctx, "IBM"); err != nil || st.Count != 20 {
+			t.Fatalf("GetOrUpdate = %+v, %v", st, err)
+		}
+	}
+	if counts["set"] != 0 || counts["getex"] != 3 {
+		t.Errorf("commands = %v, want 3 GETEX and no SET", counts)
+	}
+	if got := mr.TTL(stateKey("IBM")); got != time.Hour {
+		t.Errorf("TTL = %v, want refreshed to 1h", got)
+	}
+}
+
+// A cold bulk read over legacy hashes costs two round trips in total and
+// writes nothing; migration is left to single reads.
+func TestStateRouterGetManyLegacyBulk(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStateRouter(t)
+	var symbols []string
+	for i := 0; i < 50; i++ {
+		sym := fmt.Sprintf("S%02d", i)
+		symbols = append(symbols, sym)
+		key := compactKey(sym)
+		if i%2 == 1 {
+			key = legacyCompactKey(sym)
+		}
+		mr.HSet(key, "rsi_count", strconv.Itoa(i+1), "rsi", "50")
+	}
+	if err := s.Save(ctx, "IBM", &rsi.CompactRSI{Count: 99}); err != nil {
+		t.Fatal(err)
+	}
+	symbols = append(symbols, "IBM", "MISSING")
+
+	counts := cmdCounter{}
+	s.cli.RDB().AddHook(counts)
+	got, err := s.GetMany(ctx, symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 51 || got["IBM"].Count != 99 || got["S07"].Count != 8 || got["S10"].Count != 11 {
+		t.Errorf("got %d states: IBM %+v S07 %+v S10 %+v", len(got), got["IBM"], got["S07"], got["S10"])
+	}
+	if counts["(pipeline)"] != 2 || counts["set"]+counts["del"] != 0 {
+		t.Errorf("commands = %v, want 2 pipelines and no writes", counts)
+	}
+	if !mr.Exists(compactKey("S00")) {
+		t.Error("bulk read migrated a legacy hash")
+	}
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for webhook targets that resolve to an
+// address inside the server's own network: loopback, private, link-local
+// (including cloud metadata at 169.254.169.254), shared, unspecified or
+// multicast.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// sharedSpace is RFC 6598 carrier-grade NAT space, which netip does not
+// count as private.
+var sharedSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckAddr rejects addresses a webhook must not reach.
+func CheckAddr(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if !ip.IsValid() ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+// CheckHost resolves host (a name or IP literal) and rejects it when any
+// of its addresses fails CheckAddr.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return CheckAddr(ip)
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if err := CheckAddr(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialControl re-checks the address actually dialed, so a name that
+// resolved publicly at registration cannot be rebound to an internal
+// address later, and redirects cannot reach one either.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+	return CheckAddr(ap.Addr())
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers verify a delivery by
+// computing HMAC-SHA256(secret, timestamp + "." + body) and comparing it
+// with the hex digest in SignatureHeader (after the "sha256=" prefix).
+const (
+	SignatureHeader = "X-MarketPulse-Signature"
+	TimestampHeader = "X-MarketPulse-Timestamp"
+	DeliveryHeader  = "X-MarketPulse-Delivery"
+	EventHeader     = "X-MarketPulse-Event"
+)
+
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender returns a sender whose connections refuse internal addresses
+// (see CheckAddr) unless allowPrivate is set, which only tests and local
+// development should do.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = dialControl
+	}
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		// No proxy: it would dial the target on our behalf, unchecked.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}}
+}
+
+// Send POSTs body to url with signature headers. Any non-2xx response is
+// an error; the status code is returned either way (0 if no response).
+func (s *Sender) Send(ctx context.Context, url, secret, deliveryID, event string, body []byte) (int, error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MarketPulse-Webhook/1")
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(secret, ts, body))
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(EventHeader, event)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of timestamp + "." + body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCheckAddr(t *testing.T) {
+	cases := []struct {
+		addr string
+		ok   bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, c := range cases {
+		err := CheckAddr(netip.MustParseAddr(c.addr))
+		if (err == nil) != c.ok {
+			t.Errorf("CheckAddr(%s) = %v, want ok=%v", c.addr, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckAddr(%s) = %v, want ErrForbiddenAddress", c.addr, err)
+		}
+	}
+}
+
+func TestSendSignsBody(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	s := NewSender(time.Second, true)
+	payload := []byte(`{"id":"d1"}`)
+	code, err := s.Send(context.Background(), srv.URL, "k", "d1", "alert.fired", payload)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("Send = %d, %v", code, err)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s", body)
+	}
+	ts := got.Header.Get(TimestampHeader)
+	if want := "sha256=" + Sign("k", ts, payload); got.Header.Get(SignatureHeader) != want {
+		t.Errorf("signature = %s, want %s", got.Header.Get(SignatureHeader), want)
+	}
+	if got.Header.Get(DeliveryHeader) != "d1" || got.Header.Get(EventHeader) != "alert.fired" {
+		t.Errorf("delivery/event headers = %q/%q", got.Header.Get(DeliveryHeader), got.Header.Get(EventHeader))
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	_, err := NewSender(time.Second, false).Send(context.Background(), srv.URL, "k", "d1", "alert.fired", nil)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Send to loopback err = %v, want ErrForbiddenAddress", err)
+	}
+	if hit {
+		t.Error("request reached the loopback receiver")
+	}
+}
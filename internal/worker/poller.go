@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"marketpulse/internal/domain/service"
+)
+
+// Poller advances a fixed watchlist of symbols on a schedule aligned to
+// candle close, so state stays current whether or not anyone is viewing.
+// Symbols with live stream subscribers are added for as long as they are
+// watched.
+type Poller struct {
+	svc         *service.IntradayService
+	calendar    *service.Calendar
+	symbols     []string
+	interval    time.Duration
+	delay       time.Duration
+	concurrency int
+	logger      *zap.Logger
+
+	watchMu sync.Mutex
+	watched map[string]int // symbol -> watcher count
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPoller creates a poller for symbols. Each round runs delay after a
+// candle boundary (a multiple of interval) to give upstream time to
+// publish the closed bar. Rounds are skipped while calendar says the
+// market is not trading, except the first one after it closes.
+func NewPoller(svc *service.IntradayService, calendar *service.Calendar, symbols []string, interval, delay time.Duration, concurrency int, logger *zap.Logger) *Poller {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Poller{
+		svc:         svc,
+		calendar:    calendar,
+		symbols:     symbols,
+		interval:    interval,
+		delay:       delay,
+		concurrency: concurrency,
+		logger:      logger,
+		watched:     make(map[string]int),
+	}
+}
+
+// Watch adds symbols to every round until the returned release is called.
+// Watches are counted, so overlapping watchers of one symbol are fine.
+func (p *Poller) Watch(symbols []string) (release func()) {
+	p.watchMu.Lock()
+	for _, s := range symbols {
+		p.watched[s]++
+	}
+	p.watchMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.watchMu.Lock()
+			defer p.watchMu.Unlock()
+			for _, s := range symbols {
+				if p.watched[s]--; p.watched[s] <= 0 {
+					delete(p.watched, s)
+				}
+			}
+		})
+	}
+}
+
+// roundSymbols returns the static symbols plus any watched ones.
+func (p *Poller) roundSymbols() []string {
+	p.watchMu.Lock()
+	defer p.watchMu.Unlock()
+	if len(p.watched) == 0 {
+		return p.symbols
+	}
+	seen := make(map[string]bool, len(p.symbols)+len(p.watched))
+	out := make([]string, 0, len(p.symbols)+len(p.watched))
+	for _, s := range p.symbols {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	for s := range p.watched {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Start runs an initial round immediately, then one per candle close,
+// until Stop is called or ctx is cancelled.
+func (p *Poller) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		p.pollAll(ctx)
+		for {
+			timer := time.NewTimer(p.untilNext(time.Now()))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				p.pollAll(ctx)
+			}
+		}
+	}()
+
+	p.logger.Info("poller started",
+		zap.Int("symbols", len(p.symbols)),
+		zap.Duration("interval", p.interval),
+	)
+}
+
+// Stop cancels in-flight polls and waits for the loop to exit.
+func (p *Poller) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+	p.logger.Info("poller stopped")
+}
+
+// untilNext returns the wait until the next candle boundary plus delay.
+func (p *Poller) untilNext(now time.Time) time.Duration {
+	next := now.Truncate(p.interval).Add(p.delay)
+	if !next.After(now) {
+		next = next.Add(p.interval)
+	}
+	return next.Sub(now)
+}
+
+func (p *Poller) pollAll(ctx context.Context) {
+	start := time.Now()
+	// The round right after the close still runs to pick up the last bar.
+	if !p.calendar.Trading(start) && !p.calendar.Trading(start.Add(-p.interval-p.delay)) {
+		p.logger.Debug("market closed, poll round skipped", zap.String("status", p.calendar.Status(start)))
+		return
+	}
+	// Symbols stay fresh until the round after next would have finished,
+	// so one slow or failed round doesn't send HTTP reads upstream.
+	freshFor := 2*p.interval + p.delay
+	symbols := p.roundSymbols()
+	if len(symbols) == 0 {
+		return
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.concurrency)
+
+	var mu sync.Mutex
+	failed := 0
+	for _, sym := range symbols {
+		g.Go(func() error {
+			if err := p.svc.Refresh(ctx, sym, freshFor); err != nil {
+				if ctx.Err() == nil {
+					p.logger.Warn("poll failed", zap.String("symbol", sym), zap.Error(err))
+				}
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+			// Never abort the round for one bad symbol
+			return nil
+		})
+	}
+	g.Wait()
+
+	p.logger.Debug("poll round complete",
+		zap.Int("symbols", len(symbols)),
+		zap.Int("failed", failed),
+		zap.Duration("took", time.Since(start)),
+	)
+}
@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"marketpulse/internal/domain/entity"
+	"marketpulse/internal/domain/service"
+	"marketpulse/internal/infra/feed"
+	"marketpulse/pkg/rsi"
+)
+
+// memStates is an in-memory service.StateRepository.
+type memStates struct {
+	mu     sync.Mutex
+	states map[string]rsi.CompactRSI
+}
+
+func (m *memStates) GetOrUpdate(_ context.Context, symbol string) (*rsi.CompactRSI, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.states[symbol]
+	return &st, nil
+}
+
+func (m *memStates) Save(_ context.Context, symbol string, st *rsi.CompactRSI) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[symbol] = *st
+	return nil
+}
+
+func (m *memStates) All(context.Context) (map[string]*rsi.CompactRSI, error) { return nil, nil }
+
+func (m *memStates) GetMany(context.Context, []string) (map[string]*rsi.CompactRSI, error) {
+	return nil, nil
+}
+
+func (m *memStates) SaveMany(context.Context, map[string]*rsi.CompactRSI) error { return nil }
+
+// countingFeed serves the same three candles for every symbol and
+// records fetches.
+type countingFeed struct {
+	mu      sync.Mutex
+	fetched map[string]int
+}
+
+func (f *countingFeed) FetchIntraday(_ context.Context, symbol string, since time.Time) ([]feed.Candle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetched[symbol]++
+	if symbol == "BAD" {
+		return nil, fmt.Errorf("upstream down")
+	}
+	var out []feed.Candle
+	for i, c := range []float64{99, 101, 100} {
+		ts := time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC).Add(-time.Duration(i) * 5 * time.Minute)
+		if ts.After(since) {
+			out = append(out, feed.Candle{Timestamp: ts, Open: c, High: c, Low: c, Close: c})
+		}
+	}
+	return out, nil
+}
+
+func (f *countingFeed) symbols() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]string, 0, len(f.fetched))
+	for s := range f.fetched {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func newTestPoller(t *testing.T, calendar *service.Calendar, symbols ...string) (*Poller, *countingFeed) {
+	t.Helper()
+	policy, err := service.NewSymbolPolicy("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := &countingFeed{fetched: map[string]int{}}
+	svc := service.NewIntradayService(&memStates{states: map[string]rsi.CompactRSI{}}, fd, policy, nil)
+	return NewPoller(svc, calendar, symbols, 5*time.Minute, 10*time.Second, 2, zap.NewNop()), fd
+}
+
+func TestUntilNext(t *testing.T) {
+	p, _ := newTestPoller(t, nil)
+	at := func(clock string) time.Time {
+		ts, err := time.Parse("15:04:05", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	cases := []struct {
+		now  string
+		want time.Duration
+	}{
+		{"14:32:00", 3*time.Minute + 10*time.Second},
+		{"14:35:05", 5 * time.Second},
+		// Exactly on the boundary plus delay: that round just ran
+		{"14:35:10", 5 * time.Minute},
+		{"14:39:59", 11 * time.Second},
+	}
+	for _, c := range cases {
+		if got := p.untilNext(at(c.now)); got != c.want {
+			t.Errorf("untilNext(%s) = %v, want %v", c.now, got, c.want)
+		}
+	}
+}
+
+func TestWatchCountsWatchers(t *testing.T) {
+	p, _ := newTestPoller(t, nil, "IBM", "AAPL")
+	round := func() []string {
+		out := append([]string(nil), p.roundSymbols()...)
+		sort.Strings(out)
+		return out
+	}
+
+	release1 := p.Watch([]string{"IBM", "MSFT"})
+	release2 := p.Watch([]string{"MSFT"})
+	if got, want := round(), []string{"AAPL", "IBM", "MSFT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("round %v, want %v", got, want)
+	}
+
+	release1()
+	release1() // releasing twice must not drop the other watcher
+	if got, want := round(), []string{"AAPL", "IBM", "MSFT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after one release: round %v, want %v", got, want)
+	}
+	release2()
+	if got, want := round(), []string{"AAPL", "IBM"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after all releases: round %v, want %v", got, want)
+	}
+}
+
+func TestPollAllRefreshesSymbols(t *testing.T) {
+	p, fd := newTestPoller(t, nil, "IBM", "BAD")
+	release := p.Watch([]string{"msft"})
+	defer release()
+
+	p.pollAll(context.Background())
+
+	// One failing symbol does not stop the round
+	if got, want := fd.symbols(), []string{"BAD", "IBM", "MSFT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("fetched %v, want %v", got, want)
+	}
+
+	// Polled symbols are then served without calling upstream
+	if _, err := p.svc.GetIntraday(context.Background(), entity.IntradayRequest{Symbol: "IBM"}); err != nil {
+		t.Fatal(err)
+	}
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if n := fd.fetched["IBM"]; n != 2 {
+		t.Errorf("IBM fetched %d times, want the poller's seed and incremental fetch only", n)
+	}
+}
+
+func TestPollAllSkipsClosedMarket(t *testing.T) {
+	// Every day around now is a holiday
+	now := time.Now().UTC()
+	var days []string
+	for d := -2; d <= 2; d++ {
+		days = append(days, fmt.Sprintf("%q", now.AddDate(0, 0, d).Format("2006-01-02")))
+	}
+	path := filepath.Join(t.TempDir(), "holidays.json")
+	body := fmt.Sprintf(`{"holidays": [%s]}`, strings.Join(days, ", "))
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := service.NewCalendar(service.CalendarConfig{
+		Location: "UTC", PreOpen: "00:00", Open: "00:00", Close: "23:59", PostClose: "23:59",
+		HolidaysFile: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, fd := newTestPoller(t, cal, "IBM")
+	p.pollAll(context.Background())
+	if got := fd.symbols(); len(got) != 0 {
+		t.Errorf("fetched %v while the market was closed", got)
+	}
+}
@@ -32,6 +32,27 @@ PRICE_STATE: dict[str, float] = {
 # symbol -> interval -> response
 CACHE: dict[str, dict[str, dict]] = {}
 
+# Reference data for SYMBOL_SEARCH / OVERVIEW. Symbols auto-registered by
+# TIME_SERIES_INTRADAY get a generic entry.
+REFERENCE: dict[str, dict[str, str]] = {
+    "IBM": {"Name": "International Business Machines", "Exchange": "NYSE"},
+    "AAPL": {"Name": "Apple Inc", "Exchange": "NASDAQ"},
+    "MSFT": {"Name": "Microsoft Corporation", "Exchange": "NASDAQ"},
+    "AMZN": {"Name": "Amazon.com Inc", "Exchange": "NASDAQ"},
+    "GOOGL": {"Name": "Alphabet Inc Class A", "Exchange": "NASDAQ"},
+    "TSLA": {"Name": "Tesla Inc", "Exchange": "NASDAQ"},
+    "NVDA": {"Name": "NVIDIA Corporation", "Exchange": "NASDAQ"},
+    "JPM": {"Name": "JPMorgan Chase & Co", "Exchange": "NYSE"},
+}
+
+
+def reference(symbol: str) -> dict[str, str] | None:
+    if symbol in REFERENCE:
+        return REFERENCE[symbol]
+    if symbol in SYMBOLS:
+        return {"Name": symbol, "Exchange": "NASDAQ"}
+    return None
+
 # ─────────────────────────────────────────────
 # Candle Generation
 # ─────────────────────────────────────────────
@@ -111,6 +132,10 @@ async def price_engine():
 
 @app.on_event("startup")
 async def startup():
+    # Price every catalogue symbol so OVERVIEW and GLOBAL_QUOTE agree
+    for symbol in REFERENCE:
+        SYMBOLS.add(symbol)
+        PRICE_STATE.setdefault(symbol, random.uniform(50, 500))
     asyncio.create_task(price_engine())
 
 # ─────────────────────────────────────────────
@@ -126,22 +151,102 @@ async def server_timing_middleware(request, call_next):
     return response
 
 # ─────────────────────────────────────────────
-# Ultra-fast API Endpoint
+# API Endpoint (dispatch on function)
 # ─────────────────────────────────────────────
 @app.get("/query")
-async def intraday(
+async def query(
     function: str = Query(...),
-    symbol: str = Query(...),
-    interval: str = Query(...),
     apikey: str = Query(...),
-    tail: int | None = Query(None, ge=1),  # 👈 NEW
+    symbol: str | None = Query(None),
+    interval: str | None = Query(None),
+    keywords: str | None = Query(None),
+    tail: int | None = Query(None, ge=1),
 ):
-    if function != "TIME_SERIES_INTRADAY":
-        return JSONResponse(status_code=400, content={"error": "Invalid function"})
-
     if apikey != "demo":
         return JSONResponse(status_code=403, content={"error": "Invalid API key"})
 
+    if function == "TIME_SERIES_INTRADAY":
+        if not symbol or not interval:
+            return JSONResponse(status_code=400, content={"error": "symbol and interval required"})
+        return intraday(symbol, interval, tail)
+    if function == "SYMBOL_SEARCH":
+        if keywords is None:
+            return JSONResponse(status_code=400, content={"error": "keywords required"})
+        return symbol_search(keywords)
+    if function in ("OVERVIEW", "GLOBAL_QUOTE"):
+        if not symbol:
+            return JSONResponse(status_code=400, content={"error": "symbol required"})
+        return overview(symbol) if function == "OVERVIEW" else global_quote(symbol)
+
+    return JSONResponse(status_code=400, content={"error": "Invalid function"})
+
+
+def symbol_search(keywords: str) -> JSONResponse:
+    kw = keywords.strip().upper()
+    matches = []
+    for sym in sorted(set(REFERENCE) | SYMBOLS):
+        ref = reference(sym)
+        name = ref["Name"].upper()
+        if not kw or not (sym.startswith(kw) or kw in name):
+            continue
+        score = 1.0 if sym == kw else round(len(kw) / max(len(sym), len(name)), 4)
+        matches.append({
+            "1. symbol": sym,
+            "2. name": ref["Name"],
+            "3. type": "Equity",
+            "4. region": "United States",
+            "5. marketOpen": "09:30",
+            "6. marketClose": "16:00",
+            "7. timezone": "UTC-04",
+            "8. currency": "USD",
+            "9. matchScore": f"{score:.4f}",
+        })
+    matches.sort(key=lambda m: m["9. matchScore"], reverse=True)
+    return JSONResponse(content={"bestMatches": matches[:10]})
+
+
+def overview(symbol: str) -> JSONResponse:
+    ref = reference(symbol)
+    if ref is None:
+        return JSONResponse(content={})
+    return JSONResponse(content={
+        "Symbol": symbol,
+        "AssetType": "Common Stock",
+        "Name": ref["Name"],
+        "Exchange": ref["Exchange"],
+        "Currency": "USD",
+        "Country": "USA",
+    })
+
+
+def global_quote(symbol: str) -> JSONResponse:
+    series = CACHE.get(symbol, {}).get("1min")
+    if reference(symbol) is None or not series:
+        return JSONResponse(content={"Global Quote": {}})
+
+    candles = list(series["Time Series (1min)"].items())  # newest first
+    last_ts, last = candles[0]
+    prev_close = float(candles[-1][1]["1. open"])
+    price = float(last["4. close"])
+    change = price - prev_close
+    return JSONResponse(content={
+        "Global Quote": {
+            "01. symbol": symbol,
+            "02. open": candles[-1][1]["1. open"],
+            "03. high": f"{max(float(c['2. high']) for _, c in candles):.4f}",
+            "04. low": f"{min(float(c['3. low']) for _, c in candles):.4f}",
+            "05. price": f"{price:.4f}",
+            "06. volume": str(sum(int(c["5. volume"]) for _, c in candles)),
+            "07. latest trading day": last_ts[:10],
+            "08. previous close": f"{prev_close:.4f}",
+            "09. change": f"{change:.4f}",
+            "10. change percent": f"{change / prev_close * 100:.4f}%",
+        }
+    })
+
+
+# 🔥 O(1) hot path + optional tail
+def intraday(symbol: str, interval: str, tail: int | None) -> JSONResponse:
     if interval not in INTERVALS:
         return JSONResponse(status_code=400, content={"error": "Invalid interval"})
 
@@ -162,7 +267,6 @@ async def intraday(
             }
         )
 
-    # 🔥 O(1) hot path + optional tail
     response = symbol_cache[interval]
 
     if tail is not None:
@@ -0,0 +1,74 @@
+package rsi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Binary layout (little endian), version 1:
+//
+//	[0]      version
+//	[1:49]   AvgGain, AvgLoss, LastClose, PrevClose, RSI, ChangePct (float64 bits)
+//	[49:57]  LastTs as Unix nanoseconds (0 = zero time)
+//	[57:]    Count as uvarint
+//
+// Floats are stored as raw IEEE-754 bits so nothing is lost to decimal
+// formatting.
+const (
+	encodingV1    = 1
+	encodedFixLen = 1 + 6*8 + 8
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *CompactRSI) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, encodedFixLen, encodedFixLen+binary.MaxVarintLen64)
+	buf[0] = encodingV1
+
+	off := 1
+	for _, f := range []float64{s.AvgGain, s.AvgLoss, s.LastClose, s.PrevClose, s.RSI, s.ChangePct} {
+		binary.LittleEndian.PutUint64(buf[off:], math.Float64bits(f))
+		off += 8
+	}
+
+	var ts int64
+	if !s.LastTs.IsZero() {
+		ts = s.LastTs.UnixNano()
+	}
+	binary.LittleEndian.PutUint64(buf[off:], uint64(ts))
+
+	if s.Count < 0 {
+		return nil, fmt.Errorf("rsi: negative count %d", s.Count)
+	}
+	return binary.AppendUvarint(buf, uint64(s.Count)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *CompactRSI) UnmarshalBinary(b []byte) error {
+	if len(b) < encodedFixLen+1 {
+		return fmt.Errorf("rsi: encoded state too short (%d bytes)", len(b))
+	}
+	if b[0] != encodingV1 {
+		return fmt.Errorf("rsi: unknown encoding version %d", b[0])
+	}
+
+	off := 1
+	fields := []*float64{&s.AvgGain, &s.AvgLoss, &s.LastClose, &s.PrevClose, &s.RSI, &s.ChangePct}
+	for _, f := range fields {
+		*f = math.Float64frombits(binary.LittleEndian.Uint64(b[off:]))
+		off += 8
+	}
+
+	s.LastTs = time.Time{}
+	if ts := int64(binary.LittleEndian.Uint64(b[off:])); ts != 0 {
+		s.LastTs = time.Unix(0, ts).UTC()
+	}
+
+	cnt, n := binary.Uvarint(b[encodedFixLen:])
+	if n <= 0 {
+		return fmt.Errorf("rsi: invalid count encoding")
+	}
+	s.Count = int(cnt)
+	return nil
+}
@@ -0,0 +1,98 @@
+package rsi
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		st   CompactRSI
+	}{
+		{"zero", CompactRSI{}},
+		{"typical", CompactRSI{
+			AvgGain: 0.30000000000000004, AvgLoss: 1.0 / 3, Count: 1234,
+			LastTs: time.Date(2026, 10, 16, 15, 55, 0, 123456789, time.UTC), LastClose: 187.42,
+			PrevClose: 187.01, RSI: 47.36842105263158, ChangePct: -0.21875,
+		}},
+		// Decoded times are UTC; the instant must survive
+		{"local time", CompactRSI{Count: 14, LastTs: time.Date(2026, 3, 8, 3, 0, 0, 0, ny)}},
+		{"extremes", CompactRSI{AvgGain: math.MaxFloat64, AvgLoss: math.SmallestNonzeroFloat64, Count: math.MaxInt32, RSI: math.Inf(1)}},
+	}
+	for _, c := range cases {
+		b, err := c.st.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got CompactRSI
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !got.LastTs.Equal(c.st.LastTs) {
+			t.Errorf("%s: last ts = %v, want %v", c.name, got.LastTs, c.st.LastTs)
+		}
+		got.LastTs, c.st.LastTs = time.Time{}, time.Time{}
+		if got != c.st {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.st)
+		}
+	}
+}
+
+// States saved as JSON before the binary encoding (state exports, file
+// store records) decode to what the binary encoding now holds.
+func TestLegacyJSONMatchesBinary(t *testing.T) {
+	legacy := `{"avg_gain":0.30000000000000004,"avg_loss":0.3333333333333333,"rsi_count":57,` +
+		`"last_ts":"2026-10-16T15:55:00Z","last_close":187.42,"rsi":47.36842105263158}`
+	var fromJSON CompactRSI
+	if err := json.Unmarshal([]byte(legacy), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	b, err := fromJSON.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary CompactRSI
+	if err := fromBinary.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if fromBinary != fromJSON {
+		t.Errorf("binary %+v, json %+v", fromBinary, fromJSON)
+	}
+	if fromBinary.Count != 57 || fromBinary.PrevClose != 0 || fromBinary.WarmupStatus() != Stable {
+		t.Errorf("decoded %+v", fromBinary)
+	}
+}
+
+func TestUnmarshalBinaryRejects(t *testing.T) {
+	good, _ := (&CompactRSI{Count: 300}).MarshalBinary()
+	future := append([]byte{2}, good[1:]...)
+	badCount := append(append([]byte(nil), good[:encodedFixLen]...), 0x80) // unterminated uvarint
+
+	cases := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{"empty", nil, "too short"},
+		{"truncated", good[:encodedFixLen], "too short"},
+		{"unknown version", future, "unknown encoding version 2"},
+		{"bad count", badCount, "invalid count"},
+	}
+	for _, c := range cases {
+		var st CompactRSI
+		if err := st.UnmarshalBinary(c.b); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+		}
+	}
+	if _, err := (&CompactRSI{Count: -1}).MarshalBinary(); err == nil {
+		t.Error("negative count encoded")
+	}
+}
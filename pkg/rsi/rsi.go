@@ -78,9 +78,20 @@ func (s *CompactRSI) UpdateIncremental(candle feed.Candle) bool {
 
 // SeedFromHistory computes SMA seed over first 14, then Wilder smoothing
 func (s *CompactRSI) SeedFromHistory(candles []feed.Candle) {
+    s.SeedFromHistoryFunc(candles, nil)
+}
+
+// SeedFromHistoryFunc is SeedFromHistory, calling fn with the state after
+// each candle once the state covers it: from index 14 when seeding with
+// the SMA, from index 1 otherwise. candles is sorted oldest first in place,
+// and i indexes the sorted slice.
+func (s *CompactRSI) SeedFromHistoryFunc(candles []feed.Candle, fn func(i int, s *CompactRSI)) {
     if len(candles) == 0 {
         return
     }
+    if fn == nil {
+        fn = func(int, *CompactRSI) {}
+    }
 
     // Reverse to chronological (oldest first) - feed returns newest first
     sort.Slice(candles, func(i, j int) bool {
@@ -89,13 +100,15 @@ func (s *CompactRSI) SeedFromHistory(candles []feed.Candle) {
 
     s.Count = 0
     s.AvgGain, s.AvgLoss = 0, 0
+    s.PrevClose, s.RSI, s.ChangePct = 0, 0, 0
     s.LastClose = candles[0].Close
     s.LastTs = candles[0].Timestamp
 
-    // First 14: simple average (SMA) for seed
-    if len(candles) >= 14 {
+    // First 14 changes: simple average (SMA) for seed. They take 15
+    // candles; with 14 there are only 13 changes.
+    if len(candles) > 14 {
         gains, losses := make([]float64, 14), make([]float64, 14)
-        for i := 1; i <= 14 && i < len(candles); i++ {
+        for i := 1; i <= 14; i++ {
             change := candles[i].Close - candles[i-1].Close
             gains[i-1] = math.Max(change, 0)
             losses[i-1] = math.Max(-change, 0)
@@ -105,10 +118,23 @@ func (s *CompactRSI) SeedFromHistory(candles []feed.Candle) {
         s.Count = 14
         s.LastClose = candles[14].Close
         s.LastTs = candles[14].Timestamp
+        // Fill in what UpdateIncremental would have, so a 15 candle
+        // history already reports an RSI
+        s.PrevClose = candles[13].Close
+        if s.PrevClose != 0 {
+            s.ChangePct = (s.LastClose - s.PrevClose) / s.PrevClose * 100
+        }
+        if s.AvgLoss > 0 {
+            s.RSI = 100 - (100/(1+s.AvgGain/s.AvgLoss))
+        } else {
+            s.RSI = 100
+        }
+        fn(14, s)
     } else {
-        // Less than 14: incremental only
+        // 14 or fewer: incremental only
         for i := 1; i < len(candles); i++ {
             s.UpdateIncremental(candles[i])
+            fn(i, s)
         }
         return
     }
@@ -116,6 +142,7 @@ func (s *CompactRSI) SeedFromHistory(candles []feed.Candle) {
     // Remaining candles: Wilder smoothing
     for i := 15; i < len(candles); i++ {
         s.UpdateIncremental(candles[i])
+        fn(i, s)
     }
 }
 
@@ -0,0 +1,102 @@
+package rsi
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"marketpulse/internal/infra/feed"
+)
+
+// zigzag returns n candles, newest first as the feed sends them, whose
+// closes alternate +2, -1: 100, 102, 101, 103, 102, ...
+func zigzag(n int) []feed.Candle {
+	t0 := time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC)
+	candles := make([]feed.Candle, n)
+	for i := 0; i < n; i++ {
+		c := 100 + float64(i/2)
+		if i%2 == 1 {
+			c += 2
+		}
+		candles[n-1-i] = feed.Candle{Timestamp: t0.Add(time.Duration(i) * time.Minute), Close: c}
+	}
+	return candles
+}
+
+func TestSeedFromHistoryFunc(t *testing.T) {
+	cases := []struct {
+		n         int
+		count     int
+		firstCall int
+		status    WarmupState
+		rsi       float64 // 0 skips the check
+	}{
+		// 12 and 13 changes: too few for the SMA seed
+		{n: 13, count: 12, firstCall: 1, status: Processing},
+		{n: 14, count: 13, firstCall: 1, status: Processing},
+		// 14 changes, 7 gains of 2 and 7 losses of 1: 100 - 100/(1+2)
+		{n: 15, count: 14, firstCall: 14, status: Warming, rsi: 100 - 100.0/3},
+		{n: 100, count: 99, firstCall: 14, status: Stable},
+	}
+	for _, c := range cases {
+		candles := zigzag(c.n)
+		var st CompactRSI
+		calls, first := 0, -1
+		st.SeedFromHistoryFunc(candles, func(i int, s *CompactRSI) {
+			if first < 0 {
+				first = i
+			}
+			calls++
+			if s.LastClose != candles[i].Close || !s.LastTs.Equal(candles[i].Timestamp) {
+				t.Errorf("n=%d: state at %d is at close %v, want %v", c.n, i, s.LastClose, candles[i].Close)
+			}
+		})
+
+		if st.Count != c.count {
+			t.Errorf("n=%d: count = %d, want %d", c.n, st.Count, c.count)
+		}
+		if first != c.firstCall || calls != c.n-c.firstCall {
+			t.Errorf("n=%d: fn called %d times from %d, want %d from %d", c.n, calls, first, c.n-c.firstCall, c.firstCall)
+		}
+		if got := st.WarmupStatus(); got != c.status {
+			t.Errorf("n=%d: status = %s, want %s", c.n, got, c.status)
+		}
+		if c.rsi != 0 && math.Abs(st.RSI-c.rsi) > 1e-9 {
+			t.Errorf("n=%d: rsi = %v, want %v", c.n, st.RSI, c.rsi)
+		}
+
+		// The final bar is a -1 or +2 move off the previous close
+		last := candles[c.n-1]
+		if st.LastClose != last.Close || st.PrevClose != candles[c.n-2].Close {
+			t.Errorf("n=%d: last/prev close = %v/%v, want %v/%v", c.n, st.LastClose, st.PrevClose, last.Close, candles[c.n-2].Close)
+		}
+		if want := (st.LastClose - st.PrevClose) / st.PrevClose * 100; math.Abs(st.ChangePct-want) > 1e-9 {
+			t.Errorf("n=%d: change = %v, want %v", c.n, st.ChangePct, want)
+		}
+	}
+}
+
+// Seeding continues exactly as incremental updates would.
+func TestSeedMatchesIncremental(t *testing.T) {
+	candles := zigzag(40)
+	var seeded CompactRSI
+	seeded.SeedFromHistory(candles) // sorts candles oldest first
+
+	var stepped CompactRSI
+	stepped.SeedFromHistory(append([]feed.Candle(nil), candles[:15]...))
+	for i := 15; i < len(candles); i++ {
+		stepped.UpdateIncremental(candles[i])
+	}
+	if seeded != stepped {
+		t.Errorf("seeded %+v, stepped %+v", seeded, stepped)
+	}
+}
+
+// Reseeding starts over rather than carrying values from before.
+func TestSeedResets(t *testing.T) {
+	st := CompactRSI{RSI: 55, ChangePct: 3, PrevClose: 90, Count: 200}
+	st.SeedFromHistory(zigzag(1))
+	if st.RSI != 0 || st.ChangePct != 0 || st.PrevClose != 0 || st.Count != 0 {
+		t.Errorf("state after one candle = %+v", st)
+	}
+}
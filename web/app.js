@@ -1,5 +1,5 @@
 // Updated frontend - handles new warmup_status, seeded_candles, better UX
-const API_BASE = "http://localhost:8080";
+const API_BASE = "http://localhost:8080/v1";
 let token = localStorage.getItem("token") || "";
 let pollTimer = null;
 let chart = null;
@@ -26,6 +26,12 @@ function showError(msg) {
   if (status) status.textContent = msg;
 }
 
+// showInfo writes to the neutral line under the header
+function showInfo(msg) {
+  const info = qs("symbolInfo");
+  if (info) info.textContent = msg;
+}
+
 async function apiFetch(path, opts = {}) {
   const headers = Object.assign({}, opts.headers || {});
   if (!headers["Content-Type"] && opts.body)
@@ -42,10 +48,14 @@ async function apiFetch(path, opts = {}) {
   }
 
   if (!res.ok) {
+    const err = data && data.error;
     const msg =
-      (data && (data.error || data.message)) ||
+      (err && (err.message || err)) ||
+      (data && data.message) ||
       `HTTP ${res.status} ${res.statusText}`;
-    throw new Error(msg);
+    const e = new Error(msg);
+    e.code = err && err.code;
+    throw e;
   }
   return data;
 }
@@ -67,11 +77,35 @@ function logout() {
   setLoggedOutUI();
 }
 
+// Symbol last checked against /market/symbols/{symbol}; a new one is
+// looked up once so typos fail with SYMBOL_NOT_FOUND instead of being
+// polled. Any other lookup failure (upstream down, rate limited) leaves
+// polling alone.
+let knownSymbol = "";
+
+async function checkSymbol(symbol) {
+  symbol = symbol.toUpperCase();
+  if (symbol === knownSymbol) return;
+  try {
+    const info = await apiFetch(`/market/symbols/${encodeURIComponent(symbol)}`);
+    showInfo(`${info.symbol}: ${info.name}${info.exchange ? ` (${info.exchange})` : ""}`);
+  } catch (e) {
+    if (e.code === "SYMBOL_NOT_FOUND") {
+      showInfo("");
+      throw e;
+    }
+    showInfo(`${symbol}: reference data unavailable`);
+  }
+  knownSymbol = symbol;
+}
+
 async function poll() {
   const symbol = (qs("symbol")?.value || "NVDA").trim();
   const rsiLow = parseFloat(qs("rsiLow")?.value || "30");
   const rsiHigh = parseFloat(qs("rsiHigh")?.value || "70");
 
+  await checkSymbol(symbol);
+
   const data = await apiFetch(
     `/market/intraday/${encodeURIComponent(
       symbol